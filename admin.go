@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v28/github"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mendersoftware/integration-test-runner/logger"
+	"github.com/mendersoftware/integration-test-runner/tracing"
+)
+
+// Admin subcommands, triggering the same actions as the bot commands
+// without a GitHub comment
+const (
+	subcommandPipeline   = "pipeline"
+	subcommandCherryPick = "cherry-pick"
+	subcommandSync       = "sync"
+
+	pipelineClient = "client"
+)
+
+const (
+	pipelineUsage = `usage: integration-test-runner pipeline client --repo <repo> --pr <number> [options]
+
+Starts the client pipelines for a pull request, like the "` + commandStartClientPipeline + `" command.
+
+options:
+`
+	cherryPickUsage = `usage: integration-test-runner cherry-pick --repo <repo> --pr <number> --to <branch> [options]
+
+Cherry-picks a pull request to the given branches and opens the pull requests,
+like the "` + commandCherryPickBranch + `" command.
+
+options:
+`
+	syncUsage = `usage: integration-test-runner sync --repo <repo> [options]
+
+Syncs a ref of a GitHub repository to GitLab, like a push event.
+
+options:
+`
+)
+
+// adminFlags are the flags common to all the admin subcommands
+type adminFlags struct {
+	*flag.FlagSet
+
+	org      *string
+	dryRun   *bool
+	gitRules *string
+	format   *string
+}
+
+func newAdminFlags(name, usage string) *adminFlags {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	f := &adminFlags{
+		FlagSet: flags,
+		org:     flags.String("org", "mendersoftware", "GitHub organization of the repository"),
+		dryRun: flags.Bool("dry-run", false,
+			"do not perform any change, print the side-effect journal instead"),
+		gitRules: flags.String("git-rules", "",
+			"JSON file with the rules scripting the output of the git commands in dry-run mode"),
+		format: flags.String("format", logsFormatMessages,
+			"journal format in dry-run mode: "+logsFormatMessages+" or "+logsFormatEvents),
+	}
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	return f
+}
+
+// parse parses the arguments, failing if any of the required string or int
+// flags is not set
+func (f *adminFlags) parse(args []string, required ...string) error {
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	if f.NArg() > 0 {
+		f.Usage()
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(f.Args(), " "))
+	}
+	for _, name := range required {
+		if value := f.Lookup(name).Value.String(); value == "" || value == "0" {
+			f.Usage()
+			return fmt.Errorf("--%s is required", name)
+		}
+	}
+	if *f.format != logsFormatMessages && *f.format != logsFormatEvents {
+		return fmt.Errorf("unknown format %q", *f.format)
+	}
+	return nil
+}
+
+// run sets up the configuration for the subcommand and runs the action; in
+// dry-run mode the side-effect journal is written to out
+func (f *adminFlags) run(
+	out io.Writer,
+	action func(ctx *gin.Context, log *logrus.Entry, conf *config) error,
+) error {
+	conf, requestLogger, flushTracing, err := setupCommand(*f.dryRun, *f.gitRules)
+	if err != nil {
+		return err
+	}
+	defer flushTracing()
+	conf.githubOrganization = *f.org
+
+	ctx := newCommandContext(f.Name())
+	spanCtx, span := tracing.Start(context.Background(), "command "+f.Name())
+	ctx.Set(tracing.ContextKey, spanCtx)
+	log := getCustomLoggerFromContext(ctx)
+	done := requestLogger.StartDelivery(f.Name())
+	err = action(ctx, log, conf)
+	done()
+	tracing.End(span, err)
+
+	if conf.dryRunMode {
+		if err := writeJournal(out, requestLogger.Entries(logger.Filter{}), *f.format); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func runPipelineCommand(args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] != pipelineClient {
+		fmt.Fprint(flag.CommandLine.Output(), pipelineUsage)
+		return fmt.Errorf("unknown pipeline, expected: %s %s", subcommandPipeline, pipelineClient)
+	}
+	flags := newAdminFlags(subcommandPipeline+" "+pipelineClient, pipelineUsage)
+	repo := flags.String("repo", "", "name of the repository")
+	number := flags.Int("pr", 0, "number of the pull request")
+	fast := flags.Bool("fast", false, "start the pipelines with the --fast option")
+	noSupersede := flags.Bool("no-supersede", false,
+		"keep running the previous pipelines of the same builds")
+	var releases, with stringsFlag
+	flags.Var(&releases, "release",
+		"start the pipeline for this Mender Client release only (can be given multiple times)")
+	flags.Var(&with, "with",
+		"also build this pull request or branch, e.g. mender-connect/255 "+
+			"(can be given multiple times)")
+	if err := flags.parse(args[1:], "repo", "pr"); err != nil {
+		return err
+	}
+
+	// same syntax as the options of the comment command
+	options := []string{commandStartClientPipeline}
+	for _, rev := range with {
+		options = append(options, "--pr", rev)
+	}
+	for _, release := range releases {
+		options = append(options, "--release", release)
+	}
+	if *fast {
+		options = append(options, "--fast")
+	}
+	if *noSupersede {
+		options = append(options, "--no-supersede")
+	}
+	buildOptions, err := parseBuildOptions(strings.Join(options, " "))
+	if err != nil {
+		return err
+	}
+
+	return flags.run(out, func(ctx *gin.Context, log *logrus.Entry, conf *config) error {
+		prRequest, err := getPullRequestEvent(ctx, conf, *repo, *number)
+		if err != nil {
+			return err
+		}
+		return startClientPipelines(ctx, log, githubClient, conf, prRequest, buildOptions)
+	})
+}
+
+func runCherryPickCommand(args []string, out io.Writer) error {
+	flags := newAdminFlags(subcommandCherryPick, cherryPickUsage)
+	repo := flags.String("repo", "", "name of the repository")
+	number := flags.Int("pr", 0, "number of the pull request")
+	sender := flags.String("sender", "", "name to thank in the cherry-pick pull requests")
+	var targetBranches stringsFlag
+	flags.Var(&targetBranches, "to", "target branch (can be given multiple times)")
+	if err := flags.parse(args, "repo", "pr", "to"); err != nil {
+		return err
+	}
+
+	body := commandCherryPickBranch + "\n* " + strings.Join(targetBranches, "\n* ")
+	return flags.run(out, func(ctx *gin.Context, log *logrus.Entry, conf *config) error {
+		pr, err := githubClient.GetPullRequest(ctx, conf.githubOrganization, *repo, *number)
+		if err != nil {
+			return err
+		}
+		comment := &github.IssueCommentEvent{
+			Repo: &github.Repository{Name: repo},
+			Issue: &github.Issue{
+				Number: github.Int(pr.GetNumber()),
+				Title:  github.String(pr.GetTitle()),
+			},
+			Sender: &github.User{Login: sender, Name: sender},
+		}
+		log.Infof("Attempting to cherry-pick the changes in PR: %s/%d", *repo, pr.GetNumber())
+		return cherryPickPR(log, comment, pr, conf, body, githubClient)
+	})
+}
+
+func runSyncCommand(args []string, out io.Writer) error {
+	flags := newAdminFlags(subcommandSync, syncUsage)
+	repo := flags.String("repo", "", "name of the repository")
+	ref := flags.String("ref", "refs/heads/master", "ref to sync, e.g. refs/tags/1.0.0")
+	if err := flags.parse(args, "repo"); err != nil {
+		return err
+	}
+
+	return flags.run(out, func(ctx *gin.Context, log *logrus.Entry, conf *config) error {
+		if !strings.HasPrefix(*ref, "refs/") {
+			return errors.New("--ref must be a full ref, e.g. refs/heads/master")
+		}
+		log.Debugf("Syncing repo %s/%s", conf.githubOrganization, *repo)
+		return syncRemoteRef(log, conf.githubOrganization, *repo, *ref, conf)
+	})
+}
+
+// getPullRequestEvent returns a pull request event for an existing pull
+// request, like the ones built when processing a comment
+func getPullRequestEvent(
+	ctx *gin.Context,
+	conf *config,
+	repo string,
+	number int,
+) (*github.PullRequestEvent, error) {
+	pr, err := githubClient.GetPullRequest(ctx, conf.githubOrganization, repo, number)
+	if err != nil {
+		return nil, err
+	}
+	return &github.PullRequestEvent{
+		Repo: &github.Repository{
+			Name:     github.String(repo),
+			FullName: github.String(conf.githubOrganization + "/" + repo),
+			Owner:    &github.User{Login: github.String(conf.githubOrganization)},
+		},
+		Number:       github.Int(pr.GetNumber()),
+		PullRequest:  pr,
+		Organization: &github.Organization{Login: github.String(conf.githubOrganization)},
+	}, nil
+}
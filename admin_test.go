@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/integration-test-runner/git"
+)
+
+func setupAdminCommandTest(t *testing.T) *e2eEnvironment {
+	env := newE2EEnvironment(t)
+	t.Setenv("GITHUB_TOKEN", "github-token")
+	t.Setenv("GITHUB_BASE_URL", env.github.BaseURL())
+	t.Setenv("GITLAB_TOKEN", "gitlab-token")
+	t.Setenv("GITLAB_BASE_URL", env.gitlab.BaseURL())
+	t.Cleanup(func() {
+		git.SetDryRunMode(false)
+		git.SetDryRunExecutor(nil)
+		logrus.SetOutput(os.Stderr)
+	})
+	return env
+}
+
+func TestRunSyncCommand(t *testing.T) {
+	env := setupAdminCommandTest(t)
+	githubRepo := env.remote.GitHubRepo("cfengine", "core")
+	gitlabRepo := env.remote.GitLabRepo("Northern.tech/CFEngine/core")
+	env.remote.Init(githubRepo)
+	env.remote.Init(gitlabRepo)
+	sha := env.remote.Commit(githubRepo, "3.21.x", "README.md", "core", "initial commit")
+
+	out := &bytes.Buffer{}
+	err := runSyncCommand([]string{
+		"--org", "cfengine",
+		"--repo", "core",
+		"--ref", "refs/heads/3.21.x",
+	}, out)
+	assert.NoError(t, err)
+	assert.Empty(t, out.String())
+	assert.Equal(t, sha, env.remote.RefSHA(gitlabRepo, "refs/heads/3.21.x"))
+}
+
+func TestRunSyncCommandDryRun(t *testing.T) {
+	env := setupAdminCommandTest(t)
+	gitlabRepo := env.remote.GitLabRepo("Northern.tech/CFEngine/core")
+	env.remote.Init(gitlabRepo)
+
+	out := &bytes.Buffer{}
+	err := runSyncCommand([]string{"--org", "cfengine", "--repo", "core", "--dry-run"}, out)
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "git push -f gitlab master")
+	assert.Empty(t, env.remote.RefSHA(gitlabRepo, "refs/heads/master"))
+}
+
+func TestRunCherryPickCommandDryRun(t *testing.T) {
+	env := setupAdminCommandTest(t)
+	env.github.AddPullRequest("mendersoftware", "mender", &github.PullRequest{
+		Number: github.Int(7),
+		Title:  github.String("fix: a bug"),
+		State:  github.String("closed"),
+		Head: &github.PullRequestBranch{
+			Ref: github.String("fix-bug"),
+			SHA: github.String("1234567"),
+		},
+	})
+
+	out := &bytes.Buffer{}
+	err := runCherryPickCommand([]string{
+		"--repo", "mender",
+		"--pr", "7",
+		"--to", "4.0.x",
+		"--to", "5.0.x",
+		"--dry-run",
+	}, out)
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "git checkout mendersoftware/4.0.x")
+	assert.Contains(t, out.String(), "git checkout mendersoftware/5.0.x")
+	assert.Contains(t, out.String(),
+		`github.CreatePullRequest: org=mendersoftware,repo=mender,pr={"title":"[Cherry 5.0.x]: fix: a bug"`)
+	assert.Contains(t, out.String(), "github.CreateComment: org=mendersoftware,repo=mender,number=7")
+	assert.Empty(t, env.github.Comments("mendersoftware", "mender", 7))
+}
+
+func TestRunAdminCommandErrors(t *testing.T) {
+	setupAdminCommandTest(t)
+
+	testCases := map[string]struct {
+		run  subcommand
+		args []string
+		err  string
+	}{
+		"unknown pipeline": {
+			run:  runPipelineCommand,
+			args: []string{"integration", "--repo", "mender"},
+			err:  "unknown pipeline, expected: pipeline client",
+		},
+		"pipeline without pr": {
+			run:  runPipelineCommand,
+			args: []string{"client", "--repo", "mender"},
+			err:  "--pr is required",
+		},
+		"cherry-pick without target": {
+			run:  runCherryPickCommand,
+			args: []string{"--repo", "mender", "--pr", "7"},
+			err:  "--to is required",
+		},
+		"sync with positional argument": {
+			run:  runSyncCommand,
+			args: []string{"--repo", "mender", "master"},
+			err:  "unexpected arguments: master",
+		},
+		"sync with invalid ref": {
+			run:  runSyncCommand,
+			args: []string{"--repo", "mender", "--ref", "master", "--dry-run"},
+			err:  "--ref must be a full ref, e.g. refs/heads/master",
+		},
+		"unknown format": {
+			run:  runSyncCommand,
+			args: []string{"--repo", "mender", "--format", "xml"},
+			err:  `unknown format "xml"`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.run(tc.args, &bytes.Buffer{})
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/sirupsen/logrus"
+
+	clientgithub "github.com/mendersoftware/integration-test-runner/client/github"
+)
+
+// backportLabelPrefixes are the prefixes of the labels of the pull requests
+// cherry-picked to the branch after the prefix once merged, e.g.
+// backport/5.0.x
+var backportLabelPrefixes = []string{"backport/", "cherry-pick/"}
+
+// backportFailedLabelPrefix is the prefix of the labels replacing the
+// backport labels of the branches the cherry-pick failed for
+const backportFailedLabelPrefix = "backport-failed/"
+
+// getBackportBranch returns the target branch of a backport label, empty if
+// the label is not one
+func getBackportBranch(label string) string {
+	for _, prefix := range backportLabelPrefixes {
+		if branch, found := strings.CutPrefix(label, prefix); found && branch != "" {
+			return branch
+		}
+	}
+	return ""
+}
+
+// backportPR cherry-picks the merged pull request to the branches of its
+// backport labels: all of them when it is merged, the added one when it is
+// labeled after the merge. The labels of the failed cherry-picks are
+// replaced by backport-failed ones.
+func backportPR(
+	log *logrus.Entry,
+	pr *github.PullRequestEvent,
+	githubClient clientgithub.Client,
+	conf *config,
+) error {
+	req := pr.GetPullRequest()
+	if !req.GetMerged() {
+		return nil
+	}
+	labels := map[string]string{}
+	targetBranches := []string{}
+	addBranch := func(label string) {
+		if branch := getBackportBranch(label); branch != "" {
+			if _, exists := labels[branch]; !exists {
+				targetBranches = append(targetBranches, branch)
+			}
+			labels[branch] = label
+		}
+	}
+	switch pr.GetAction() {
+	case "closed":
+		for _, label := range req.Labels {
+			addBranch(label.GetName())
+		}
+	case "labeled":
+		addBranch(pr.GetLabel().GetName())
+	}
+	if len(targetBranches) == 0 {
+		return nil
+	}
+
+	log.Infof("Backporting PR %s/%d to %s", pr.GetRepo().GetName(), pr.GetNumber(),
+		strings.Join(targetBranches, ", "))
+	// the cherry-picks are done as if commented by the sender of the event
+	comment := &github.IssueCommentEvent{
+		Repo: pr.GetRepo(),
+		Issue: &github.Issue{
+			Number: github.Int(pr.GetNumber()),
+			Title:  github.String(req.GetTitle()),
+		},
+		Sender: &github.User{
+			Login: github.String(pr.GetSender().GetLogin()),
+			Name:  github.String(pr.GetSender().GetLogin()),
+		},
+	}
+	failed, err := cherryPickToBranches(log, comment, req, conf, targetBranches, githubClient)
+	for _, branch := range failed {
+		ctx := traceContext(log)
+		if err := githubClient.RemoveLabelFromPullRequest(ctx, conf.githubOrganization,
+			pr.GetRepo().GetName(), pr.GetNumber(), labels[branch]); err != nil {
+			log.Errorf("Failed to remove the label %s: %s", labels[branch], err.Error())
+		}
+		if err := githubClient.AddLabelsToPullRequest(ctx, conf.githubOrganization,
+			pr.GetRepo().GetName(), pr.GetNumber(),
+			[]string{backportFailedLabelPrefix + branch}); err != nil {
+			log.Errorf("Failed to add the label %s: %s", backportFailedLabelPrefix+branch,
+				err.Error())
+		}
+	}
+	return err
+}
+
+// isBackportMetadataLabel tells whether the label of a pull request is not
+// copied to its backports: the backport labels, which would backport them
+// again once merged, and the options selecting the pipelines of the original
+func isBackportMetadataLabel(label string) bool {
+	name := strings.ToLower(label)
+	return getBackportBranch(label) != "" ||
+		strings.HasPrefix(label, backportFailedLabelPrefix) ||
+		strings.HasPrefix(name, titleOptionRelease) ||
+		strings.HasPrefix(name, titleOptionWith)
+}
+
+// getBackportReviewers returns the approvers of the pull request, other than
+// its author, to request the review of its backports from
+func getBackportReviewers(
+	log *logrus.Entry,
+	conf *config,
+	repo string,
+	pr *github.PullRequest,
+	githubClient clientgithub.Client,
+) []string {
+	reviewers := []string{}
+	add := func(login string) {
+		if login != "" && login != githubBotName && login != pr.GetUser().GetLogin() &&
+			!slices.Contains(reviewers, login) {
+			reviewers = append(reviewers, login)
+		}
+	}
+	reviews, err := githubClient.ListReviews(traceContext(log), conf.githubOrganization, repo,
+		pr.GetNumber(), &github.ListOptions{PerPage: 100})
+	if err != nil {
+		log.Errorf("Failed to list the reviews of the PR: %s", err.Error())
+	}
+	for _, review := range reviews {
+		if review.GetState() == "APPROVED" {
+			add(review.GetUser().GetLogin())
+		}
+	}
+	return reviewers
+}
+
+// inheritBackportMetadata copies the labels and the assignees of the pull
+// request to its backport, and requests the review of the backport from the
+// author and the approvers of the pull request
+func inheritBackportMetadata(
+	log *logrus.Entry,
+	conf *config,
+	repo string,
+	pr *github.PullRequest,
+	backport *github.PullRequest,
+	githubClient clientgithub.Client,
+) {
+	ctx := traceContext(log)
+	labels := []string{}
+	for _, label := range pr.Labels {
+		if !isBackportMetadataLabel(label.GetName()) {
+			labels = append(labels, label.GetName())
+		}
+	}
+	if len(labels) > 0 {
+		if err := githubClient.AddLabelsToPullRequest(ctx, conf.githubOrganization, repo,
+			backport.GetNumber(), labels); err != nil {
+			log.Errorf("Failed to label the backport PR: %s", err.Error())
+		}
+	}
+	assignees := []string{}
+	for _, assignee := range pr.Assignees {
+		assignees = append(assignees, assignee.GetLogin())
+	}
+	if len(assignees) > 0 {
+		if err := githubClient.AssignPullRequest(ctx, conf.githubOrganization, repo,
+			backport.GetNumber(), assignees); err != nil {
+			log.Errorf("Failed to assign the backport PR: %s", err.Error())
+		}
+	}
+	// the author is requested on its own: GitHub rejects the whole request
+	// when the author is an outside contributor, who may not review
+	if author := pr.GetUser().GetLogin(); author != "" && author != githubBotName {
+		if err := githubClient.RequestReviewers(ctx, conf.githubOrganization, repo,
+			backport.GetNumber(), []string{author}); err != nil {
+			log.Errorf("Failed to request the review of the backport PR from its author: %s",
+				err.Error())
+		}
+	}
+	if reviewers := getBackportReviewers(log, conf, repo, pr, githubClient); len(reviewers) > 0 {
+		if err := githubClient.RequestReviewers(ctx, conf.githubOrganization, repo,
+			backport.GetNumber(), reviewers); err != nil {
+			log.Errorf("Failed to request the review of the backport PR: %s", err.Error())
+		}
+	}
+}
+
+// startBackportClientPipelines starts the client pipelines of the backport
+// of the pull request, for the releases of its target branch, unless the pull
+// request skips the CI or carries the client pipeline label, whose addition
+// to the backport starts them already
+func startBackportClientPipelines(
+	log *logrus.Entry,
+	conf *config,
+	repo *github.Repository,
+	pr *github.PullRequest,
+	backport *github.PullRequest,
+	targetBranch string,
+	headSHA string,
+) {
+	options := getPullRequestOptions(pr)
+	if options.SkipCI ||
+		(conf.clientPipelineLabel != "" && hasPullRequestLabel(pr, conf.clientPipelineLabel)) {
+		return
+	}
+	buildOptions := NewBuildOptions()
+	buildOptions.Fast = options.Fast
+	prRequest := &github.PullRequestEvent{
+		Action: github.String("opened"),
+		Repo:   repo,
+		Number: github.Int(backport.GetNumber()),
+		PullRequest: &github.PullRequest{
+			Number: github.Int(backport.GetNumber()),
+			Title:  backport.Title,
+			Head: &github.PullRequestBranch{
+				Ref: github.String(backport.GetHead().GetRef()),
+				SHA: github.String(headSHA),
+			},
+			Base: &github.PullRequestBranch{
+				Ref:   github.String(targetBranch),
+				Label: github.String("mendersoftware:" + targetBranch),
+			},
+		},
+	}
+	triggerClientBuilds(log, conf, prRequest, buildOptions)
+}
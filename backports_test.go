@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v28/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBackportBranch(t *testing.T) {
+	testCases := map[string]string{
+		"backport/5.0.x":        "5.0.x",
+		"cherry-pick/4.0.x":     "4.0.x",
+		"backport/":             "",
+		"backport-failed/5.0.x": "",
+		"cfengine:master":       "",
+	}
+	for label, branch := range testCases {
+		t.Run(label, func(t *testing.T) {
+			assert.Equal(t, branch, getBackportBranch(label))
+		})
+	}
+}
+
+// backportEnvironment returns a merged pull request of a repository with
+// the 2.6.x branch it cherry-picks cleanly to, and the 2.5.x one it
+// conflicts with
+func backportEnvironment(t *testing.T, action string) (*e2eEnvironment, *github.PullRequestEvent) {
+	env := newE2EEnvironment(t)
+	githubRepo := env.remote.GitHubRepo("mendersoftware", "core")
+	env.remote.Init(githubRepo)
+	baseSHA := env.remote.Commit(githubRepo, "master", "VERSION", "1", "initial commit")
+	env.remote.Git(githubRepo, "branch", "2.6.x", baseSHA)
+	env.remote.Git(githubRepo, "branch", "2.5.x", baseSHA)
+	env.remote.Commit(githubRepo, "2.5.x", "VERSION", "old", "diverge")
+	env.remote.Git(githubRepo, "branch", "fix-bug", baseSHA)
+	headSHA := env.remote.Commit(githubRepo, "fix-bug", "VERSION", "2",
+		"fix: a bug\n\nChangelog: Title\nTicket: None")
+
+	pr := env.pullRequestEvent(action, 7)
+	pr.Sender = &github.User{Login: github.String("alice")}
+	pr.PullRequest.Title = github.String("fix: a bug")
+	pr.PullRequest.Merged = github.Bool(true)
+	pr.PullRequest.Base.SHA = github.String(baseSHA)
+	pr.PullRequest.Head.SHA = github.String(headSHA)
+	for _, label := range []string{"backport/2.6.x", "cherry-pick/2.5.x"} {
+		pr.PullRequest.Labels = append(pr.PullRequest.Labels,
+			&github.Label{Name: github.String(label)})
+		require.NoError(t, githubClient.AddLabelsToPullRequest(context.Background(),
+			"cfengine", "core", 7, []string{label}))
+	}
+	return env, pr
+}
+
+func TestBackportPROnMerge(t *testing.T) {
+	env, pr := backportEnvironment(t, "closed")
+
+	err := backportPR(getCustomLoggerFromContext(&gin.Context{}), pr, githubClient, env.conf)
+	require.NoError(t, err)
+
+	prs := env.github.PullRequests("cfengine", "core")
+	require.Len(t, prs, 1)
+	assert.Equal(t, "[Cherry 2.6.x]: fix: a bug", prs[0].GetTitle())
+	assert.Equal(t, "2.6.x", prs[0].GetBase().GetRef())
+	comments := env.github.Comments("cfengine", "core", 7)
+	require.Len(t, comments, 1)
+	assert.Contains(t, comments[0].GetBody(), "* 2.6.x :heavy_check_mark: #")
+	assert.Contains(t, comments[0].GetBody(), "* 2.5.x Had merge conflicts")
+	assert.Equal(t, []string{"backport/2.6.x", "backport-failed/2.5.x"},
+		env.github.Labels("cfengine", "core", 7))
+}
+
+func TestBackportPRLabeledAfterMerge(t *testing.T) {
+	env, pr := backportEnvironment(t, "labeled")
+	pr.Label = &github.Label{Name: github.String("backport/2.6.x")}
+
+	err := backportPR(getCustomLoggerFromContext(&gin.Context{}), pr, githubClient, env.conf)
+	require.NoError(t, err)
+	prs := env.github.PullRequests("cfengine", "core")
+	require.Len(t, prs, 1)
+	assert.Equal(t, "2.6.x", prs[0].GetBase().GetRef())
+
+	// labeling before the merge does nothing, the merge backports
+	pr.PullRequest.Merged = github.Bool(false)
+	pr.Label = &github.Label{Name: github.String("backport/2.5.x")}
+	err = backportPR(getCustomLoggerFromContext(&gin.Context{}), pr, githubClient, env.conf)
+	require.NoError(t, err)
+	assert.Len(t, env.github.Comments("cfengine", "core", 7), 1)
+}
+
+func TestBackportPRInheritsMetadata(t *testing.T) {
+	env, pr := backportEnvironment(t, "closed")
+	pr.PullRequest.Body = github.String("Fixes #3")
+	pr.PullRequest.User = &github.User{Login: github.String("bob")}
+	pr.PullRequest.Assignees = []*github.User{{Login: github.String("bob")}}
+	for _, label := range []string{"bug", "release:5.0.x", "backport-failed/2.4.x"} {
+		pr.PullRequest.Labels = append(pr.PullRequest.Labels,
+			&github.Label{Name: github.String(label)})
+	}
+	for login, state := range map[string]string{
+		"carol":         "APPROVED",
+		"dave":          "COMMENTED",
+		githubBotName:   "APPROVED",
+		"bob":           "COMMENTED",
+		"not-approving": "CHANGES_REQUESTED",
+	} {
+		env.github.AddReview("cfengine", "core", 7, &github.PullRequestReview{
+			User:  &github.User{Login: github.String(login)},
+			State: github.String(state),
+		})
+	}
+
+	err := backportPR(getCustomLoggerFromContext(&gin.Context{}), pr, githubClient, env.conf)
+	require.NoError(t, err)
+
+	prs := env.github.PullRequests("cfengine", "core")
+	require.Len(t, prs, 1)
+	backport := prs[0].GetNumber()
+	assert.Equal(t, "Cherry pick of PR: #7\nFor you alice :)\n\n---\n\nFixes #3", prs[0].GetBody())
+	assert.Equal(t, []string{"bug"}, env.github.Labels("cfengine", "core", backport))
+	assert.Equal(t, []string{"bob"}, env.github.Assignees("cfengine", "core", backport))
+	assert.Equal(t, []string{"bob", "carol"}, env.github.Reviewers("cfengine", "core", backport))
+}
+
+func TestBackportPRReviewByOutsideAuthor(t *testing.T) {
+	env, pr := backportEnvironment(t, "closed")
+	pr.PullRequest.User = &github.User{Login: github.String("bob")}
+	env.github.AddOutsideContributor("cfengine", "core", "bob")
+	env.github.AddReview("cfengine", "core", 7, &github.PullRequestReview{
+		User:  &github.User{Login: github.String("carol")},
+		State: github.String("APPROVED"),
+	})
+
+	err := backportPR(getCustomLoggerFromContext(&gin.Context{}), pr, githubClient, env.conf)
+	require.NoError(t, err)
+
+	prs := env.github.PullRequests("cfengine", "core")
+	require.Len(t, prs, 1)
+	assert.Equal(t, []string{"carol"}, env.github.Reviewers("cfengine", "core", prs[0].GetNumber()))
+}
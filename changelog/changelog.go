@@ -0,0 +1,274 @@
+// Package changelog generates the changelog of a range of commits from the
+// Changelog and Ticket trailers and the conventional commit headers of their
+// messages, as the changelog-generator of the integration repository did
+package changelog
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Keywords of the Changelog trailer, instead of the text of the entry
+const (
+	// KeywordNone excludes the commit from the changelog
+	KeywordNone = "none"
+	// KeywordTitle makes the title of the commit the entry
+	KeywordTitle = "title"
+	// KeywordCommit makes the whole message of the commit the entry
+	KeywordCommit = "commit"
+	// KeywordAll is the same as KeywordCommit
+	KeywordAll = "all"
+)
+
+// changelogTypes are the conventional commit types making an entry of the
+// title when the commit has no Changelog trailer
+var changelogTypes = []string{"feat", "fix", "perf", "revert"}
+
+var (
+	conventionalHeaderPattern = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?: (.+)$`)
+	// trailerPattern matches the trailers ending the text of a Changelog
+	// trailer spanning multiple lines
+	trailerPattern = regexp.MustCompile(
+		`(?i)^(changelog|ticket|cancel-changelog|signed-off-by|co-authored-by|reviewed-by)\s*:\s*(.*)$`)
+	// numberPattern matches the numbers which may be ticket references
+	numberPattern = regexp.MustCompile(`(?:^|[^0-9A-Za-z.-])([0-9]{3,})(?:[^0-9A-Za-z.-]|$)`)
+)
+
+// Commit is a commit of the range
+type Commit struct {
+	SHA     string
+	Message string
+}
+
+// Entry is an entry of the changelog
+type Entry struct {
+	// Commit is the SHA of the commit the entry comes from
+	Commit string
+	// Type, Scope and Breaking are parsed from the conventional commit
+	// header, if any
+	Type     string
+	Scope    string
+	Breaking bool
+	// Text is the text of the entry, possibly spanning multiple lines
+	Text string
+	// Tickets are the tickets of the Ticket trailers, e.g. MEN-1234
+	Tickets []string
+}
+
+// Warning is a commit the changelog may have missed something of
+type Warning struct {
+	Commit string
+	// Number is the number in the message of a commit with changelog
+	// entries but no ticket, which may be a ticket reference
+	Number  string
+	Message string
+}
+
+// Changelog is the changelog of a range of commits
+type Changelog struct {
+	Entries  []Entry
+	Warnings []Warning
+}
+
+// LogArgs returns the arguments of the git log command listing the commits
+// of the range, e.g. "3.0.0..3.1.0", in the format ParseLog reads
+func LogArgs(versionRange string) []string {
+	return []string{"log", "--no-merges", "--format=%H%n%B%x00", versionRange}
+}
+
+// ParseLog parses the output of the git log command given by LogArgs
+func ParseLog(out []byte) []Commit {
+	commits := []Commit{}
+	for _, record := range strings.Split(string(out), "\x00") {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
+		}
+		sha, message, _ := strings.Cut(record, "\n")
+		commits = append(commits, Commit{SHA: sha, Message: message})
+	}
+	return commits
+}
+
+// Generate returns the changelog of the commits, its entries sorted by text
+func Generate(commits []Commit) *Changelog {
+	c := &Changelog{Entries: []Entry{}, Warnings: []Warning{}}
+	seen := map[string]bool{}
+	for _, commit := range commits {
+		entries := ParseCommit(commit)
+		for _, entry := range entries {
+			key := entry.Text + "\x00" + strings.Join(entry.Tickets, ",")
+			if !seen[key] {
+				seen[key] = true
+				c.Entries = append(c.Entries, entry)
+			}
+		}
+		if len(entries) > 0 && len(entries[0].Tickets) == 0 {
+			if match := numberPattern.FindStringSubmatch(commit.Message); match != nil {
+				c.Warnings = append(c.Warnings, Warning{
+					Commit:  commit.SHA,
+					Number:  match[1],
+					Message: commit.Message,
+				})
+			}
+		}
+	}
+	// the Cancel-Changelog trailers drop the entries of the commits they
+	// name, e.g. of a reverted commit
+	entries := c.Entries[:0]
+	for _, entry := range c.Entries {
+		if !isCancelled(commits, entry.Commit) {
+			entries = append(entries, entry)
+		}
+	}
+	c.Entries = entries
+	sort.SliceStable(c.Entries, func(i, j int) bool {
+		return c.Entries[i].Text < c.Entries[j].Text
+	})
+	return c
+}
+
+// Header is a conventional commit header, e.g. "feat(auth)!: add a token
+// refresh"
+type Header struct {
+	Type        string
+	Scope       string
+	Breaking    bool
+	Description string
+}
+
+// Message is a commit message split into its parts
+type Message struct {
+	Title string
+	// Header is the conventional commit header of the title, nil if the
+	// title is not one
+	Header *Header
+	// Changelogs are the texts of the Changelog trailers, possibly
+	// spanning multiple lines
+	Changelogs []string
+	// Tickets are the values of the Ticket trailers, split on commas and
+	// spaces and including the None keyword
+	Tickets []string
+	// Body is the message without the Changelog and Ticket trailers
+	Body []string
+}
+
+// ParseHeader parses the conventional commit header of a title
+func ParseHeader(title string) *Header {
+	match := conventionalHeaderPattern.FindStringSubmatch(strings.TrimSpace(title))
+	if match == nil {
+		return nil
+	}
+	return &Header{
+		Type:        strings.ToLower(match[1]),
+		Scope:       match[2],
+		Breaking:    match[3] != "",
+		Description: match[4],
+	}
+}
+
+// ParseMessage splits a commit message into its title, conventional commit
+// header and Changelog and Ticket trailers
+func ParseMessage(message string) *Message {
+	lines := strings.Split(strings.TrimRight(message, "\n"), "\n")
+	m := &Message{Title: strings.TrimSpace(lines[0])}
+	m.Header = ParseHeader(m.Title)
+
+	var (
+		current     []string
+		inChangelog bool
+	)
+	flush := func() {
+		if inChangelog {
+			m.Changelogs = append(m.Changelogs, strings.TrimSpace(strings.Join(current, "\n")))
+		}
+		current, inChangelog = nil, false
+	}
+	for _, line := range lines {
+		match := trailerPattern.FindStringSubmatch(line)
+		switch {
+		case match != nil:
+			flush()
+			value := strings.TrimSpace(match[2])
+			switch strings.ToLower(match[1]) {
+			case "changelog":
+				current, inChangelog = []string{value}, true
+			case "ticket":
+				m.Tickets = append(m.Tickets, strings.FieldsFunc(value, func(r rune) bool {
+					return r == ',' || r == ' '
+				})...)
+			}
+		case inChangelog && strings.TrimSpace(line) == "":
+			flush()
+			m.Body = append(m.Body, line)
+		case inChangelog:
+			current = append(current, line)
+		default:
+			m.Body = append(m.Body, line)
+		}
+	}
+	flush()
+	return m
+}
+
+// ParseCommit returns the changelog entries of a commit: one per Changelog
+// trailer, or one of the title of the conventional commits of the types
+// going in the changelog when the commit has none
+func ParseCommit(commit Commit) []Entry {
+	m := ParseMessage(commit.Message)
+	header := Entry{Commit: commit.SHA}
+	if m.Header != nil {
+		header.Type = m.Header.Type
+		header.Scope = m.Header.Scope
+		header.Breaking = m.Header.Breaking
+	}
+	var tickets []string
+	for _, ticket := range m.Tickets {
+		if !strings.EqualFold(ticket, KeywordNone) {
+			tickets = append(tickets, ticket)
+		}
+	}
+
+	texts := m.Changelogs
+	if len(texts) == 0 {
+		if header.Breaking || slices.Contains(changelogTypes, header.Type) {
+			texts = []string{m.Title}
+		}
+	}
+	entries := []Entry{}
+	for _, text := range texts {
+		switch strings.ToLower(text) {
+		case KeywordNone, "":
+			continue
+		case KeywordTitle:
+			text = m.Title
+		case KeywordCommit, KeywordAll:
+			text = strings.TrimSpace(strings.Join(m.Body, "\n"))
+		}
+		entry := header
+		entry.Text = text
+		entry.Tickets = tickets
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// isCancelled tells whether a Cancel-Changelog trailer of the commits names
+// the commit sha, possibly abbreviated
+func isCancelled(commits []Commit, sha string) bool {
+	for _, commit := range commits {
+		for _, line := range strings.Split(commit.Message, "\n") {
+			match := trailerPattern.FindStringSubmatch(line)
+			if match == nil || !strings.EqualFold(match[1], "cancel-changelog") {
+				continue
+			}
+			if cancelled := strings.TrimSpace(match[2]); len(cancelled) >= 7 &&
+				strings.HasPrefix(sha, cancelled) {
+				return true
+			}
+		}
+	}
+	return false
+}
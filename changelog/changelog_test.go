@@ -0,0 +1,165 @@
+package changelog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLog(t *testing.T) {
+	out := "1111111111111111111111111111111111111111\nfix: a bug\n\nChangelog: Title\n\x00\n" +
+		"2222222222222222222222222222222222222222\nchore: tidy\n\x00\n"
+	assert.Equal(t, []Commit{
+		{SHA: "1111111111111111111111111111111111111111", Message: "fix: a bug\n\nChangelog: Title\n"},
+		{SHA: "2222222222222222222222222222222222222222", Message: "chore: tidy\n"},
+	}, ParseLog([]byte(out)))
+	assert.Empty(t, ParseLog(nil))
+}
+
+func TestParseCommit(t *testing.T) {
+	testCases := map[string]struct {
+		message string
+		entries []Entry
+	}{
+		"changelog text": {
+			message: "Fix the sync\n\nChangelog: Add missing filesystem sync which could\n" +
+				"produce an empty tree.\nTicket: MEN-3420\nSigned-off-by: Alice <alice@example.com>\n",
+			entries: []Entry{{
+				Text:    "Add missing filesystem sync which could\nproduce an empty tree.",
+				Tickets: []string{"MEN-3420"},
+			}},
+		},
+		"changelog title": {
+			message: "Extend logs for docker module\n\nChangelog: Title\nTicket: None\n",
+			entries: []Entry{{Text: "Extend logs for docker module"}},
+		},
+		"changelog commit": {
+			message: "Implement support for tool names.\n\nThe tools can have other names.\n\n" +
+				"Changelog: Commit\nTicket: MEN-3978, MEN-3979\n",
+			entries: []Entry{{
+				Text:    "Implement support for tool names.\n\nThe tools can have other names.",
+				Tickets: []string{"MEN-3978", "MEN-3979"},
+			}},
+		},
+		"changelog none": {
+			message: "feat: something internal\n\nChangelog: None\n",
+			entries: []Entry{},
+		},
+		"multiple changelogs": {
+			message: "Two changes\n\nChangelog: First change\n\nChangelog: Second change\n",
+			entries: []Entry{{Text: "First change"}, {Text: "Second change"}},
+		},
+		"conventional feature": {
+			message: "feat(auth): add a token refresh\n\nTicket: MEN-1\n",
+			entries: []Entry{{
+				Type:    "feat",
+				Scope:   "auth",
+				Text:    "feat(auth): add a token refresh",
+				Tickets: []string{"MEN-1"},
+			}},
+		},
+		"conventional breaking": {
+			message: "refactor!: drop the v1 API\n",
+			entries: []Entry{{Type: "refactor", Breaking: true, Text: "refactor!: drop the v1 API"}},
+		},
+		"conventional chore": {
+			message: "chore: bump the dependencies\n",
+			entries: []Entry{},
+		},
+		"conventional with changelog": {
+			message: "fix: a crash\n\nChangelog: Fix a crash on startup with an empty config\n",
+			entries: []Entry{{Type: "fix", Text: "Fix a crash on startup with an empty config"}},
+		},
+		"no changelog": {
+			message: "Update the README\n",
+			entries: []Entry{},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			entries := ParseCommit(Commit{Message: tc.message})
+			assert.Equal(t, tc.entries, entries)
+		})
+	}
+}
+
+func TestParseMessage(t *testing.T) {
+	m := ParseMessage("feat(auth)!: add a token refresh\n\nThe tokens expire.\n\n" +
+		"Changelog: Refresh the tokens\nbefore they expire\nTicket: MEN-1, None\n")
+	assert.Equal(t, &Message{
+		Title: "feat(auth)!: add a token refresh",
+		Header: &Header{
+			Type:        "feat",
+			Scope:       "auth",
+			Breaking:    true,
+			Description: "add a token refresh",
+		},
+		Changelogs: []string{"Refresh the tokens\nbefore they expire"},
+		Tickets:    []string{"MEN-1", "None"},
+		Body:       []string{"feat(auth)!: add a token refresh", "", "The tokens expire.", ""},
+	}, m)
+	assert.Nil(t, ParseMessage("Update the README\n").Header)
+}
+
+func TestGenerateAndRender(t *testing.T) {
+	commits := []Commit{
+		{SHA: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", Message: "Support passing docker run CLI " +
+			"arguments\n\nChangelog: Support passing docker run CLI arguments when deploying\n" +
+			"an artifact using the `docker` _update module_.\n"},
+		{SHA: "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", Message: "Add missing filesystem sync after " +
+			"populating Update Module's file tree.\n\nChangelog: Add missing filesystem sync which " +
+			"could produce an empty or\ncorrupted Update Module file tree in\n" +
+			"`/var/lib/mender/modules/v3/payloads/0000/tree/files/` after an\nunexpected reboot.\n\n" +
+			"Signed-off-by: Kristian Amlie <kristian.amlie@northern.tech>\n"},
+		{SHA: "cccccccccccccccccccccccccccccccccccccccc", Message: "Implement support for non-U-Boot " +
+			"tool names.\n\nThe tools still have to be command line compatible.\n\n" +
+			"Changelog: Commit\nTicket: MEN-3978\n"},
+		{SHA: "dddddddddddddddddddddddddddddddddddddddd", Message: "Extend logs for docker module\n\n" +
+			"Changelog: Title\n"},
+		{SHA: "eeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee", Message: "Extend logs for docker module\n\n" +
+			"Changelog: Title\n"},
+		{SHA: "ffffffffffffffffffffffffffffffffffffffff", Message: "feat: a reverted feature\n"},
+		{SHA: "1234567890123456789012345678901234567890", Message: "Revert \"feat: a reverted feature\"\n\n" +
+			"Cancel-Changelog: fffffffffff\nChangelog: None\n"},
+	}
+	c := Generate(commits)
+
+	assert.Equal(t, `### Changelogs
+
+#### mender (3.1.0)
+
+New changes in mender since 3.0.0:
+
+* Add missing filesystem sync which could produce an empty or
+  corrupted Update Module file tree in
+  `+"`/var/lib/mender/modules/v3/payloads/0000/tree/files/`"+` after an
+  unexpected reboot.
+* Extend logs for docker module
+* Implement support for non-U-Boot tool names.
+
+  The tools still have to be command line compatible.
+  ([MEN-3978](https://northerntech.atlassian.net/browse/MEN-3978))
+* Support passing docker run CLI arguments when deploying
+  an artifact using the `+"`docker`"+` _update module_.
+
+`, Render(c, "mender", "3.0.0", "3.1.0"))
+
+	assert.Equal(t, `*** One commit had a number 0000 which may be a ticket reference we missed. Should be manually checked.
+---
+Add missing filesystem sync after populating Update Module's file tree.
+
+Changelog: Add missing filesystem sync which could produce an empty or
+corrupted Update Module file tree in
+`+"`/var/lib/mender/modules/v3/payloads/0000/tree/files/`"+` after an
+unexpected reboot.
+
+Signed-off-by: Kristian Amlie <kristian.amlie@northern.tech>---
+
+`, RenderWarnings(c))
+}
+
+func TestRenderEmpty(t *testing.T) {
+	c := Generate([]Commit{{SHA: "aaaaaaa", Message: "chore: tidy\n"}})
+	assert.Equal(t, "### Changelogs\n\n", Render(c, "mender", "master", "fix-bug"))
+	assert.Equal(t, "", RenderWarnings(c))
+}
@@ -0,0 +1,60 @@
+package changelog
+
+import (
+	"strings"
+)
+
+// TicketURL is the URL of the tickets, followed by their key
+const TicketURL = "https://northerntech.atlassian.net/browse/"
+
+// Render renders the changelog in Markdown, as the new changes in repo
+// since the from version, e.g. a release or the base branch of a pull
+// request, to the to version; without entries only the heading is rendered
+func Render(c *Changelog, repo, from, to string) string {
+	var b strings.Builder
+	b.WriteString("### Changelogs\n\n")
+	if len(c.Entries) == 0 {
+		return b.String()
+	}
+	b.WriteString("#### " + repo + " (" + to + ")\n\n")
+	b.WriteString("New changes in " + repo + " since " + from + ":\n\n")
+	for _, entry := range c.Entries {
+		text := entry.Text
+		if len(entry.Tickets) > 0 {
+			links := make([]string, len(entry.Tickets))
+			for i, ticket := range entry.Tickets {
+				links[i] = "[" + ticket + "](" + TicketURL + ticket + ")"
+			}
+			text += "\n(" + strings.Join(links, ", ") + ")"
+		}
+		b.WriteString("* " + indent(text) + "\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// RenderWarnings renders the warnings, one per commit, without their SHAs
+// so that the rendering doesn't change when the commits are amended
+func RenderWarnings(c *Changelog) string {
+	var b strings.Builder
+	for _, warning := range c.Warnings {
+		b.WriteString("*** One commit had a number " + warning.Number +
+			" which may be a ticket reference we missed. Should be manually checked.\n")
+		b.WriteString("---\n" + strings.TrimRight(warning.Message, "\n") + "---\n\n")
+	}
+	return b.String()
+}
+
+// indent indents the lines of the text following the first one under the
+// list item, leaving the empty lines empty
+func indent(text string) string {
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != "" {
+			lines[i] = "  " + lines[i]
+		} else {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
+}
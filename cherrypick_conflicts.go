@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/sirupsen/logrus"
+
+	clientgithub "github.com/mendersoftware/integration-test-runner/client/github"
+	"github.com/mendersoftware/integration-test-runner/git"
+)
+
+// cherryPickConflictError is the errorCherryPickConflict of a cherry-pick,
+// with what the sequencer stopped at
+type cherryPickConflictError struct {
+	// Commits are the commits which conflicted, e.g. "1a2b3c4 fix: a bug"
+	Commits []string
+	// Files are the files with conflicts
+	Files []string
+	// Pending are the commits not cherry-picked after the conflict
+	Pending []string
+	// ConflictsPR is the draft pull request with the conflict markers
+	// committed, e.g. "#42", if any
+	ConflictsPR string
+	// Source is what was cherry-picked
+	Source *cherryPickSource
+}
+
+func (e *cherryPickConflictError) Error() string {
+	return errorCherryPickConflict.Error()
+}
+
+func (e *cherryPickConflictError) Is(target error) bool {
+	return target == errorCherryPickConflict
+}
+
+// getCherryPickConflict returns the conflict the cherry-pick in progress in
+// the state stopped at
+func getCherryPickConflict(state *git.State) *cherryPickConflictError {
+	conflict := &cherryPickConflictError{}
+	out, err := git.Command("diff", "--name-only", "--diff-filter=U").
+		With(state).CombinedOutput()
+	if err == nil {
+		conflict.Files = strings.Fields(string(out))
+	}
+	out, err = git.Command("log", "-1", "--format=%h %s", "CHERRY_PICK_HEAD").
+		With(state).CombinedOutput()
+	if err == nil && len(out) > 0 {
+		conflict.Commits = []string{strings.TrimSpace(string(out))}
+	}
+	// the sequencer only exists when cherry-picking multiple commits
+	todo, err := os.ReadFile(filepath.Join(state.Dir, ".git", "sequencer", "todo"))
+	if err == nil {
+		for _, line := range strings.Split(string(todo), "\n") {
+			if action, commit, found := strings.Cut(strings.TrimSpace(line), " "); found &&
+				action == "pick" {
+				conflict.Pending = append(conflict.Pending, commit)
+			}
+		}
+	}
+	// the first line of the todo is the conflicting commit
+	if len(conflict.Pending) > 0 {
+		conflict.Pending = conflict.Pending[1:]
+	}
+	return conflict
+}
+
+// pushCherryPickConflicts commits the conflict markers of the cherry-pick in
+// progress, and of the following conflicting commits, pushes them to the
+// cherry-<branch>-<ref>-conflicts branch and opens a draft pull request
+func pushCherryPickConflicts(
+	log *logrus.Entry,
+	comment *github.IssueCommentEvent,
+	pr *github.PullRequest,
+	conf *config,
+	targetBranch string,
+	state *git.State,
+	conflict *cherryPickConflictError,
+	client clientgithub.Client,
+) error {
+	current := conflict
+	for {
+		if err := git.CommandsWithState(state,
+			git.Command("add", "--all"),
+			git.Command("commit", "--no-verify", "--no-edit"),
+		); err != nil {
+			return err
+		}
+		if len(current.Pending) == 0 {
+			break
+		}
+		err := git.Command("-c", "core.editor=true", "cherry-pick", "--continue").
+			With(state).Run()
+		if err == nil {
+			break
+		} else if !strings.Contains(err.Error(), "conflict") {
+			return err
+		}
+		current = getCherryPickConflict(state)
+		conflict.Commits = append(conflict.Commits, current.Commits...)
+		for _, file := range current.Files {
+			if !slices.Contains(conflict.Files, file) {
+				conflict.Files = append(conflict.Files, file)
+			}
+		}
+	}
+
+	branchName := fmt.Sprintf("cherry-%s-%s-conflicts", targetBranch, pr.GetHead().GetRef())
+	if err := git.Command("push", "--force", "mendersoftware", "HEAD:"+branchName).
+		With(state).Run(); err != nil {
+		return err
+	}
+	newPR := &github.NewPullRequest{
+		Title: github.String(fmt.Sprintf("[Cherry %s conflicts]: %s",
+			targetBranch, comment.GetIssue().GetTitle())),
+		Head: github.String(branchName),
+		Base: github.String(targetBranch),
+		Body: github.String(
+			fmt.Sprintf("Cherry pick of PR: #%d, with the conflict markers committed.\n"+
+				"Resolve them, then mark it as ready for review.", pr.GetNumber())),
+		Draft:               github.Bool(true),
+		MaintainerCanModify: github.Bool(true),
+	}
+	newPRRes, err := client.CreatePullRequest(
+		traceContext(log),
+		conf.githubOrganization,
+		comment.GetRepo().GetName(),
+		newPR)
+	if err != nil {
+		return fmt.Errorf("Failed to create the conflicts PR for: (%s) %v",
+			comment.GetRepo().GetName(), err)
+	}
+	conflict.ConflictsPR = fmt.Sprintf("#%d", newPRRes.GetNumber())
+	return nil
+}
+
+// renderCherryPickConflict returns the report of the conflicts of the
+// cherry-pick to the branch, as a list item of the comment of the outcome
+func renderCherryPickConflict(
+	repo string,
+	pr *github.PullRequest,
+	targetBranch string,
+	conflict *cherryPickConflictError,
+) string {
+	report := fmt.Sprintf("* %s Had merge conflicts, you will have to fix this yourself "+
+		":crying_cat_face:\n", targetBranch)
+	if len(conflict.Commits) > 0 {
+		report += "  Conflicting commits:\n"
+		for _, commit := range conflict.Commits {
+			report += "  * " + commit + "\n"
+		}
+	}
+	if len(conflict.Files) > 0 {
+		report += "  Conflicting files:\n"
+		for _, file := range conflict.Files {
+			report += "  * `" + file + "`\n"
+		}
+	}
+	if len(conflict.Pending) > 0 && conflict.ConflictsPR == "" {
+		report += "  Not cherry-picked after the conflict:\n"
+		for _, commit := range conflict.Pending {
+			report += "  * " + commit + "\n"
+		}
+	}
+	if conflict.ConflictsPR != "" {
+		report += "  The conflict markers are committed in the draft PR " +
+			conflict.ConflictsPR + ".\n"
+	}
+	source := conflict.Source
+	if source == nil {
+		source = &cherryPickSource{
+			Strategy: cherryPickStrategyCommits,
+			Args:     []string{pr.GetHead().GetSHA(), "^" + pr.GetBase().GetSHA()},
+		}
+	}
+	repoURL := getRemoteURLGitHub(gitProtocolHTTP, "mendersoftware", repo)
+	report += fmt.Sprintf("  To reproduce the cherry-pick of the %s locally:\n", source.Strategy) +
+		"  ```\n" +
+		fmt.Sprintf("  git fetch %s %s\n", repoURL, targetBranch) +
+		fmt.Sprintf("  git checkout -b cherry-%s-%s FETCH_HEAD\n",
+			targetBranch, pr.GetHead().GetRef())
+	if source.Strategy == cherryPickStrategyCommits {
+		report += fmt.Sprintf("  git fetch %s pull/%d/head\n", repoURL, pr.GetNumber())
+	} else {
+		report += fmt.Sprintf("  git fetch %s %s\n", repoURL, pr.GetBase().GetRef())
+	}
+	report += "  git cherry-pick -x --allow-empty " + strings.Join(source.Args, " ") + "\n" +
+		"  ```\n"
+	return report
+}
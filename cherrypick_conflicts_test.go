@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v28/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cherryPickConflictEnvironment returns a pull request of two commits both
+// conflicting with the 2.5.x branch, and the short SHAs of the commits
+func cherryPickConflictEnvironment(
+	t *testing.T,
+) (*e2eEnvironment, *github.IssueCommentEvent, *github.PullRequest, []string) {
+	env := newE2EEnvironment(t)
+	githubRepo := env.remote.GitHubRepo("mendersoftware", "core")
+	env.remote.Init(githubRepo)
+	env.remote.Commit(githubRepo, "master", "VERSION", "1", "initial commit")
+	baseSHA := env.remote.Commit(githubRepo, "master", "NOTES", "a", "add notes")
+	env.remote.Git(githubRepo, "branch", "2.5.x", baseSHA)
+	env.remote.Commit(githubRepo, "2.5.x", "VERSION", "old", "old version")
+	env.remote.Commit(githubRepo, "2.5.x", "NOTES", "old", "old notes")
+	env.remote.Git(githubRepo, "branch", "fix-bug", baseSHA)
+	firstSHA := env.remote.Commit(githubRepo, "fix-bug", "VERSION", "2", "fix: the version")
+	headSHA := env.remote.Commit(githubRepo, "fix-bug", "NOTES", "b", "fix: the notes")
+
+	comment := &github.IssueCommentEvent{
+		Repo:   &github.Repository{Name: github.String("core")},
+		Issue:  &github.Issue{Number: github.Int(7), Title: github.String("fix: a bug")},
+		Sender: &github.User{Login: github.String("alice"), Name: github.String("alice")},
+	}
+	pr := &github.PullRequest{
+		Number: github.Int(7),
+		Base:   &github.PullRequestBranch{Ref: github.String("master"), SHA: github.String(baseSHA)},
+		Head:   &github.PullRequestBranch{Ref: github.String("fix-bug"), SHA: github.String(headSHA)},
+	}
+	return env, comment, pr, []string{firstSHA[:7], headSHA[:7]}
+}
+
+func TestCherryPickConflictReport(t *testing.T) {
+	env, comment, pr, shas := cherryPickConflictEnvironment(t)
+
+	err := cherryPickPR(getCustomLoggerFromContext(&gin.Context{}), comment, pr, env.conf,
+		"cherry-pick to: `2.5.x`", githubClient)
+	require.NoError(t, err)
+	assert.Empty(t, env.github.PullRequests("cfengine", "core"))
+	comments := env.github.Comments("cfengine", "core", 7)
+	require.Len(t, comments, 1)
+	assert.Equal(t, `Hi :smiley_cat:
+I did my very best, and this is the result of the cherry pick operation:
+* 2.5.x Had merge conflicts, you will have to fix this yourself :crying_cat_face:
+  Conflicting commits:
+  * `+shas[0]+` fix: the version
+  Conflicting files:
+  * `+"`VERSION`"+`
+  Not cherry-picked after the conflict:
+  * `+shas[1]+` fix: the notes
+  To reproduce the cherry-pick of the pull request commits locally:
+  `+"```"+`
+  git fetch https://github.com/mendersoftware/core 2.5.x
+  git checkout -b cherry-2.5.x-fix-bug FETCH_HEAD
+  git fetch https://github.com/mendersoftware/core pull/7/head
+  git cherry-pick -x --allow-empty `+pr.GetHead().GetSHA()+` ^`+pr.GetBase().GetSHA()+`
+  `+"```"+`
+`, comments[0].GetBody())
+}
+
+func TestCherryPickConflictPR(t *testing.T) {
+	env, comment, pr, shas := cherryPickConflictEnvironment(t)
+	env.conf.cherryPickConflictPRs = true
+
+	err := cherryPickPR(getCustomLoggerFromContext(&gin.Context{}), comment, pr, env.conf,
+		"cherry-pick to: `2.5.x`", githubClient)
+	require.NoError(t, err)
+
+	prs := env.github.PullRequests("cfengine", "core")
+	require.Len(t, prs, 1)
+	assert.Equal(t, "cherry-2.5.x-fix-bug-conflicts", prs[0].GetHead().GetRef())
+	assert.Equal(t, "2.5.x", prs[0].GetBase().GetRef())
+	assert.True(t, prs[0].GetDraft())
+	githubRepo := env.remote.GitHubRepo("mendersoftware", "core")
+	for _, file := range []string{"VERSION", "NOTES"} {
+		content := env.remote.Git(githubRepo, "show", "cherry-2.5.x-fix-bug-conflicts:"+file)
+		assert.True(t, strings.HasPrefix(content, "<<<<<<< "), content)
+	}
+
+	comments := env.github.Comments("cfengine", "core", 7)
+	require.Len(t, comments, 1)
+	body := comments[0].GetBody()
+	assert.Contains(t, body, "  * "+shas[0]+" fix: the version\n  * "+shas[1]+" fix: the notes\n")
+	assert.Contains(t, body, "  * `VERSION`\n  * `NOTES`\n")
+	assert.Contains(t, body, "The conflict markers are committed in the draft PR #"+
+		strconv.Itoa(prs[0].GetNumber())+".\n")
+	assert.NotContains(t, body, "not cherry-picked")
+}
@@ -8,7 +8,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os/exec"
 	"regexp"
 	"sort"
 	"strconv"
@@ -22,6 +21,7 @@ import (
 
 	clientgithub "github.com/mendersoftware/integration-test-runner/client/github"
 	"github.com/mendersoftware/integration-test-runner/git"
+	"github.com/mendersoftware/integration-test-runner/integration"
 )
 
 var versionsUrl = "https://docs.mender.io/releases/versions.json"
@@ -99,11 +99,20 @@ func getReleaseBranchesForCherryPick(
 
 	repo := pr.GetRepo().GetName()
 	for _, version := range versions {
-		releaseBranch, err := getServiceRevisionFromIntegration(repo, "origin/"+version, conf)
-		if err != nil {
+		releaseBranch, err := getServiceRevisionFromIntegration(
+			state.Context,
+			repo,
+			"origin/"+version,
+			conf,
+		)
+		if errors.Is(err, integration.ErrUnknownComponent) ||
+			errors.Is(err, integration.ErrNoVersion) {
+			continue
+		} else if err != nil {
 			return releaseBranches, err
 		} else if releaseBranch != "" {
 			if isCherryPickBottable(
+				state.Context,
 				pr.GetRepo().GetName(),
 				conf, pr.GetPullRequest(),
 				releaseBranch,
@@ -176,7 +185,7 @@ func suggestCherryPicks(
 	repoURL := getRemoteURLGitHub(conf.githubProtocol, conf.githubOrganization, repo)
 	prNumber := strconv.Itoa(pr.GetNumber())
 	prBranchName := "pr_" + prNumber
-	state, err := git.Commands(
+	state, err := git.CommandsContext(traceContext(log),
 		git.Command("init", "."),
 		git.Command("remote", "add", "github", repoURL),
 		git.Command("fetch", "github", baseRef+":local"),
@@ -189,19 +198,13 @@ func suggestCherryPicks(
 
 	// count the number commits with Changelog entries
 	baseSHA := pr.GetPullRequest().GetBase().GetSHA()
-	countCmd := exec.Command(
-		"sh",
-		"-c",
-		"git log "+baseSHA+"...pr_"+prNumber+" | grep -i -e \"^    Changelog:\" "+
-			"| grep -v -i -e \"^    Changelog: *none\" | wc -l",
-	)
-	countCmd.Dir = state.Dir
-	out, err := countCmd.CombinedOutput()
+	logCmd := git.Command("log", baseSHA+"...pr_"+prNumber).With(state)
+	out, err := logCmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("%v returned error: %s: %s", countCmd.Args, out, err.Error())
+		return fmt.Errorf("%v returned error: %s: %s", logCmd.Args, out, err.Error())
 	}
 
-	changelogs, _ := strconv.Atoi(strings.TrimSpace(string(out)))
+	changelogs := countChangelogEntries(out)
 	if changelogs == 0 {
 		log.Infof("Found no changelog entries, ignoring cherry-pick suggestions")
 		return nil
@@ -220,7 +223,7 @@ func suggestCherryPicks(
 	comment := github.IssueComment{
 		Body: &commentBody,
 	}
-	if err := githubClient.CreateComment(context.Background(), conf.githubOrganization,
+	if err := githubClient.CreateComment(traceContext(log), conf.githubOrganization,
 		pr.GetRepo().GetName(), pr.GetNumber(), &comment); err != nil {
 		log.Infof("Failed to comment on the pr: %v, Error: %s", pr, err.Error())
 		return err
@@ -228,13 +231,31 @@ func suggestCherryPicks(
 	return nil
 }
 
+var (
+	changelogEntryRegex     = regexp.MustCompile(`(?i)^    Changelog:`)
+	changelogEntryNoneRegex = regexp.MustCompile(`(?i)^    Changelog: *none`)
+)
+
+// countChangelogEntries counts the Changelog trailers in the output of
+// git log, ignoring the "Changelog: None" ones
+func countChangelogEntries(gitLog []byte) int {
+	count := 0
+	for _, line := range strings.Split(string(gitLog), "\n") {
+		if changelogEntryRegex.MatchString(line) && !changelogEntryNoneRegex.MatchString(line) {
+			count++
+		}
+	}
+	return count
+}
+
 func isCherryPickBottable(
+	ctx context.Context,
 	repoName string,
 	conf *config,
 	pr *github.PullRequest,
 	targetBranch string,
 ) bool {
-	_, state, err := tryCherryPickToBranch(repoName, conf, pr, targetBranch)
+	_, _, state, err := tryCherryPickToBranch(ctx, repoName, conf, pr, targetBranch)
 	state.Cleanup()
 	if err != nil {
 		logrus.Errorf("isCherryPickBottable received error: %s", err.Error())
@@ -242,15 +263,102 @@ func isCherryPickBottable(
 	return err == nil
 }
 
+// Strategies of the cherry-picks of the pull requests, after how they were
+// merged
+const (
+	cherryPickStrategyCommits = "pull request commits"
+	cherryPickStrategyMerge   = "merge commit"
+	cherryPickStrategySquash  = "squash merge"
+	cherryPickStrategyRebase  = "rebase merge"
+)
+
+// cherryPickSource is what the cherry-pick of a pull request picks
+type cherryPickSource struct {
+	Strategy string
+	// Range is the range of the commits picked, for git log; empty if
+	// unknown
+	Range string
+	// Args are the arguments of git cherry-pick -x --allow-empty picking
+	// them
+	Args []string
+}
+
+// getCherryPickSource returns the commits of the pull request to
+// cherry-pick: the ones which landed on the base branch if it is merged,
+// found from its merge commit, otherwise the commits of the pull request
+func getCherryPickSource(state *git.State, pr *github.PullRequest) *cherryPickSource {
+	headSHA := pr.GetHead().GetSHA()
+	source := &cherryPickSource{
+		Strategy: cherryPickStrategyCommits,
+		Args:     []string{headSHA, "^" + pr.GetBase().GetSHA()},
+	}
+	if pr.Commits != nil {
+		source.Range = fmt.Sprintf("%s~%d..%s", headSHA, pr.GetCommits(), headSHA)
+	}
+	mergeSHA := pr.GetMergeCommitSHA()
+	if !pr.GetMerged() || mergeSHA == "" {
+		return source
+	}
+
+	// the merge commit is on the base branch, fetched with the other
+	// branches, the head of the pull request may live in a fork
+	const format = "--format=%an <%ae>%n%B"
+	out, err := git.Command("log", "-1", "--format=%P", mergeSHA).With(state).CombinedOutput()
+	if err != nil {
+		return source
+	}
+	switch len(strings.Fields(string(out))) {
+	case 2:
+		return &cherryPickSource{
+			Strategy: cherryPickStrategyMerge,
+			Range:    mergeSHA + "^1.." + mergeSHA + "^2",
+			Args:     []string{"-m", "1", mergeSHA},
+		}
+	case 1:
+		// a rebase merge lands copies of the commits of the pull request,
+		// the last one being the merge commit, a squash merge lands one
+		// commit with a message of its own
+		merged, err := git.Command("log", "-1", format, mergeSHA).With(state).CombinedOutput()
+		if err != nil {
+			return source
+		}
+		head, err := git.Command("log", "-1", format, headSHA).With(state).CombinedOutput()
+		if err != nil {
+			err = git.Command("fetch", "mendersoftware",
+				fmt.Sprintf("pull/%d/head", pr.GetNumber())).With(state).Run()
+			if err == nil {
+				head, err = git.Command("log", "-1", format, headSHA).
+					With(state).CombinedOutput()
+			}
+		}
+		if commits := pr.GetCommits(); commits > 0 && err == nil &&
+			bytes.Equal(head, merged) {
+			first := fmt.Sprintf("%s~%d", mergeSHA, commits)
+			return &cherryPickSource{
+				Strategy: cherryPickStrategyRebase,
+				Range:    first + ".." + mergeSHA,
+				Args:     []string{mergeSHA, "^" + first},
+			}
+		}
+		return &cherryPickSource{
+			Strategy: cherryPickStrategySquash,
+			Range:    mergeSHA + "~1.." + mergeSHA,
+			Args:     []string{mergeSHA},
+		}
+	}
+	return source
+}
+
 func tryCherryPickToBranch(
+	ctx context.Context,
 	repoName string,
 	conf *config,
 	pr *github.PullRequest,
 	targetBranch string,
-) (string, *git.State, error) {
+) (string, *cherryPickSource, *git.State, error) {
 	prBranchName := fmt.Sprintf("cherry-%s-%s",
 		targetBranch, pr.GetHead().GetRef())
-	state, err := git.Commands(
+	state, err := git.CommandsContext(ctx,
 		git.Command("init", "."),
 		git.Command("remote", "add", "mendersoftware",
 			getRemoteURLGitHub(conf.githubProtocol, "mendersoftware", repoName)),
@@ -259,33 +367,34 @@ func tryCherryPickToBranch(
 		git.Command("checkout", "-b", prBranchName),
 	)
 	if err != nil {
-		return "", state, err
+		return "", nil, state, err
 	}
 
-	if pr.Commits != nil {
-		firstPRCommit := fmt.Sprintf("%s~%d", pr.GetHead().GetSHA(), *pr.Commits)
-		out, err := git.Command("log", "--pretty=format:%s",
-			firstPRCommit+".."+pr.GetHead().GetSHA()).With(state).CombinedOutput()
+	source := getCherryPickSource(state, pr)
+	if source.Range != "" {
+		out, err := git.Command("log", "--pretty=format:%s", source.Range).
+			With(state).CombinedOutput()
 		if err != nil {
-			return "", state, err
+			return "", source, state, err
 		}
 		lines := bytes.Split(out, []byte("\n"))
 		for _, line := range lines {
 			if bytes.HasPrefix(line, []byte("feat:")) || bytes.HasPrefix(line, []byte("feat(")) {
-				return "", state, errorCherryPickFeature
+				return "", source, state, errorCherryPickFeature
 			}
 		}
 	}
 
-	if err = git.Command("cherry-pick", "-x", "--allow-empty",
-		pr.GetHead().GetSHA(), "^"+pr.GetBase().GetSHA()).
-		With(state).Run(); err != nil {
+	args := append([]string{"cherry-pick", "-x", "--allow-empty"}, source.Args...)
+	if err = git.Command(args...).With(state).Run(); err != nil {
 		if strings.Contains(err.Error(), "conflict") {
-			return "", state, errorCherryPickConflict
+			conflict := getCherryPickConflict(state)
+			conflict.Source = source
+			return "", source, state, conflict
 		}
-		return "", state, err
+		return "", source, state, err
 	}
-	return prBranchName, state, nil
+	return prBranchName, source, state, nil
 }
 
 func cherryPickToBranch(
@@ -295,46 +404,66 @@ func cherryPickToBranch(
 	conf *config,
 	targetBranch string,
 	client clientgithub.Client,
-) (*github.PullRequest, error) {
+) (*github.PullRequest, *cherryPickSource, error) {
 
-	prBranchName, state, err := tryCherryPickToBranch(
+	prBranchName, source, state, err := tryCherryPickToBranch(
+		traceContext(log),
 		comment.GetRepo().GetName(),
 		conf,
 		pr,
 		targetBranch,
 	)
 	defer state.Cleanup()
+	var conflict *cherryPickConflictError
+	if errors.As(err, &conflict) && conf.cherryPickConflictPRs {
+		if err := pushCherryPickConflicts(log, comment, pr, conf, targetBranch, state,
+			conflict, client); err != nil {
+			log.Errorf("Failed to push the conflicts of the cherry-pick to %s: %s",
+				targetBranch, err.Error())
+		}
+	}
 	if err != nil {
-		return nil, err
+		return nil, source, err
 	}
 
 	if err = git.Command("push",
 		"mendersoftware",
 		prBranchName+":"+prBranchName).
 		With(state).Run(); err != nil {
-		return nil, err
+		return nil, source, err
 	}
 
+	// the description of the pull request links its issues, if any
+	body := fmt.Sprintf("Cherry pick of PR: #%d\nFor you %s :)",
+		pr.GetNumber(), comment.Sender.GetName())
+	if description := strings.TrimSpace(pr.GetBody()); description != "" {
+		body += "\n\n---\n\n" + description
+	}
 	newPR := &github.NewPullRequest{
 		Title: github.String(fmt.Sprintf("[Cherry %s]: %s",
 			targetBranch, comment.GetIssue().GetTitle())),
-		Head: github.String(prBranchName),
-		Base: github.String(targetBranch),
-		Body: github.String(
-			fmt.Sprintf("Cherry pick of PR: #%d\nFor you %s :)",
-				pr.GetNumber(), comment.Sender.GetName())),
+		Head:                github.String(prBranchName),
+		Base:                github.String(targetBranch),
+		Body:                github.String(body),
 		MaintainerCanModify: github.Bool(true),
 	}
 	newPRRes, err := client.CreatePullRequest(
-		context.Background(),
+		traceContext(log),
 		conf.githubOrganization,
 		comment.GetRepo().GetName(),
 		newPR)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to create the PR for: (%s) %v",
+		return nil, source, fmt.Errorf("Failed to create the PR for: (%s) %v",
 			comment.GetRepo().GetName(), err)
 	}
-	return newPRRes, nil
+	inheritBackportMetadata(log, conf, comment.GetRepo().GetName(), pr, newPRRes, client)
+	// the head is unknown in dry-run mode
+	if out, err := git.Command("rev-parse", "HEAD").With(state).CombinedOutput(); err == nil &&
+		len(bytes.TrimSpace(out)) > 0 {
+		startBackportClientPipelines(log, conf, comment.GetRepo(), pr, newPRRes, targetBranch,
+			string(bytes.TrimSpace(out)))
+	}
+	return newPRRes, source, nil
 }
 
 func cherryPickPR(
@@ -349,11 +478,26 @@ func cherryPickPR(
 	if err != nil {
 		return err
 	}
-	conflicts := make(map[string]bool)
-	errors := make(map[string]string)
+	_, err = cherryPickToBranches(log, comment, pr, conf, targetBranches, githubClient)
+	return err
+}
+
+// cherryPickToBranches cherry-picks the pull request to the target branches,
+// commenting the outcome on the pull request, and returns the branches the
+// cherry-pick failed for
+func cherryPickToBranches(
+	log *logrus.Entry,
+	comment *github.IssueCommentEvent,
+	pr *github.PullRequest,
+	conf *config,
+	targetBranches []string,
+	githubClient clientgithub.Client,
+) ([]string, error) {
+	conflicts := make(map[string]*cherryPickConflictError)
+	failures := make(map[string]string)
 	success := make(map[string]string)
 	for _, targetBranch := range targetBranches {
-		if newPR, err := cherryPickToBranch(
+		if newPR, source, err := cherryPickToBranch(
 			log,
 			comment,
 			pr,
@@ -361,32 +505,37 @@ func cherryPickPR(
 			targetBranch,
 			githubClient,
 		); err != nil {
-			if err == errorCherryPickConflict {
-				conflicts[targetBranch] = true
+			var conflict *cherryPickConflictError
+			if errors.As(err, &conflict) {
+				conflicts[targetBranch] = conflict
 				continue
 			}
 			log.Errorf("Failed to cherry pick: %s to %s, err: %s",
 				comment.GetIssue().GetTitle(), targetBranch, err)
-			errors[targetBranch] = err.Error()
+			failures[targetBranch] = err.Error()
 		} else {
-			success[targetBranch] = fmt.Sprintf("#%d", newPR.GetNumber())
+			success[targetBranch] = fmt.Sprintf("#%d (%s)", newPR.GetNumber(),
+				source.Strategy)
 		}
 	}
 	// Comment with cherry links on the PR
 	commentText := `Hi :smiley_cat:
 I did my very best, and this is the result of the cherry pick operation:
 `
+	failed := []string{}
 	for _, targetBranch := range targetBranches {
-		if !conflicts[targetBranch] && errors[targetBranch] != "" {
+		if success[targetBranch] == "" {
+			failed = append(failed, targetBranch)
+		}
+		if conflicts[targetBranch] == nil && failures[targetBranch] != "" {
 			commentText = commentText +
-				fmt.Sprintf("* %s :red_circle: Error: %s\n", targetBranch, errors[targetBranch])
+				fmt.Sprintf("* %s :red_circle: Error: %s\n", targetBranch, failures[targetBranch])
 		} else if success[targetBranch] != "" {
 			commentText = commentText +
 				fmt.Sprintf("* %s :heavy_check_mark: %s\n", targetBranch, success[targetBranch])
 		} else {
-			commentText = commentText +
-				fmt.Sprintf("* %s Had merge conflicts, you will have to fix this yourself "+
-					":crying_cat_face:\n", targetBranch)
+			commentText = commentText + renderCherryPickConflict(comment.GetRepo().GetName(),
+				pr, targetBranch, conflicts[targetBranch])
 		}
 	}
 
@@ -394,16 +543,16 @@ I did my very best, and this is the result of the cherry pick operation:
 		Body: &commentText,
 	}
 	if err := githubClient.CreateComment(
-		context.Background(),
+		traceContext(log),
 		conf.githubOrganization,
 		comment.GetRepo().GetName(),
 		pr.GetNumber(),
 		&commentBody,
 	); err != nil {
 		log.Infof("Failed to comment on the pr: %v, Error: %s", pr, err.Error())
-		return err
+		return failed, err
 	}
-	return nil
+	return failed, nil
 }
 
 func parseCherryTargetBranches(body string) ([]string, error) {
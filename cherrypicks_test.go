@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -717,6 +718,16 @@ cherry-pick to:
 				Number: github.Int(42),
 			}, nil)
 
+			mclient.On("ListReviews",
+				mock.MatchedBy(func(ctx context.Context) bool {
+					return true
+				}),
+				conf.githubOrganization,
+				test.comment.GetRepo().GetName(),
+				*test.pr.Number,
+				mock.AnythingOfType("*github.ListOptions"),
+			).Return(nil, nil)
+
 			log := logrus.NewEntry(logrus.StandardLogger())
 
 			err := cherryPickPR(log, test.comment, test.pr, conf, test.body, mclient)
@@ -731,6 +742,107 @@ cherry-pick to:
 	}
 }
 
+// cherryPickMergedEnvironment returns a pull request of two commits merged
+// to master with the method, and the 2.6.x branch to cherry-pick it to
+func cherryPickMergedEnvironment(
+	t *testing.T,
+	method string,
+) (*e2eEnvironment, *github.IssueCommentEvent, *github.PullRequest) {
+	env := newE2EEnvironment(t)
+	githubRepo := env.remote.GitHubRepo("mendersoftware", "core")
+	env.remote.Init(githubRepo)
+	baseSHA := env.remote.Commit(githubRepo, "master", "VERSION", "1", "initial commit")
+	env.remote.Git(githubRepo, "branch", "2.6.x", baseSHA)
+	env.remote.Commit(githubRepo, "master", "NOTES", "a", "add notes")
+	env.remote.Git(githubRepo, "branch", "fix-bug", baseSHA)
+	env.remote.Commit(githubRepo, "fix-bug", "FIX", "1", "fix: one")
+	headSHA := env.remote.Commit(githubRepo, "fix-bug", "FIX", "2", "fix: two")
+	// the head branch is deleted once merged, the pull request ref remains
+	env.remote.Git(githubRepo, "update-ref", pullRequestRef(7), headSHA)
+	env.remote.Git(githubRepo, "branch", "-D", "fix-bug")
+
+	work := t.TempDir()
+	env.remote.Git(work, "clone", "--quiet", "--branch", "master", githubRepo, ".")
+	env.remote.Git(work, "fetch", "--quiet", "origin", pullRequestRef(7))
+	switch method {
+	case "merge":
+		env.remote.Git(work, "merge", "--quiet", "--no-ff", "-m", "Merge pull request #7",
+			"FETCH_HEAD")
+	case "squash":
+		env.remote.Git(work, "merge", "--quiet", "--squash", "FETCH_HEAD")
+		env.remote.Git(work, "commit", "--quiet", "-m", "fix: a bug (#7)")
+	case "rebase":
+		env.remote.Git(work, "cherry-pick", "HEAD..FETCH_HEAD")
+	}
+	env.remote.Git(work, "push", "--quiet", "origin", "HEAD:master")
+
+	comment := &github.IssueCommentEvent{
+		Repo:   &github.Repository{Name: github.String("core")},
+		Issue:  &github.Issue{Number: github.Int(7), Title: github.String("fix: a bug")},
+		Sender: &github.User{Login: github.String("alice"), Name: github.String("alice")},
+	}
+	pr := &github.PullRequest{
+		Number:         github.Int(7),
+		Commits:        github.Int(2),
+		Merged:         github.Bool(true),
+		MergeCommitSHA: github.String(env.remote.RefSHA(githubRepo, "master")),
+		Base: &github.PullRequestBranch{
+			Ref: github.String("master"),
+			SHA: github.String(baseSHA),
+		},
+		Head: &github.PullRequestBranch{
+			Ref: github.String("fix-bug"),
+			SHA: github.String(headSHA),
+		},
+	}
+	return env, comment, pr
+}
+
+func TestCherryPickMergedPR(t *testing.T) {
+	testCases := map[string]struct {
+		strategy string
+		subjects string
+	}{
+		"merge": {
+			strategy: cherryPickStrategyMerge,
+			subjects: "Merge pull request #7\n",
+		},
+		"squash": {
+			strategy: cherryPickStrategySquash,
+			subjects: "fix: a bug (#7)\n",
+		},
+		"rebase": {
+			strategy: cherryPickStrategyRebase,
+			subjects: "fix: two\nfix: one\n",
+		},
+	}
+	for method, tc := range testCases {
+		t.Run(method, func(t *testing.T) {
+			env, comment, pr := cherryPickMergedEnvironment(t, method)
+
+			err := cherryPickPR(logrus.NewEntry(logrus.StandardLogger()), comment, pr,
+				env.conf, "cherry-pick to: `2.6.x`", githubClient)
+			assert.NoError(t, err)
+
+			prs := env.github.PullRequests("cfengine", "core")
+			if assert.Len(t, prs, 1) {
+				comments := env.github.Comments("cfengine", "core", 7)
+				if assert.Len(t, comments, 1) {
+					assert.Contains(t, comments[0].GetBody(), fmt.Sprintf(
+						"* 2.6.x :heavy_check_mark: #%d (%s)\n", prs[0].GetNumber(), tc.strategy))
+				}
+			}
+			githubRepo := env.remote.GitHubRepo("mendersoftware", "core")
+			assert.Equal(t, tc.subjects, env.remote.Git(githubRepo, "log", "--format=%s",
+				"2.6.x..cherry-2.6.x-fix-bug"))
+			assert.Contains(t, env.remote.Git(githubRepo, "log", "-1", "--format=%B",
+				"cherry-2.6.x-fix-bug"),
+				"(cherry picked from commit "+pr.GetMergeCommitSHA()+")")
+			assert.Equal(t, "1", env.remote.Git(githubRepo, "show", "cherry-2.6.x-fix-bug:VERSION"))
+		})
+	}
+}
+
 func TestParseMultiLineCherryTargetBranches(t *testing.T) {
 	tests := map[string]struct {
 		body     string
@@ -837,3 +949,32 @@ func TestParseSingleLineCherryTargetBranches(t *testing.T) {
 		assert.Equal(t, test.expected, res)
 	}
 }
+
+func TestCountChangelogEntries(t *testing.T) {
+	gitLog := `commit 1c0ffee
+Author: Some One <some.one@example.com>
+
+    fix: crash on startup
+
+    Changelog: Fixed a crash on startup
+    Ticket: MEN-1234
+
+commit 2c0ffee
+Author: Some One <some.one@example.com>
+
+    chore: refactor
+
+    Changelog: None
+    changelog: none
+
+commit 3c0ffee
+Author: Some One <some.one@example.com>
+
+    feat: new thing
+
+    changelog: title
+    Changelog: Commit
+`
+	assert.Equal(t, 3, countChangelogEntries([]byte(gitLog)))
+	assert.Equal(t, 0, countChangelogEntries(nil))
+}
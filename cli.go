@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mendersoftware/integration-test-runner/git"
+	"github.com/mendersoftware/integration-test-runner/logger"
+)
+
+// subcommand runs a subcommand of the binary with the given arguments,
+// writing its result to out
+type subcommand func(args []string, out io.Writer) error
+
+// subcommands maps the first argument of the binary to the subcommand to
+// run; without arguments the webhook server is started
+var subcommands = map[string]subcommand{
+	subcommandReplay:     runReplay,
+	subcommandPipeline:   runPipelineCommand,
+	subcommandCherryPick: runCherryPickCommand,
+	subcommandSync:       runSyncCommand,
+}
+
+// stringsFlag is a flag which can be given multiple times
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// setupCommand loads the configuration and sets up the logging, the
+// tracing, the git dry-run mode and the GitHub client for a subcommand; the
+// logs and the traces are written to stderr, keeping stdout for the result
+// of the subcommand. The returned function flushes the traces.
+func setupCommand(
+	dryRunMode bool,
+	gitRules string,
+) (*config, logger.RequestLogger, func(), error) {
+	conf, err := getConfigWithDryRunMode(dryRunMode)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	requestLogger := logger.NewRequestLogger()
+	logger.SetRequestLogger(requestLogger)
+	setupLogging(conf, requestLogger)
+	if conf.dryRunMode {
+		logrus.SetOutput(io.MultiWriter(os.Stderr, requestLogger))
+	} else {
+		logrus.SetOutput(os.Stderr)
+	}
+
+	git.SetDryRunMode(conf.dryRunMode)
+	if conf.dryRunMode {
+		gitExecutor, err := git.NewFakeExecutor(nil)
+		if gitRules != "" {
+			gitExecutor, err = git.LoadFakeExecutor(gitRules)
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		git.SetDryRunExecutor(gitExecutor)
+	}
+
+	githubClient, err = newGitHubClient(conf)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	shutdownTracing, err := setupTracing(conf, os.Stderr)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	flushTracing := func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logrus.Errorf("failed to flush the traces: %s", err.Error())
+		}
+	}
+	return conf, requestLogger, flushTracing, nil
+}
+
+// newCommandContext returns the context the webhook handlers are called with
+// by a subcommand
+func newCommandContext(delivery string) *gin.Context {
+	ctx := &gin.Context{}
+	ctx.Set("delivery", delivery)
+	return ctx
+}
+
+// writeJournal writes the log entries in one of the dry-run logs formats
+func writeJournal(out io.Writer, entries []logger.Entry, format string) error {
+	if format == logsFormatEvents {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+	for _, entry := range entries {
+		if _, err := fmt.Fprintln(out, entry.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
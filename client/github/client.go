@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"slices"
 	"strings"
@@ -37,6 +38,13 @@ type Client interface {
 		number int,
 		labels []string,
 	) error
+	RemoveLabelFromPullRequest(
+		ctx context.Context,
+		org string,
+		repo string,
+		number int,
+		label string,
+	) error
 	CreatePullRequest(
 		ctx context.Context,
 		org string,
@@ -49,6 +57,13 @@ type Client interface {
 		prNumber int,
 		assignees []string,
 	) error
+	RequestReviewers(
+		ctx context.Context,
+		org string,
+		repo string,
+		number int,
+		reviewers []string,
+	) error
 
 	GetPullRequest(
 		ctx context.Context,
@@ -85,6 +100,15 @@ type Client interface {
 		owner, repo, path string,
 		opts *github.RepositoryContentGetOptions,
 	) (*github.RepositoryContent, []*github.RepositoryContent, error)
+	CreateStatus(
+		ctx context.Context,
+		owner, repo, ref string,
+		status *github.RepoStatus,
+	) error
+	GetCombinedStatus(
+		ctx context.Context,
+		owner, repo, ref string,
+	) ([]*github.RepoStatus, error)
 }
 
 type gitHubClient struct {
@@ -99,6 +123,7 @@ func NewGitHubClient(accessToken string, dryRunMode bool) Client {
 		&oauth2.Token{AccessToken: accessToken},
 	)
 	tc := oauth2.NewClient(ctx, ts)
+	tc.Transport = newCacheTransport(newRateLimitTransport(tc.Transport))
 	client := github.NewClient(tc)
 	return &gitHubClient{
 		client:     client,
@@ -106,6 +131,25 @@ func NewGitHubClient(accessToken string, dryRunMode bool) Client {
 	}
 }
 
+// NewGitHubClientWithBaseURL returns a new GitHubClient talking to the API
+// served at baseURL instead of api.github.com, e.g. a fake server in tests
+func NewGitHubClientWithBaseURL(
+	accessToken string,
+	baseURL string,
+	dryRunMode bool,
+) (Client, error) {
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	c := NewGitHubClient(accessToken, dryRunMode).(*gitHubClient)
+	c.client.BaseURL = u
+	return c, nil
+}
+
 func (c *gitHubClient) CreateComment(
 	ctx context.Context,
 	org string,
@@ -118,7 +162,9 @@ func (c *gitHubClient) CreateComment(
 		msg := fmt.Sprintf("github.CreateComment: org=%s,repo=%s,number=%d,comment=%s",
 			org, repo, number, string(commentJSON),
 		)
-		logger.GetRequestLogger().Push(msg)
+		record("CreateComment", logger.Args{
+			"org": org, "repo": repo, "number": number, "comment": comment,
+		}, msg)
 		return nil
 	}
 	_, _, err := c.client.Issues.CreateComment(ctx, org, repo, number, comment)
@@ -135,7 +181,7 @@ func (c *gitHubClient) DeleteComment(
 		msg := fmt.Sprintf("github.DeleteComment: org=%s,repo=%s,commentID=%d",
 			org, repo, commentID,
 		)
-		logger.GetRequestLogger().Push(msg)
+		record("DeleteComment", logger.Args{"org": org, "repo": repo, "comment_id": commentID}, msg)
 		return nil
 	}
 	_, err := c.client.Issues.DeleteComment(ctx, org, repo, commentID)
@@ -145,7 +191,7 @@ func (c *gitHubClient) DeleteComment(
 func (c *gitHubClient) IsOrganizationMember(ctx context.Context, org string, user string) bool {
 	if c.dryRunMode {
 		msg := fmt.Sprintf("github.IsOrganizationMember: org=%s,user=%s", org, user)
-		logger.GetRequestLogger().Push(msg)
+		record("IsOrganizationMember", logger.Args{"org": org, "user": user}, msg)
 		// Let the acceptance tests exercise the non-member paths
 		return !slices.Contains(
 			strings.Split(os.Getenv("DRY_RUN_NON_ORG_MEMBERS"), ","), user)
@@ -165,13 +211,37 @@ func (c *gitHubClient) AddLabelsToPullRequest(
 		msg := fmt.Sprintf("github.AddLabelsToPullRequest: org=%s,repo=%s,number=%d,labels=%v",
 			org, repo, number, labels,
 		)
-		logger.GetRequestLogger().Push(msg)
+		record("AddLabelsToPullRequest", logger.Args{
+			"org": org, "repo": repo, "number": number, "labels": labels,
+		}, msg)
 		return nil
 	}
 	_, _, err := c.client.Issues.AddLabelsToIssue(ctx, org, repo, number, labels)
 	return err
 }
 
+func (c *gitHubClient) RemoveLabelFromPullRequest(
+	ctx context.Context,
+	org string,
+	repo string,
+	number int,
+	label string,
+) error {
+	if c.dryRunMode {
+		msg := fmt.Sprintf("github.RemoveLabelFromPullRequest: org=%s,repo=%s,number=%d,label=%s",
+			org, repo, number, label,
+		)
+		record("RemoveLabelFromPullRequest", logger.Args{
+			"org": org, "repo": repo, "number": number, "label": label,
+		}, msg)
+		return nil
+	}
+	// the labels may have slashes, e.g. backport/5.0.x, which the client
+	// does not escape
+	_, err := c.client.Issues.RemoveLabelForIssue(ctx, org, repo, number, url.PathEscape(label))
+	return err
+}
+
 func (c *gitHubClient) CreatePullRequest(
 	ctx context.Context,
 	org string,
@@ -183,7 +253,7 @@ func (c *gitHubClient) CreatePullRequest(
 		msg := fmt.Sprintf("github.CreatePullRequest: org=%s,repo=%s,pr=%s",
 			org, repo, string(prJSON),
 		)
-		logger.GetRequestLogger().Push(msg)
+		record("CreatePullRequest", logger.Args{"org": org, "repo": repo, "pr": pr}, msg)
 		return &github.PullRequest{}, nil
 	}
 	newPR, _, err := c.client.PullRequests.Create(ctx, org, repo, pr)
@@ -198,6 +268,15 @@ func (c *gitHubClient) AssignPullRequest(
 	if len(assignees) == 0 {
 		return nil
 	}
+	if c.dryRunMode {
+		msg := fmt.Sprintf("github.AssignPullRequest: org=%s,repo=%s,number=%d,assignees=%v",
+			owner, repo, prNumber, assignees,
+		)
+		record("AssignPullRequest", logger.Args{
+			"org": owner, "repo": repo, "number": prNumber, "assignees": assignees,
+		}, msg)
+		return nil
+	}
 	_, res, err := c.client.Issues.AddAssignees(ctx, owner, repo, prNumber, assignees)
 	if err != nil {
 		return fmt.Errorf("failed to assign pull request: %w", err)
@@ -211,6 +290,30 @@ func (c *gitHubClient) AssignPullRequest(
 	return nil
 }
 
+func (c *gitHubClient) RequestReviewers(
+	ctx context.Context,
+	org string,
+	repo string,
+	number int,
+	reviewers []string,
+) error {
+	if len(reviewers) == 0 {
+		return nil
+	}
+	if c.dryRunMode {
+		msg := fmt.Sprintf("github.RequestReviewers: org=%s,repo=%s,number=%d,reviewers=%v",
+			org, repo, number, reviewers,
+		)
+		record("RequestReviewers", logger.Args{
+			"org": org, "repo": repo, "number": number, "reviewers": reviewers,
+		}, msg)
+		return nil
+	}
+	_, _, err := c.client.PullRequests.RequestReviewers(ctx, org, repo, number,
+		github.ReviewersRequest{Reviewers: reviewers})
+	return err
+}
+
 func (c *gitHubClient) GetPullRequest(
 	ctx context.Context,
 	org string,
@@ -274,3 +377,57 @@ func (c *gitHubClient) GetContents(
 	)
 	return fileContent, dirContents, err
 }
+
+// CreateStatus sets a commit status on the ref
+func (c *gitHubClient) CreateStatus(
+	ctx context.Context,
+	owner, repo, ref string,
+	status *github.RepoStatus,
+) error {
+	if c.dryRunMode {
+		statusJSON, _ := json.Marshal(status)
+		msg := fmt.Sprintf("github.CreateStatus: owner=%s,repo=%s,ref=%s,status=%s",
+			owner, repo, ref, string(statusJSON),
+		)
+		record("CreateStatus", logger.Args{
+			"owner": owner, "repo": repo, "ref": ref, "status": status,
+		}, msg)
+		return nil
+	}
+	_, _, err := c.client.Repositories.CreateStatus(ctx, owner, repo, ref, status)
+	return err
+}
+
+// GetCombinedStatus returns the latest commit status of each context of the
+// ref, from all the pages of its combined status
+func (c *gitHubClient) GetCombinedStatus(
+	ctx context.Context,
+	owner, repo, ref string,
+) ([]*github.RepoStatus, error) {
+	statuses := []*github.RepoStatus{}
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		combined, res, err := c.client.Repositories.GetCombinedStatus(ctx, owner, repo, ref,
+			opts)
+		if err != nil {
+			return nil, err
+		}
+		for i := range combined.Statuses {
+			statuses = append(statuses, &combined.Statuses[i])
+		}
+		if res.NextPage == 0 {
+			return statuses, nil
+		}
+		opts.Page = res.NextPage
+	}
+}
+
+// record records a dry-run API call in the request logger
+func record(operation string, args logger.Args, msg string) {
+	logger.GetRequestLogger().PushEntry(logger.Entry{
+		Kind:      logger.KindGitHub,
+		Operation: operation,
+		Args:      args,
+		Message:   msg,
+	})
+}
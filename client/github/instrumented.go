@@ -0,0 +1,223 @@
+package github
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+
+	"github.com/mendersoftware/integration-test-runner/metrics"
+	"github.com/mendersoftware/integration-test-runner/tracing"
+)
+
+type instrumentedClient struct {
+	client Client
+}
+
+// NewInstrumentedClient returns a Client recording the latency and the errors
+// of the calls to the given client in the metrics, and tracing them under
+// the span carried by the context of each call
+func NewInstrumentedClient(client Client) Client {
+	return &instrumentedClient{client: client}
+}
+
+// observe starts recording a call, traced under the span carried by ctx;
+// the returned function records its outcome
+func observe(ctx context.Context, operation string) func(error) {
+	start := time.Now()
+	_, span := tracing.Start(ctx, "github "+operation)
+	return func(err error) {
+		metrics.ObserveAPICall(metrics.ServiceGitHub, operation, start, err)
+		tracing.End(span, err)
+	}
+}
+
+func (c *instrumentedClient) CreateComment(
+	ctx context.Context,
+	org string,
+	repo string,
+	number int,
+	comment *github.IssueComment,
+) error {
+	done := observe(ctx, "CreateComment")
+	err := c.client.CreateComment(ctx, org, repo, number, comment)
+	done(err)
+	return err
+}
+
+func (c *instrumentedClient) DeleteComment(
+	ctx context.Context,
+	org string,
+	repo string,
+	commentID int64,
+) error {
+	done := observe(ctx, "DeleteComment")
+	err := c.client.DeleteComment(ctx, org, repo, commentID)
+	done(err)
+	return err
+}
+
+func (c *instrumentedClient) IsOrganizationMember(
+	ctx context.Context,
+	org string,
+	user string,
+) bool {
+	done := observe(ctx, "IsOrganizationMember")
+	res := c.client.IsOrganizationMember(ctx, org, user)
+	done(nil)
+	return res
+}
+
+func (c *instrumentedClient) AddLabelsToPullRequest(
+	ctx context.Context,
+	org string,
+	repo string,
+	number int,
+	labels []string,
+) error {
+	done := observe(ctx, "AddLabelsToPullRequest")
+	err := c.client.AddLabelsToPullRequest(ctx, org, repo, number, labels)
+	done(err)
+	return err
+}
+
+func (c *instrumentedClient) RemoveLabelFromPullRequest(
+	ctx context.Context,
+	org string,
+	repo string,
+	number int,
+	label string,
+) error {
+	done := observe(ctx, "RemoveLabelFromPullRequest")
+	err := c.client.RemoveLabelFromPullRequest(ctx, org, repo, number, label)
+	done(err)
+	return err
+}
+
+func (c *instrumentedClient) CreatePullRequest(
+	ctx context.Context,
+	org string,
+	repo string,
+	pr *github.NewPullRequest,
+) (*github.PullRequest, error) {
+	done := observe(ctx, "CreatePullRequest")
+	res, err := c.client.CreatePullRequest(ctx, org, repo, pr)
+	done(err)
+	return res, err
+}
+
+func (c *instrumentedClient) AssignPullRequest(
+	ctx context.Context,
+	owner, repo string,
+	prNumber int,
+	assignees []string,
+) error {
+	done := observe(ctx, "AssignPullRequest")
+	err := c.client.AssignPullRequest(ctx, owner, repo, prNumber, assignees)
+	done(err)
+	return err
+}
+
+func (c *instrumentedClient) RequestReviewers(
+	ctx context.Context,
+	org string,
+	repo string,
+	number int,
+	reviewers []string,
+) error {
+	done := observe(ctx, "RequestReviewers")
+	err := c.client.RequestReviewers(ctx, org, repo, number, reviewers)
+	done(err)
+	return err
+}
+
+func (c *instrumentedClient) GetPullRequest(
+	ctx context.Context,
+	org string,
+	repo string,
+	pr int,
+) (*github.PullRequest, error) {
+	done := observe(ctx, "GetPullRequest")
+	res, err := c.client.GetPullRequest(ctx, org, repo, pr)
+	done(err)
+	return res, err
+}
+
+func (c *instrumentedClient) ListComments(
+	ctx context.Context,
+	owner, repo string,
+	number int,
+	opts *github.IssueListCommentsOptions,
+) ([]*github.IssueComment, error) {
+	done := observe(ctx, "ListComments")
+	res, err := c.client.ListComments(ctx, owner, repo, number, opts)
+	done(err)
+	return res, err
+}
+
+func (c *instrumentedClient) ListPullRequests(
+	ctx context.Context,
+	owner, repo string,
+	opts *github.PullRequestListOptions,
+) ([]*github.PullRequest, error) {
+	done := observe(ctx, "ListPullRequests")
+	res, err := c.client.ListPullRequests(ctx, owner, repo, opts)
+	done(err)
+	return res, err
+}
+
+func (c *instrumentedClient) ListReviews(
+	ctx context.Context,
+	owner, repo string,
+	number int,
+	opts *github.ListOptions,
+) ([]*github.PullRequestReview, error) {
+	done := observe(ctx, "ListReviews")
+	res, err := c.client.ListReviews(ctx, owner, repo, number, opts)
+	done(err)
+	return res, err
+}
+
+func (c *instrumentedClient) ListTimeline(
+	ctx context.Context,
+	owner, repo string,
+	number int,
+	opts *github.ListOptions,
+) ([]*github.Timeline, error) {
+	done := observe(ctx, "ListTimeline")
+	res, err := c.client.ListTimeline(ctx, owner, repo, number, opts)
+	done(err)
+	return res, err
+}
+
+func (c *instrumentedClient) GetContents(
+	ctx context.Context,
+	owner, repo, path string,
+	opts *github.RepositoryContentGetOptions,
+) (*github.RepositoryContent, []*github.RepositoryContent, error) {
+	done := observe(ctx, "GetContents")
+	fileContent, dirContents, err := c.client.GetContents(ctx, owner, repo, path, opts)
+	done(err)
+	return fileContent, dirContents, err
+}
+
+func (c *instrumentedClient) CreateStatus(
+	ctx context.Context,
+	owner, repo, ref string,
+	status *github.RepoStatus,
+) error {
+	done := observe(ctx, "CreateStatus")
+	err := c.client.CreateStatus(ctx, owner, repo, ref, status)
+	done(err)
+	return err
+}
+
+func (c *instrumentedClient) GetCombinedStatus(
+	ctx context.Context,
+	owner, repo, ref string,
+) ([]*github.RepoStatus, error) {
+	done := observe(ctx, "GetCombinedStatus")
+	res, err := c.client.GetCombinedStatus(ctx, owner, repo, ref)
+	done(err)
+	return res, err
+}
@@ -80,6 +80,20 @@ func (_m *Client) CreatePullRequest(ctx context.Context, org string, repo string
 	return r0, r1
 }
 
+// CreateStatus provides a mock function with given fields: ctx, owner, repo, ref, status
+func (_m *Client) CreateStatus(ctx context.Context, owner string, repo string, ref string, status *v28github.RepoStatus) error {
+	ret := _m.Called(ctx, owner, repo, ref, status)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, *v28github.RepoStatus) error); ok {
+		r0 = rf(ctx, owner, repo, ref, status)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetContents provides a mock function with given fields: ctx, owner, repo, path, opts
 func (_m *Client) GetContents(ctx context.Context, owner string, repo string, path string, opts *v28github.RepositoryContentGetOptions) (*v28github.RepositoryContent, []*v28github.RepositoryContent, error) {
 	ret := _m.Called(ctx, owner, repo, path, opts)
@@ -163,6 +177,34 @@ func (_m *Client) IsOrganizationMember(ctx context.Context, org string, user str
 	return r0
 }
 
+// RemoveLabelFromPullRequest provides a mock function with given fields: ctx, org, repo, number, label
+func (_m *Client) RemoveLabelFromPullRequest(ctx context.Context, org string, repo string, number int, label string) error {
+	ret := _m.Called(ctx, org, repo, number, label)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int, string) error); ok {
+		r0 = rf(ctx, org, repo, number, label)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RequestReviewers provides a mock function with given fields: ctx, org, repo, number, reviewers
+func (_m *Client) RequestReviewers(ctx context.Context, org string, repo string, number int, reviewers []string) error {
+	ret := _m.Called(ctx, org, repo, number, reviewers)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int, []string) error); ok {
+		r0 = rf(ctx, org, repo, number, reviewers)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ListComments provides a mock function with given fields: ctx, owner, repo, number, opts
 func (_m *Client) ListComments(ctx context.Context, owner string, repo string, number int, opts *v28github.IssueListCommentsOptions) ([]*v28github.IssueComment, error) {
 	ret := _m.Called(ctx, owner, repo, number, opts)
@@ -232,6 +274,29 @@ func (_m *Client) ListReviews(ctx context.Context, owner string, repo string, nu
 	return r0, r1
 }
 
+// GetCombinedStatus provides a mock function with given fields: ctx, owner, repo, ref
+func (_m *Client) GetCombinedStatus(ctx context.Context, owner string, repo string, ref string) ([]*v28github.RepoStatus, error) {
+	ret := _m.Called(ctx, owner, repo, ref)
+
+	var r0 []*v28github.RepoStatus
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) []*v28github.RepoStatus); ok {
+		r0 = rf(ctx, owner, repo, ref)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*v28github.RepoStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, owner, repo, ref)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListTimeline provides a mock function with given fields: ctx, owner, repo, number, opts
 func (_m *Client) ListTimeline(ctx context.Context, owner string, repo string, number int, opts *v28github.ListOptions) ([]*v28github.Timeline, error) {
 	ret := _m.Called(ctx, owner, repo, number, opts)
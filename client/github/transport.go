@@ -0,0 +1,295 @@
+package github
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/mendersoftware/integration-test-runner/metrics"
+)
+
+const (
+	// maxRetries is the number of times an idempotent request is retried
+	maxRetries = 3
+	// maxRateLimitWait is the longest wait for a rate limit to reset, or
+	// before a retry; beyond it the response is returned as is
+	maxRateLimitWait = time.Minute
+	// retryBaseDelay is the delay before the first retry of a request
+	// failing without a hint from GitHub, doubled at every retry
+	retryBaseDelay = time.Second
+	// maxCachedResponses is the number of GET responses kept to revalidate
+	// them with their ETag
+	maxCachedResponses = 1000
+)
+
+// rateLimitTransport honors the GitHub rate limits: it retries the
+// idempotent requests failing because of a rate limit or a server error, and
+// waits for the rate limit window to reset once exhausted, so that the
+// go-github client does not refuse the following requests. The rate limit
+// state of every response is recorded in the metrics and logged.
+type rateLimitTransport struct {
+	base  http.RoundTripper
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func newRateLimitTransport(base http.RoundTripper) *rateLimitTransport {
+	return &rateLimitTransport{
+		base:  base,
+		now:   time.Now,
+		sleep: sleep,
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead
+	for attempt := 0; ; attempt++ {
+		res, err := t.base.RoundTrip(req)
+		t.log(req, res, err, attempt)
+
+		wait, retry := t.retryDelay(req, res, err, attempt)
+		if !idempotent || !retry || attempt >= maxRetries || wait > maxRateLimitWait {
+			if err == nil && res.StatusCode < http.StatusBadRequest {
+				err = t.waitForReset(req, res)
+			}
+			return res, err
+		}
+		if err == nil {
+			_, _ = io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+		logrus.Infof("GitHub API: retrying %s %s in %s", req.Method, req.URL.Path, wait)
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryDelay returns how long to wait before retrying the request, and
+// whether it is worth retrying at all
+func (t *rateLimitTransport) retryDelay(
+	req *http.Request,
+	res *http.Response,
+	err error,
+	attempt int,
+) (time.Duration, bool) {
+	backoff := retryBaseDelay << attempt
+	if err != nil {
+		return backoff, req.Context().Err() == nil
+	}
+	switch res.StatusCode {
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		// secondary rate limits set Retry-After, the primary rate limit
+		// exhausts the remaining requests; other 403s are permanent
+		if wait, ok := parseRetryAfter(res.Header.Get("Retry-After"), t.now()); ok {
+			return wait, true
+		}
+		if res.Header.Get("X-RateLimit-Remaining") == "0" {
+			return t.untilReset(res), true
+		}
+		return 0, res.StatusCode == http.StatusTooManyRequests
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return backoff, true
+	}
+	return 0, false
+}
+
+// waitForReset waits for the rate limit window to reset when the response
+// exhausted it and the reset is close enough
+func (t *rateLimitTransport) waitForReset(req *http.Request, res *http.Response) error {
+	if res.Header.Get("X-RateLimit-Remaining") != "0" {
+		return nil
+	}
+	wait := t.untilReset(res)
+	if wait <= 0 || wait > maxRateLimitWait {
+		return nil
+	}
+	logrus.Infof("GitHub API: rate limit exhausted, waiting %s for the reset", wait)
+	return t.sleep(req.Context(), wait)
+}
+
+// untilReset returns the time left until the rate limit window resets
+func (t *rateLimitTransport) untilReset(res *http.Response) time.Duration {
+	reset, err := strconv.ParseInt(res.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return 0
+	}
+	// the reset time has a precision of one second
+	return time.Unix(reset, 0).Sub(t.now()) + time.Second
+}
+
+func (t *rateLimitTransport) log(req *http.Request, res *http.Response, err error, attempt int) {
+	fields := logrus.Fields{
+		"method":  req.Method,
+		"url":     req.URL.Redacted(),
+		"attempt": attempt,
+	}
+	if err != nil {
+		logrus.WithFields(fields).Debugf("GitHub API request failed: %s", err.Error())
+		return
+	}
+	fields["status"] = res.StatusCode
+	remaining := res.Header.Get("X-RateLimit-Remaining")
+	if remaining != "" {
+		fields["rate_limit_remaining"] = remaining
+		fields["rate_limit_reset"] = res.Header.Get("X-RateLimit-Reset")
+		if value, err := strconv.Atoi(remaining); err == nil {
+			metrics.SetGitHubRateLimitRemaining(value)
+		}
+	}
+	logrus.WithFields(fields).Debug("GitHub API request")
+}
+
+// parseRetryAfter parses the Retry-After header, either in seconds or as
+// an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return date.Sub(now), true
+	}
+	return 0, false
+}
+
+// cacheTransport caches the responses to the GET requests having an ETag,
+// and revalidates them with conditional requests: the 304 Not Modified
+// responses do not count against the GitHub rate limit
+type cacheTransport struct {
+	base http.RoundTripper
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type cachedResponse struct {
+	key    string
+	etag   string
+	header http.Header
+	body   []byte
+}
+
+func newCacheTransport(base http.RoundTripper) *cacheTransport {
+	return &cacheTransport{
+		base:    base,
+		entries: map[string]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("If-None-Match") != "" {
+		return t.base.RoundTrip(req)
+	}
+	key := req.URL.String() + " " + req.Header.Get("Accept")
+	cached := t.get(key)
+	if cached != nil {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotModified && cached != nil {
+		_, _ = io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+		logrus.WithField("url", req.URL.Redacted()).Debug("GitHub API: response not modified")
+		return cached.response(req, res.Header), nil
+	}
+	etag := res.Header.Get("ETag")
+	if res.StatusCode != http.StatusOK || etag == "" {
+		return res, nil
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	t.put(&cachedResponse{
+		key:    key,
+		etag:   etag,
+		header: res.Header.Clone(),
+		body:   body,
+	})
+	return res, nil
+}
+
+func (t *cacheTransport) get(key string) *cachedResponse {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	element, ok := t.entries[key]
+	if !ok {
+		return nil
+	}
+	t.lru.MoveToFront(element)
+	return element.Value.(*cachedResponse)
+}
+
+func (t *cacheTransport) put(entry *cachedResponse) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if element, ok := t.entries[entry.key]; ok {
+		element.Value = entry
+		t.lru.MoveToFront(element)
+		return
+	}
+	t.entries[entry.key] = t.lru.PushFront(entry)
+	if t.lru.Len() > maxCachedResponses {
+		oldest := t.lru.Back()
+		t.lru.Remove(oldest)
+		delete(t.entries, oldest.Value.(*cachedResponse).key)
+	}
+}
+
+// response returns the cached response, with the rate limit headers of the
+// revalidation response
+func (c *cachedResponse) response(req *http.Request, revalidation http.Header) *http.Response {
+	header := c.header.Clone()
+	for _, name := range []string{
+		"X-RateLimit-Limit",
+		"X-RateLimit-Remaining",
+		"X-RateLimit-Reset",
+	} {
+		if value := revalidation.Get(name); value != "" {
+			header.Set(name, value)
+		}
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(c.body)),
+		ContentLength: int64(len(c.body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,228 @@
+package github
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Unix(1700000000, 0)
+
+// newTestRateLimitTransport returns a transport recording its waits instead
+// of sleeping
+func newTestRateLimitTransport(waits *[]time.Duration) *rateLimitTransport {
+	t := newRateLimitTransport(http.DefaultTransport)
+	t.now = func() time.Time { return testNow }
+	t.sleep = func(_ context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return nil
+	}
+	return t
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	reset := func(d time.Duration) string {
+		return strconv.FormatInt(testNow.Add(d).Unix(), 10)
+	}
+	testCases := map[string]struct {
+		method    string
+		responses []func(w http.ResponseWriter)
+		status    int
+		requests  int
+		waits     []time.Duration
+	}{
+		"secondary rate limit": {
+			method: http.MethodGet,
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.Header().Set("Retry-After", "30")
+					w.WriteHeader(http.StatusForbidden)
+				},
+				func(w http.ResponseWriter) {},
+			},
+			status:   http.StatusOK,
+			requests: 2,
+			waits:    []time.Duration{30 * time.Second},
+		},
+		"primary rate limit": {
+			method: http.MethodGet,
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.Header().Set("X-RateLimit-Remaining", "0")
+					w.Header().Set("X-RateLimit-Reset", reset(10*time.Second))
+					w.WriteHeader(http.StatusForbidden)
+				},
+				func(w http.ResponseWriter) {
+					w.Header().Set("X-RateLimit-Remaining", "4999")
+				},
+			},
+			status:   http.StatusOK,
+			requests: 2,
+			waits:    []time.Duration{11 * time.Second},
+		},
+		"primary rate limit resetting too late": {
+			method: http.MethodGet,
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.Header().Set("X-RateLimit-Remaining", "0")
+					w.Header().Set("X-RateLimit-Reset", reset(time.Hour))
+					w.WriteHeader(http.StatusForbidden)
+				},
+			},
+			status:   http.StatusForbidden,
+			requests: 1,
+		},
+		"rate limit exhausted": {
+			method: http.MethodPost,
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.Header().Set("X-RateLimit-Remaining", "0")
+					w.Header().Set("X-RateLimit-Reset", reset(5*time.Second))
+					w.WriteHeader(http.StatusCreated)
+				},
+			},
+			status:   http.StatusCreated,
+			requests: 1,
+			waits:    []time.Duration{6 * time.Second},
+		},
+		"server errors": {
+			method: http.MethodGet,
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadGateway) },
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadGateway) },
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadGateway) },
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadGateway) },
+			},
+			status:   http.StatusBadGateway,
+			requests: 4,
+			waits:    []time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+		},
+		"no retry of non-idempotent requests": {
+			method: http.MethodPost,
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadGateway) },
+			},
+			status:   http.StatusBadGateway,
+			requests: 1,
+		},
+		"no retry of permission errors": {
+			method: http.MethodGet,
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.Header().Set("X-RateLimit-Remaining", "4999")
+					w.WriteHeader(http.StatusForbidden)
+				},
+			},
+			status:   http.StatusForbidden,
+			requests: 1,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Less(t, requests, len(tc.responses))
+				tc.responses[requests](w)
+				requests++
+			}))
+			defer srv.Close()
+
+			var waits []time.Duration
+			client := &http.Client{Transport: newTestRateLimitTransport(&waits)}
+			req, err := http.NewRequest(tc.method, srv.URL, nil)
+			require.NoError(t, err)
+			res, err := client.Do(req)
+			require.NoError(t, err)
+			res.Body.Close()
+
+			assert.Equal(t, tc.status, res.StatusCode)
+			assert.Equal(t, tc.requests, requests)
+			assert.Equal(t, tc.waits, waits)
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	wait, ok := parseRetryAfter("120", testNow)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, wait)
+
+	wait, ok = parseRetryAfter(testNow.Add(time.Minute).UTC().Format(http.TimeFormat), testNow)
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, wait)
+
+	_, ok = parseRetryAfter("", testNow)
+	assert.False(t, ok)
+	_, ok = parseRetryAfter("soon", testNow)
+	assert.False(t, ok)
+}
+
+func TestCacheTransport(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(5000-requests))
+		switch {
+		case r.URL.Path == "/no-etag":
+			_, _ = io.WriteString(w, "fresh")
+		case r.Header.Get("If-None-Match") == `"v1"`:
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = io.WriteString(w, "release data")
+		}
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: newCacheTransport(http.DefaultTransport)}
+	get := func(path string) (*http.Response, string) {
+		res, err := client.Get(srv.URL + path)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res, string(body)
+	}
+
+	res, body := get("/releases")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "release data", body)
+
+	res, body = get("/releases")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "release data", body)
+	assert.Equal(t, `"v1"`, res.Header.Get("ETag"))
+	assert.Equal(t, "4998", res.Header.Get("X-RateLimit-Remaining"))
+	assert.Equal(t, 2, requests)
+
+	_, body = get("/no-etag")
+	assert.Equal(t, "fresh", body)
+	_, body = get("/no-etag")
+	assert.Equal(t, "fresh", body)
+	assert.Equal(t, 4, requests)
+
+	res, err := client.Post(srv.URL+"/releases", "text/plain", strings.NewReader("{}"))
+	require.NoError(t, err)
+	res.Body.Close()
+	// not revalidated, the server would answer 304
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestCacheTransportEviction(t *testing.T) {
+	transport := newCacheTransport(http.DefaultTransport)
+	for i := 0; i <= maxCachedResponses; i++ {
+		transport.put(&cachedResponse{key: strconv.Itoa(i), etag: "etag"})
+	}
+	assert.Nil(t, transport.get("0"))
+	assert.NotNil(t, transport.get("1"))
+	assert.NotNil(t, transport.get(strconv.Itoa(maxCachedResponses)))
+	assert.Len(t, transport.entries, maxCachedResponses)
+}
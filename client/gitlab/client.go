@@ -41,6 +41,20 @@ type Client interface {
 		branch string,
 		options *gitlab.RequestOptionFunc,
 	) (*gitlab.Response, error)
+	ListProjectMergeRequests(
+		path string,
+		options *gitlab.ListProjectMergeRequestsOptions,
+	) ([]*gitlab.BasicMergeRequest, error)
+	CreateMergeRequest(
+		path string,
+		options *gitlab.CreateMergeRequestOptions,
+	) (*gitlab.MergeRequest, error)
+	UpdateMergeRequest(
+		path string,
+		iid int64,
+		options *gitlab.UpdateMergeRequestOptions,
+	) (*gitlab.MergeRequest, error)
+	CreateMergeRequestPipeline(path string, iid int64) (*gitlab.PipelineInfo, error)
 }
 
 type gitLabClient struct {
@@ -50,7 +64,12 @@ type gitLabClient struct {
 
 // NewGitLabClient returns a new GitLabClient for the given conf
 func NewGitLabClient(accessToken string, baseURL string, dryRunMode bool) (Client, error) {
-	gitlabClient, err := gitlab.NewClient(accessToken, gitlab.WithBaseURL(baseURL))
+	// the retries are left to the decorator, see NewRetryingClient
+	gitlabClient, err := gitlab.NewClient(
+		accessToken,
+		gitlab.WithBaseURL(baseURL),
+		gitlab.WithoutRetries(),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -66,7 +85,7 @@ func (c *gitLabClient) CancelPipelineBuild(path string, id int64) error {
 		msg := fmt.Sprintf("gitlab.CancelPipelineBuild: path=%s,id=%d",
 			path, id,
 		)
-		logger.GetRequestLogger().Push(msg)
+		record("CancelPipelineBuild", logger.Args{"path": path, "id": id}, msg)
 		return nil
 	}
 	_, _, err := c.client.Pipelines.CancelPipelineBuild(path, id, nil)
@@ -83,7 +102,7 @@ func (c *gitLabClient) CreatePipeline(
 		msg := fmt.Sprintf("gitlab.CreatePipeline: path=%s,options=%s",
 			path, string(optionsJSON),
 		)
-		logger.GetRequestLogger().Push(msg)
+		record("CreatePipeline", logger.Args{"path": path, "options": options}, msg)
 		return &gitlab.Pipeline{}, nil
 	}
 	pipeline, _, err := c.client.Pipelines.CreatePipeline(path, options, nil)
@@ -99,7 +118,7 @@ func (c *gitLabClient) GetPipelineVariables(
 		msg := fmt.Sprintf("gitlab.GetPipelineVariables: path=%s,id=%d",
 			path, id,
 		)
-		logger.GetRequestLogger().Push(msg)
+		record("GetPipelineVariables", logger.Args{"path": path, "id": id}, msg)
 		return []*gitlab.PipelineVariable{}, nil
 	}
 	variables, _, err := c.client.Pipelines.GetPipelineVariables(path, id, nil)
@@ -116,7 +135,7 @@ func (c *gitLabClient) ListProjectPipelines(
 		msg := fmt.Sprintf("gitlab.ListProjectPipelines: path=%s,options=%s",
 			path, string(optionsJSON),
 		)
-		logger.GetRequestLogger().Push(msg)
+		record("ListProjectPipelines", logger.Args{"path": path, "options": options}, msg)
 		return []*gitlab.PipelineInfo{{ID: 1}}, nil
 	}
 	pipelines, _, err := c.client.Pipelines.ListProjectPipelines(path, options, nil)
@@ -133,7 +152,7 @@ func (c *gitLabClient) ProtectRepositoryBranches(
 		msg := fmt.Sprintf("gitlab.ProtectedBranch: path=%s,options=%s",
 			path, string(optionsJSON),
 		)
-		logger.GetRequestLogger().Push(msg)
+		record("ProtectRepositoryBranches", logger.Args{"path": path, "options": options}, msg)
 		return &gitlab.ProtectedBranch{}, nil
 	}
 	protected_branch, _, err := c.client.ProtectedBranches.ProtectRepositoryBranches(
@@ -153,7 +172,7 @@ func (c *gitLabClient) UnprotectRepositoryBranches(
 		msg := fmt.Sprintf("gitlab.UnprotectedBranch: path=%s,branch=%s",
 			path, branch,
 		)
-		logger.GetRequestLogger().Push(msg)
+		record("UnprotectRepositoryBranches", logger.Args{"path": path, "branch": branch}, msg)
 		return nil, nil
 	}
 	response, err := c.client.ProtectedBranches.UnprotectRepositoryBranches(
@@ -174,7 +193,9 @@ func (c *gitLabClient) ListPipelineJobs(
 		msg := fmt.Sprintf("gitlab.ListPipelineJobs: path=%s,pipelineID=%d,options=%s",
 			path, pipelineID, string(optionsJSON),
 		)
-		logger.GetRequestLogger().Push(msg)
+		record("ListPipelineJobs", logger.Args{
+			"path": path, "pipeline_id": pipelineID, "options": options,
+		}, msg)
 		return []*gitlab.Job{}, nil
 	}
 	jobs, _, err := c.client.Jobs.ListPipelineJobs(path, pipelineID, options, nil)
@@ -192,7 +213,7 @@ func (c *gitLabClient) PlayJob(
 		msg := fmt.Sprintf("gitlab.PlayJob: path=%s,jobID=%d,options=%s",
 			path, jobID, string(optionsJSON),
 		)
-		logger.GetRequestLogger().Push(msg)
+		record("PlayJob", logger.Args{"path": path, "job_id": jobID, "options": options}, msg)
 		return &gitlab.Job{}, nil
 	}
 	job, _, err := c.client.Jobs.PlayJob(path, jobID, options, nil)
@@ -209,7 +230,7 @@ func (c *gitLabClient) DeleteBranch(
 		msg := fmt.Sprintf("gitlab.DeleteBranch: path=%s,branch=%s",
 			path, branch,
 		)
-		logger.GetRequestLogger().Push(msg)
+		record("DeleteBranch", logger.Args{"path": path, "branch": branch}, msg)
 		return nil, nil
 	}
 	response, err := c.client.Branches.DeleteBranch(path,
@@ -218,3 +239,83 @@ func (c *gitLabClient) DeleteBranch(
 
 	return response, err
 }
+
+// ListProjectMergeRequests lists the merge requests of a project
+func (c *gitLabClient) ListProjectMergeRequests(
+	path string,
+	options *gitlab.ListProjectMergeRequestsOptions,
+) ([]*gitlab.BasicMergeRequest, error) {
+	if c.dryRunMode {
+		optionsJSON, _ := json.Marshal(options)
+		msg := fmt.Sprintf("gitlab.ListProjectMergeRequests: path=%s,options=%s",
+			path, string(optionsJSON),
+		)
+		record("ListProjectMergeRequests", logger.Args{"path": path, "options": options}, msg)
+		return []*gitlab.BasicMergeRequest{}, nil
+	}
+	mergeRequests, _, err := c.client.MergeRequests.ListProjectMergeRequests(path, options, nil)
+	return mergeRequests, err
+}
+
+// CreateMergeRequest creates a merge request
+func (c *gitLabClient) CreateMergeRequest(
+	path string,
+	options *gitlab.CreateMergeRequestOptions,
+) (*gitlab.MergeRequest, error) {
+	if c.dryRunMode {
+		optionsJSON, _ := json.Marshal(options)
+		msg := fmt.Sprintf("gitlab.CreateMergeRequest: path=%s,options=%s",
+			path, string(optionsJSON),
+		)
+		record("CreateMergeRequest", logger.Args{"path": path, "options": options}, msg)
+		return &gitlab.MergeRequest{}, nil
+	}
+	mergeRequest, _, err := c.client.MergeRequests.CreateMergeRequest(path, options, nil)
+	return mergeRequest, err
+}
+
+// UpdateMergeRequest updates a merge request, e.g. to close it
+func (c *gitLabClient) UpdateMergeRequest(
+	path string,
+	iid int64,
+	options *gitlab.UpdateMergeRequestOptions,
+) (*gitlab.MergeRequest, error) {
+	if c.dryRunMode {
+		optionsJSON, _ := json.Marshal(options)
+		msg := fmt.Sprintf("gitlab.UpdateMergeRequest: path=%s,iid=%d,options=%s",
+			path, iid, string(optionsJSON),
+		)
+		record("UpdateMergeRequest", logger.Args{
+			"path": path, "iid": iid, "options": options,
+		}, msg)
+		return &gitlab.MergeRequest{}, nil
+	}
+	mergeRequest, _, err := c.client.MergeRequests.UpdateMergeRequest(path, iid, options, nil)
+	return mergeRequest, err
+}
+
+// CreateMergeRequestPipeline starts a merge request pipeline
+func (c *gitLabClient) CreateMergeRequestPipeline(
+	path string,
+	iid int64,
+) (*gitlab.PipelineInfo, error) {
+	if c.dryRunMode {
+		msg := fmt.Sprintf("gitlab.CreateMergeRequestPipeline: path=%s,iid=%d",
+			path, iid,
+		)
+		record("CreateMergeRequestPipeline", logger.Args{"path": path, "iid": iid}, msg)
+		return &gitlab.PipelineInfo{}, nil
+	}
+	pipeline, _, err := c.client.MergeRequests.CreateMergeRequestPipeline(path, iid, nil)
+	return pipeline, err
+}
+
+// record records a dry-run API call in the request logger
+func record(operation string, args logger.Args, msg string) {
+	logger.GetRequestLogger().PushEntry(logger.Entry{
+		Kind:      logger.KindGitLab,
+		Operation: operation,
+		Args:      args,
+		Message:   msg,
+	})
+}
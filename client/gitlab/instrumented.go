@@ -0,0 +1,175 @@
+package gitlab
+
+import (
+	"context"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+
+	"github.com/mendersoftware/integration-test-runner/metrics"
+	"github.com/mendersoftware/integration-test-runner/tracing"
+)
+
+type instrumentedClient struct {
+	ctx    context.Context
+	client Client
+}
+
+// NewInstrumentedClient returns a Client recording the latency and the errors
+// of the calls to the given client, and the pipelines created and cancelled,
+// in the metrics; the calls are traced under the span carried by ctx
+func NewInstrumentedClient(ctx context.Context, client Client) Client {
+	return &instrumentedClient{ctx: ctx, client: client}
+}
+
+// observe starts recording a call, traced under the span carried by ctx;
+// the returned function records its outcome
+func observe(ctx context.Context, operation string) func(error) {
+	start := time.Now()
+	_, span := tracing.Start(ctx, "gitlab "+operation)
+	return func(err error) {
+		metrics.ObserveAPICall(metrics.ServiceGitLab, operation, start, err)
+		tracing.End(span, err)
+	}
+}
+
+func (c *instrumentedClient) CancelPipelineBuild(path string, id int64) error {
+	done := observe(c.ctx, "CancelPipelineBuild")
+	err := c.client.CancelPipelineBuild(path, id)
+	done(err)
+	if err == nil {
+		metrics.PipelineCancelled(path)
+	}
+	return err
+}
+
+func (c *instrumentedClient) CreatePipeline(
+	path string,
+	options *gitlab.CreatePipelineOptions,
+) (*gitlab.Pipeline, error) {
+	done := observe(c.ctx, "CreatePipeline")
+	pipeline, err := c.client.CreatePipeline(path, options)
+	done(err)
+	if err == nil {
+		metrics.PipelineCreated(path)
+	}
+	return pipeline, err
+}
+
+func (c *instrumentedClient) GetPipelineVariables(
+	path string,
+	id int64,
+) ([]*gitlab.PipelineVariable, error) {
+	done := observe(c.ctx, "GetPipelineVariables")
+	variables, err := c.client.GetPipelineVariables(path, id)
+	done(err)
+	return variables, err
+}
+
+func (c *instrumentedClient) ProtectRepositoryBranches(
+	path string,
+	options *gitlab.ProtectRepositoryBranchesOptions,
+) (*gitlab.ProtectedBranch, error) {
+	done := observe(c.ctx, "ProtectRepositoryBranches")
+	branch, err := c.client.ProtectRepositoryBranches(path, options)
+	done(err)
+	return branch, err
+}
+
+func (c *instrumentedClient) UnprotectRepositoryBranches(
+	path string,
+	branch string,
+	options gitlab.RequestOptionFunc,
+) (*gitlab.Response, error) {
+	done := observe(c.ctx, "UnprotectRepositoryBranches")
+	res, err := c.client.UnprotectRepositoryBranches(path, branch, options)
+	done(err)
+	return res, err
+}
+
+func (c *instrumentedClient) ListProjectPipelines(
+	path string,
+	options *gitlab.ListProjectPipelinesOptions,
+) ([]*gitlab.PipelineInfo, error) {
+	done := observe(c.ctx, "ListProjectPipelines")
+	pipelines, err := c.client.ListProjectPipelines(path, options)
+	done(err)
+	return pipelines, err
+}
+
+func (c *instrumentedClient) ListPipelineJobs(
+	path string,
+	pipelineID int64,
+	options *gitlab.ListJobsOptions,
+) ([]*gitlab.Job, error) {
+	done := observe(c.ctx, "ListPipelineJobs")
+	jobs, err := c.client.ListPipelineJobs(path, pipelineID, options)
+	done(err)
+	return jobs, err
+}
+
+func (c *instrumentedClient) PlayJob(
+	path string,
+	jobID int64,
+	options *gitlab.PlayJobOptions,
+) (*gitlab.Job, error) {
+	done := observe(c.ctx, "PlayJob")
+	job, err := c.client.PlayJob(path, jobID, options)
+	done(err)
+	return job, err
+}
+
+func (c *instrumentedClient) DeleteBranch(
+	path string,
+	branch string,
+	options *gitlab.RequestOptionFunc,
+) (*gitlab.Response, error) {
+	done := observe(c.ctx, "DeleteBranch")
+	res, err := c.client.DeleteBranch(path, branch, options)
+	done(err)
+	return res, err
+}
+
+func (c *instrumentedClient) ListProjectMergeRequests(
+	path string,
+	options *gitlab.ListProjectMergeRequestsOptions,
+) ([]*gitlab.BasicMergeRequest, error) {
+	done := observe(c.ctx, "ListProjectMergeRequests")
+	mergeRequests, err := c.client.ListProjectMergeRequests(path, options)
+	done(err)
+	return mergeRequests, err
+}
+
+func (c *instrumentedClient) CreateMergeRequest(
+	path string,
+	options *gitlab.CreateMergeRequestOptions,
+) (*gitlab.MergeRequest, error) {
+	done := observe(c.ctx, "CreateMergeRequest")
+	mergeRequest, err := c.client.CreateMergeRequest(path, options)
+	done(err)
+	return mergeRequest, err
+}
+
+func (c *instrumentedClient) UpdateMergeRequest(
+	path string,
+	iid int64,
+	options *gitlab.UpdateMergeRequestOptions,
+) (*gitlab.MergeRequest, error) {
+	done := observe(c.ctx, "UpdateMergeRequest")
+	mergeRequest, err := c.client.UpdateMergeRequest(path, iid, options)
+	done(err)
+	return mergeRequest, err
+}
+
+func (c *instrumentedClient) CreateMergeRequestPipeline(
+	path string,
+	iid int64,
+) (*gitlab.PipelineInfo, error) {
+	done := observe(c.ctx, "CreateMergeRequestPipeline")
+	pipeline, err := c.client.CreateMergeRequestPipeline(path, iid)
+	done(err)
+	if err == nil {
+		metrics.PipelineCreated(path)
+	}
+	return pipeline, err
+}
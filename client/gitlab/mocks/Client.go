@@ -31,6 +31,66 @@ func (_m *Client) CancelPipelineBuild(path string, id int64) error {
 	return r0
 }
 
+// CreateMergeRequest provides a mock function with given fields: path, options
+func (_m *Client) CreateMergeRequest(path string, options *client_go.CreateMergeRequestOptions) (*client_go.MergeRequest, error) {
+	ret := _m.Called(path, options)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateMergeRequest")
+	}
+
+	var r0 *client_go.MergeRequest
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, *client_go.CreateMergeRequestOptions) (*client_go.MergeRequest, error)); ok {
+		return rf(path, options)
+	}
+	if rf, ok := ret.Get(0).(func(string, *client_go.CreateMergeRequestOptions) *client_go.MergeRequest); ok {
+		r0 = rf(path, options)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*client_go.MergeRequest)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, *client_go.CreateMergeRequestOptions) error); ok {
+		r1 = rf(path, options)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateMergeRequestPipeline provides a mock function with given fields: path, iid
+func (_m *Client) CreateMergeRequestPipeline(path string, iid int64) (*client_go.PipelineInfo, error) {
+	ret := _m.Called(path, iid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateMergeRequestPipeline")
+	}
+
+	var r0 *client_go.PipelineInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, int64) (*client_go.PipelineInfo, error)); ok {
+		return rf(path, iid)
+	}
+	if rf, ok := ret.Get(0).(func(string, int64) *client_go.PipelineInfo); ok {
+		r0 = rf(path, iid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*client_go.PipelineInfo)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, int64) error); ok {
+		r1 = rf(path, iid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreatePipeline provides a mock function with given fields: path, options
 func (_m *Client) CreatePipeline(path string, options *client_go.CreatePipelineOptions) (*client_go.Pipeline, error) {
 	ret := _m.Called(path, options)
@@ -121,6 +181,36 @@ func (_m *Client) GetPipelineVariables(path string, id int64) ([]*client_go.Pipe
 	return r0, r1
 }
 
+// ListProjectMergeRequests provides a mock function with given fields: path, options
+func (_m *Client) ListProjectMergeRequests(path string, options *client_go.ListProjectMergeRequestsOptions) ([]*client_go.BasicMergeRequest, error) {
+	ret := _m.Called(path, options)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListProjectMergeRequests")
+	}
+
+	var r0 []*client_go.BasicMergeRequest
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, *client_go.ListProjectMergeRequestsOptions) ([]*client_go.BasicMergeRequest, error)); ok {
+		return rf(path, options)
+	}
+	if rf, ok := ret.Get(0).(func(string, *client_go.ListProjectMergeRequestsOptions) []*client_go.BasicMergeRequest); ok {
+		r0 = rf(path, options)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*client_go.BasicMergeRequest)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, *client_go.ListProjectMergeRequestsOptions) error); ok {
+		r1 = rf(path, options)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListProjectPipelines provides a mock function with given fields: path, options
 func (_m *Client) ListProjectPipelines(path string, options *client_go.ListProjectPipelinesOptions) ([]*client_go.PipelineInfo, error) {
 	ret := _m.Called(path, options)
@@ -263,6 +353,36 @@ func (_m *Client) UnprotectRepositoryBranches(path string, branch string, option
 	return r0, r1
 }
 
+// UpdateMergeRequest provides a mock function with given fields: path, iid, options
+func (_m *Client) UpdateMergeRequest(path string, iid int64, options *client_go.UpdateMergeRequestOptions) (*client_go.MergeRequest, error) {
+	ret := _m.Called(path, iid, options)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateMergeRequest")
+	}
+
+	var r0 *client_go.MergeRequest
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, int64, *client_go.UpdateMergeRequestOptions) (*client_go.MergeRequest, error)); ok {
+		return rf(path, iid, options)
+	}
+	if rf, ok := ret.Get(0).(func(string, int64, *client_go.UpdateMergeRequestOptions) *client_go.MergeRequest); ok {
+		r0 = rf(path, iid, options)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*client_go.MergeRequest)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, int64, *client_go.UpdateMergeRequestOptions) error); ok {
+		r1 = rf(path, iid, options)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewClient creates a new instance of Client. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewClient(t interface {
@@ -0,0 +1,275 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// RetryPolicy configures the retries of the failed GitLab client operations
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts of an operation, including the
+	// first one; 1 disables the retries
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, doubled at every retry
+	// and jittered
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between two attempts
+	MaxDelay time.Duration
+	// Retryable tells whether the operation failing with err can be retried
+	Retryable func(operation string, err error) bool
+}
+
+// DefaultRetryPolicy returns the policy used for the zero fields of a policy
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   2 * time.Second,
+		MaxDelay:    30 * time.Second,
+		Retryable:   IsRetryable,
+	}
+}
+
+// idempotentOperations can be repeated without further side effects when the
+// outcome of a previous attempt is unknown
+var idempotentOperations = map[string]bool{
+	"CancelPipelineBuild":         true,
+	"GetPipelineVariables":        true,
+	"ProtectRepositoryBranches":   true,
+	"UnprotectRepositoryBranches": true,
+	"ListProjectPipelines":        true,
+	"ListPipelineJobs":            true,
+	"DeleteBranch":                true,
+	"ListProjectMergeRequests":    true,
+	"UpdateMergeRequest":          true,
+}
+
+// IsRetryable tells whether an operation failing with err can be retried:
+// the requests rejected before being processed are always retried, the
+// server and network errors only for the idempotent operations. A pipeline
+// for a ref just pushed can also be created again, as GitLab may not see the
+// ref yet.
+func IsRetryable(operation string, err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var errResponse *gitlab.ErrorResponse
+	if errors.As(err, &errResponse) {
+		if errResponse.Response == nil {
+			return false
+		}
+		status := errResponse.Response.StatusCode
+		switch {
+		case status == http.StatusTooManyRequests, status == http.StatusServiceUnavailable:
+			return true
+		case status >= http.StatusInternalServerError:
+			return idempotentOperations[operation]
+		case status == http.StatusBadRequest && operation == "CreatePipeline":
+			return strings.Contains(err.Error(), "Reference not found")
+		}
+		return false
+	}
+	// *url.Error is a net.Error too, even when the request is invalid
+	var (
+		opErr  *net.OpError
+		dnsErr *net.DNSError
+		netErr net.Error
+	)
+	if errors.As(err, &opErr) || errors.As(err, &dnsErr) ||
+		(errors.As(err, &netErr) && netErr.Timeout()) {
+		return idempotentOperations[operation]
+	}
+	return false
+}
+
+// delay returns the jittered delay before the given retry, starting from 0
+func (p RetryPolicy) delay(retry int) time.Duration {
+	d := p.BaseDelay << retry
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	// equal jitter: between half and the whole delay
+	return d/2 + rand.N(d/2+1)
+}
+
+type retryingClient struct {
+	ctx    context.Context
+	client Client
+	policy RetryPolicy
+}
+
+// NewRetryingClient returns a Client retrying the failed calls to the given
+// client according to the policy, whose zero fields take the default values;
+// the retries stop when ctx is done
+func NewRetryingClient(ctx context.Context, client Client, policy RetryPolicy) Client {
+	defaults := DefaultRetryPolicy()
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = defaults.MaxAttempts
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = defaults.BaseDelay
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = defaults.MaxDelay
+	}
+	if policy.Retryable == nil {
+		policy.Retryable = defaults.Retryable
+	}
+	return &retryingClient{ctx: ctx, client: client, policy: policy}
+}
+
+// retry calls the operation until it succeeds, fails with an error which
+// cannot be retried, or the attempts are exhausted
+func retry[T any](c *retryingClient, operation string, call func() (T, error)) (T, error) {
+	for attempt := 1; ; attempt++ {
+		res, err := call()
+		if err == nil || attempt >= c.policy.MaxAttempts ||
+			!c.policy.Retryable(operation, err) {
+			return res, err
+		}
+		delay := c.policy.delay(attempt - 1)
+		logrus.Infof("GitLab %s failed, retrying in %s: %s", operation, delay, err.Error())
+		timer := time.NewTimer(delay)
+		select {
+		case <-c.ctx.Done():
+			timer.Stop()
+			return res, err
+		case <-timer.C:
+		}
+	}
+}
+
+// retryErr is retry for the operations returning only an error
+func retryErr(c *retryingClient, operation string, call func() error) error {
+	_, err := retry(c, operation, func() (struct{}, error) {
+		return struct{}{}, call()
+	})
+	return err
+}
+
+func (c *retryingClient) CancelPipelineBuild(path string, id int64) error {
+	return retryErr(c, "CancelPipelineBuild", func() error {
+		return c.client.CancelPipelineBuild(path, id)
+	})
+}
+
+func (c *retryingClient) CreatePipeline(
+	path string,
+	options *gitlab.CreatePipelineOptions,
+) (*gitlab.Pipeline, error) {
+	return retry(c, "CreatePipeline", func() (*gitlab.Pipeline, error) {
+		return c.client.CreatePipeline(path, options)
+	})
+}
+
+func (c *retryingClient) GetPipelineVariables(
+	path string,
+	id int64,
+) ([]*gitlab.PipelineVariable, error) {
+	return retry(c, "GetPipelineVariables", func() ([]*gitlab.PipelineVariable, error) {
+		return c.client.GetPipelineVariables(path, id)
+	})
+}
+
+func (c *retryingClient) ProtectRepositoryBranches(
+	path string,
+	options *gitlab.ProtectRepositoryBranchesOptions,
+) (*gitlab.ProtectedBranch, error) {
+	return retry(c, "ProtectRepositoryBranches", func() (*gitlab.ProtectedBranch, error) {
+		return c.client.ProtectRepositoryBranches(path, options)
+	})
+}
+
+func (c *retryingClient) UnprotectRepositoryBranches(
+	path string,
+	branch string,
+	options gitlab.RequestOptionFunc,
+) (*gitlab.Response, error) {
+	return retry(c, "UnprotectRepositoryBranches", func() (*gitlab.Response, error) {
+		return c.client.UnprotectRepositoryBranches(path, branch, options)
+	})
+}
+
+func (c *retryingClient) ListProjectPipelines(
+	path string,
+	options *gitlab.ListProjectPipelinesOptions,
+) ([]*gitlab.PipelineInfo, error) {
+	return retry(c, "ListProjectPipelines", func() ([]*gitlab.PipelineInfo, error) {
+		return c.client.ListProjectPipelines(path, options)
+	})
+}
+
+func (c *retryingClient) ListPipelineJobs(
+	path string,
+	pipelineID int64,
+	options *gitlab.ListJobsOptions,
+) ([]*gitlab.Job, error) {
+	return retry(c, "ListPipelineJobs", func() ([]*gitlab.Job, error) {
+		return c.client.ListPipelineJobs(path, pipelineID, options)
+	})
+}
+
+func (c *retryingClient) PlayJob(
+	path string,
+	jobID int64,
+	options *gitlab.PlayJobOptions,
+) (*gitlab.Job, error) {
+	return retry(c, "PlayJob", func() (*gitlab.Job, error) {
+		return c.client.PlayJob(path, jobID, options)
+	})
+}
+
+func (c *retryingClient) DeleteBranch(
+	path string,
+	branch string,
+	options *gitlab.RequestOptionFunc,
+) (*gitlab.Response, error) {
+	return retry(c, "DeleteBranch", func() (*gitlab.Response, error) {
+		return c.client.DeleteBranch(path, branch, options)
+	})
+}
+
+func (c *retryingClient) ListProjectMergeRequests(
+	path string,
+	options *gitlab.ListProjectMergeRequestsOptions,
+) ([]*gitlab.BasicMergeRequest, error) {
+	return retry(c, "ListProjectMergeRequests", func() ([]*gitlab.BasicMergeRequest, error) {
+		return c.client.ListProjectMergeRequests(path, options)
+	})
+}
+
+func (c *retryingClient) CreateMergeRequest(
+	path string,
+	options *gitlab.CreateMergeRequestOptions,
+) (*gitlab.MergeRequest, error) {
+	return retry(c, "CreateMergeRequest", func() (*gitlab.MergeRequest, error) {
+		return c.client.CreateMergeRequest(path, options)
+	})
+}
+
+func (c *retryingClient) UpdateMergeRequest(
+	path string,
+	iid int64,
+	options *gitlab.UpdateMergeRequestOptions,
+) (*gitlab.MergeRequest, error) {
+	return retry(c, "UpdateMergeRequest", func() (*gitlab.MergeRequest, error) {
+		return c.client.UpdateMergeRequest(path, iid, options)
+	})
+}
+
+func (c *retryingClient) CreateMergeRequestPipeline(
+	path string,
+	iid int64,
+) (*gitlab.PipelineInfo, error) {
+	return retry(c, "CreateMergeRequestPipeline", func() (*gitlab.PipelineInfo, error) {
+		return c.client.CreateMergeRequestPipeline(path, iid)
+	})
+}
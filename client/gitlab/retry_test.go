@@ -0,0 +1,151 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+
+	"github.com/mendersoftware/integration-test-runner/client/gitlab/mocks"
+)
+
+func errorResponse(status int, message string) error {
+	return &gitlab.ErrorResponse{
+		Response: &http.Response{
+			StatusCode: status,
+			Request: &http.Request{
+				Method: http.MethodPost,
+				URL:    &url.URL{Scheme: "https", Host: "gitlab.com", Path: "/api/v4"},
+			},
+		},
+		Message: message,
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	netErr := &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+	testCases := map[string]struct {
+		operation string
+		err       error
+		retryable bool
+	}{
+		"no error":            {"ListPipelineJobs", nil, false},
+		"rate limited":        {"CreatePipeline", errorResponse(429, ""), true},
+		"unavailable":         {"PlayJob", errorResponse(503, ""), true},
+		"server error":        {"ListProjectPipelines", errorResponse(500, ""), true},
+		"server error create": {"CreatePipeline", errorResponse(502, ""), false},
+		"ref not pushed yet": {
+			"CreatePipeline", errorResponse(400, "{base: [Reference not found]}"), true,
+		},
+		"missing CI config": {
+			"CreatePipeline", errorResponse(400, "{base: [Missing CI config file]}"), false,
+		},
+		"forbidden":      {"ProtectRepositoryBranches", errorResponse(403, ""), false},
+		"not found":      {"DeleteBranch", gitlab.ErrNotFound, false},
+		"network":        {"GetPipelineVariables", netErr, true},
+		"network create": {"CreatePipeline", netErr, false},
+		"invalid url": {
+			"ListPipelineJobs",
+			&url.Error{Op: "Get", URL: "/api/v4", Err: errors.New("unsupported protocol scheme")},
+			false,
+		},
+		"canceled":        {"ListPipelineJobs", context.Canceled, false},
+		"unknown failure": {"ListPipelineJobs", errors.New("failed"), false},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.retryable, IsRetryable(tc.operation, tc.err))
+		})
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	for retry, expected := range []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second,
+	} {
+		for i := 0; i < 20; i++ {
+			delay := policy.delay(retry)
+			assert.GreaterOrEqual(t, delay, expected/2)
+			assert.LessOrEqual(t, delay, expected)
+		}
+	}
+	// no overflow with many retries
+	assert.LessOrEqual(t, policy.delay(100), 5*time.Second)
+}
+
+func TestRetryingClient(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    time.Millisecond,
+	}
+
+	t.Run("retried until success", func(t *testing.T) {
+		client := mocks.NewClient(t)
+		client.On("ListPipelineJobs", "group/project", int64(1), mock.Anything).
+			Return(nil, errorResponse(502, "")).Once()
+		client.On("ListPipelineJobs", "group/project", int64(1), mock.Anything).
+			Return([]*gitlab.Job{{ID: 2}}, nil).Once()
+
+		jobs, err := NewRetryingClient(context.Background(), client, policy).
+			ListPipelineJobs("group/project", 1, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []*gitlab.Job{{ID: 2}}, jobs)
+	})
+
+	t.Run("attempts exhausted", func(t *testing.T) {
+		client := mocks.NewClient(t)
+		client.On("CancelPipelineBuild", "group/project", int64(1)).
+			Return(errorResponse(429, "")).Times(3)
+
+		err := NewRetryingClient(context.Background(), client, policy).
+			CancelPipelineBuild("group/project", 1)
+		assert.Error(t, err)
+	})
+
+	t.Run("not retryable", func(t *testing.T) {
+		client := mocks.NewClient(t)
+		client.On("CreatePipeline", "group/project", mock.Anything).
+			Return(nil, errorResponse(500, "")).Once()
+
+		_, err := NewRetryingClient(context.Background(), client, policy).
+			CreatePipeline("group/project", &gitlab.CreatePipelineOptions{})
+		assert.Error(t, err)
+	})
+
+	t.Run("context canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		client := mocks.NewClient(t)
+		client.On("PlayJob", "group/project", int64(1), mock.Anything).
+			Return(nil, errorResponse(503, "")).Once()
+
+		_, err := NewRetryingClient(ctx, client, RetryPolicy{BaseDelay: time.Hour}).
+			PlayJob("group/project", 1, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("custom retryable", func(t *testing.T) {
+		client := mocks.NewClient(t)
+		client.On("DeleteBranch", "group/project", "pr_1", mock.Anything).
+			Return(nil, errors.New("flaky")).Twice()
+		client.On("DeleteBranch", "group/project", "pr_1", mock.Anything).
+			Return(&gitlab.Response{}, nil).Once()
+
+		retryable := policy
+		retryable.Retryable = func(operation string, err error) bool {
+			return operation == "DeleteBranch"
+		}
+		_, err := NewRetryingClient(context.Background(), client, retryable).
+			DeleteBranch("group/project", "pr_1", nil)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v28/github"
+	"github.com/sirupsen/logrus"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"go.opentelemetry.io/otel/attribute"
+
+	clientgithub "github.com/mendersoftware/integration-test-runner/client/github"
+	"github.com/mendersoftware/integration-test-runner/tracing"
+)
+
+const (
+	// clientPipelineStatusContext is the commit status gating the merge of
+	// the pull requests until the client pipelines of their head succeeded
+	clientPipelineStatusContext = "client pipeline required"
+
+	clientPipelineWaivedPrefix = "Waived by @"
+
+	// statusDescriptionMaxLength is the longest description GitHub accepts
+	statusDescriptionMaxLength = 140
+)
+
+// clientPipelineReleaseContext returns the commit status of the client
+// pipeline of a release
+func clientPipelineReleaseContext(release string) string {
+	return "client pipeline (" + release + ")"
+}
+
+// hasClientPipelineStatus tells whether the pull requests of the repository
+// get the client pipeline status
+func hasClientPipelineStatus(conf *config, repo string) bool {
+	return conf.clientPipelineStatus && slices.Contains(clientPipelineRepositories, repo)
+}
+
+func setCommitStatus(
+	ctx context.Context,
+	githubClient clientgithub.Client,
+	conf *config,
+	repo, sha, statusContext, state, description, targetURL string,
+) error {
+	if len(description) > statusDescriptionMaxLength {
+		description = description[:statusDescriptionMaxLength-3] + "..."
+	}
+	status := &github.RepoStatus{
+		Context:     github.String(statusContext),
+		State:       github.String(state),
+		Description: github.String(description),
+	}
+	if targetURL != "" {
+		status.TargetURL = github.String(targetURL)
+	}
+	return githubClient.CreateStatus(ctx, conf.githubOrganization, repo, sha, status)
+}
+
+// getClientPipelineReleases returns the releases a client pipeline has to
+// succeed for before merging the pull request
+func getClientPipelineReleases(
+	log *logrus.Entry,
+	conf *config,
+	pr *github.PullRequestEvent,
+) []string {
+	releases := []string{}
+	for _, build := range getClientBuilds(log, conf, pr) {
+		if skipClientBuildReason(&build, NewBuildOptions()) != "" {
+			continue
+		}
+		if !slices.Contains(releases, build.baseBranch) {
+			releases = append(releases, build.baseBranch)
+		}
+	}
+	return releases
+}
+
+// updateClientPipelineStatus sets the client pipeline status of the head of
+// the pull request from the statuses of the client pipelines of each release:
+// pending until all of them succeeded, unless waived
+func updateClientPipelineStatus(
+	ctx context.Context,
+	log *logrus.Entry,
+	githubClient clientgithub.Client,
+	conf *config,
+	pr *github.PullRequestEvent,
+) error {
+	repo := pr.GetRepo().GetName()
+	sha := pr.GetPullRequest().GetHead().GetSHA()
+	statuses, err := githubClient.GetCombinedStatus(ctx, conf.githubOrganization, repo, sha)
+	if err != nil {
+		return err
+	}
+	latest := map[string]*github.RepoStatus{}
+	for _, status := range statuses {
+		latest[status.GetContext()] = status
+	}
+	if required := latest[clientPipelineStatusContext]; required.GetState() == "success" &&
+		strings.HasPrefix(required.GetDescription(), clientPipelineWaivedPrefix) {
+		log.Debugf("The client pipeline is waived for %s", sha)
+		return nil
+	}
+
+	releases := getClientPipelineReleases(log, conf, pr)
+	if len(releases) == 0 {
+		return setCommitStatus(ctx, githubClient, conf, repo, sha, clientPipelineStatusContext,
+			"success", "No client pipeline is needed", "")
+	}
+	missing := []string{}
+	for _, release := range releases {
+		if latest[clientPipelineReleaseContext(release)].GetState() != "success" {
+			missing = append(missing, release)
+		}
+	}
+	if len(missing) == 0 {
+		return setCommitStatus(ctx, githubClient, conf, repo, sha, clientPipelineStatusContext,
+			"success", "The client pipelines succeeded for "+strings.Join(releases, ", "), "")
+	}
+	return setCommitStatus(ctx, githubClient, conf, repo, sha, clientPipelineStatusContext,
+		"pending", "Waiting for a successful client pipeline for "+strings.Join(missing, ", "), "")
+}
+
+// waiveClientPipeline sets the client pipeline status of the head of the
+// pull request successful, recording who waived it and why
+func waiveClientPipeline(
+	ctx *gin.Context,
+	log *logrus.Entry,
+	githubClient clientgithub.Client,
+	conf *config,
+	pr *github.PullRequestEvent,
+	user string,
+	reason string,
+) error {
+	if !hasClientPipelineStatus(conf, pr.GetRepo().GetName()) {
+		return say(ctx, "The client pipeline is not required in this repository.",
+			nil, log, conf, pr)
+	}
+	if reason == "" {
+		return say(ctx, "Please tell me why, e.g.: `"+commandWaiveClientPipeline+
+			" docs only change`", nil, log, conf, pr)
+	}
+	log.Infof("%s waived the client pipeline: %s", user, reason)
+	return setCommitStatus(ctx, githubClient, conf, pr.GetRepo().GetName(),
+		pr.GetPullRequest().GetHead().GetSHA(), clientPipelineStatusContext, "success",
+		clientPipelineWaivedPrefix+user+": "+reason, "")
+}
+
+// parseWaiveReason returns the reason following the waive command
+func parseWaiveReason(commentBody string) string {
+	idx := strings.Index(commentBody, commandWaiveClientPipeline)
+	if idx < 0 {
+		return ""
+	}
+	return strings.Join(strings.Fields(commentBody[idx+len(commandWaiveClientPipeline):]), " ")
+}
+
+func processGitLabPipelineEvent(
+	ctx *gin.Context,
+	event *gitlab.PipelineEvent,
+	githubClient clientgithub.Client,
+	conf *config,
+) error {
+	spanCtx, span := tracing.Start(context.Background(), "webhook gitlab pipeline",
+		attribute.String("gitlab.project", event.Project.PathWithNamespace),
+		attribute.Int64("gitlab.pipeline", event.ObjectAttributes.ID),
+	)
+	ctx.Set(tracing.ContextKey, spanCtx)
+	err := updateClientPipelineReleaseStatus(ctx, event, githubClient, conf)
+	tracing.End(span, err)
+	return err
+}
+
+// clientPipelineStates maps the GitLab pipeline statuses to the GitHub
+// commit status states, the other statuses are ignored
+var clientPipelineStates = map[string]string{
+	string(gitlab.Pending):  "pending",
+	string(gitlab.Running):  "pending",
+	string(gitlab.Success):  "success",
+	string(gitlab.Failed):   "failure",
+	string(gitlab.Canceled): "error",
+}
+
+// updateClientPipelineReleaseStatus sets the status of the client pipeline of
+// a release on the commit it built, and updates the client pipeline status of
+// the pull request if the commit is still its head
+func updateClientPipelineReleaseStatus(
+	ctx *gin.Context,
+	event *gitlab.PipelineEvent,
+	githubClient clientgithub.Client,
+	conf *config,
+) error {
+	log := getCustomLoggerFromContext(ctx)
+	if event.Project.PathWithNamespace != clientPipelinePath {
+		log.Debugf("ignoring pipeline of project %s", event.Project.PathWithNamespace)
+		return nil
+	}
+	state, ok := clientPipelineStates[event.ObjectAttributes.Status]
+	if !ok {
+		log.Debugf("ignoring pipeline with status %s", event.ObjectAttributes.Status)
+		return nil
+	}
+	variables := map[string]string{}
+	for _, variable := range event.ObjectAttributes.Variables {
+		variables[variable.Key] = variable.Value
+	}
+	repo := variables[pipelineOriginRepoKey]
+	release := variables[pipelineOriginReleaseKey]
+	sha := variables[pipelineOriginSHAKey]
+	number, err := strconv.Atoi(variables[pipelineOriginPRKey])
+	if err != nil || repo == "" || release == "" || sha == "" {
+		log.Debugf("ignoring pipeline %d not started for a pull request",
+			event.ObjectAttributes.ID)
+		return nil
+	}
+	if !hasClientPipelineStatus(conf, repo) {
+		return nil
+	}
+
+	err = setCommitStatus(ctx, githubClient, conf, repo, sha,
+		clientPipelineReleaseContext(release), state,
+		"Pipeline #"+strconv.FormatInt(event.ObjectAttributes.ID, 10)+" "+
+			event.ObjectAttributes.Status, event.ObjectAttributes.URL)
+	if err != nil {
+		log.Errorf("Failed to set the status of the client pipeline: %s", err.Error())
+		return err
+	}
+
+	pr, err := githubClient.GetPullRequest(ctx, conf.githubOrganization, repo, number)
+	if err != nil {
+		log.Errorf("Unable to retrieve the pull request: %s", err.Error())
+		return err
+	}
+	if pr.GetHead().GetSHA() != sha {
+		log.Debugf("%s/%d has moved past %s", repo, number, sha)
+		return nil
+	}
+	return updateClientPipelineStatus(ctx, log, githubClient, conf, &github.PullRequestEvent{
+		Repo:        &github.Repository{Name: github.String(repo)},
+		Number:      github.Int(number),
+		PullRequest: pr,
+	})
+}
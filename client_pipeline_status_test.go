@@ -0,0 +1,175 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v28/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const clientPipelineTestSHA = "5ca6c70c8aec49182c885a264a31721d10951fc0"
+
+func clientPipelineStatusEnvironment(t *testing.T) (*e2eEnvironment, *github.PullRequestEvent) {
+	env := newE2EEnvironment(t)
+	env.conf.clientPipelineStatus = true
+	pr := env.pullRequestEvent("synchronize", 7)
+	pr.Repo.Name = github.String("meta-mender")
+	pr.PullRequest.Head.SHA = github.String(clientPipelineTestSHA)
+	env.github.AddPullRequest("cfengine", "meta-mender", pr.PullRequest)
+	return env, pr
+}
+
+func clientPipelineEvent(status string) *gitlab.PipelineEvent {
+	event := &gitlab.PipelineEvent{}
+	event.Project.PathWithNamespace = clientPipelinePath
+	event.ObjectAttributes.ID = 42
+	event.ObjectAttributes.Status = status
+	event.ObjectAttributes.URL = "https://gitlab.com/Northern.tech/Mender/mender-qa/-/pipelines/42"
+	for key, value := range map[string]string{
+		pipelineOriginRepoKey:    "meta-mender",
+		pipelineOriginPRKey:      "7",
+		pipelineOriginReleaseKey: "master",
+		pipelineOriginSHAKey:     clientPipelineTestSHA,
+	} {
+		event.ObjectAttributes.Variables = append(event.ObjectAttributes.Variables,
+			gitlab.PipelineEventObjectAttributesVariable{Key: key, Value: value})
+	}
+	return event
+}
+
+func latestStatus(env *e2eEnvironment, statusContext string) *github.RepoStatus {
+	for _, status := range env.github.Statuses("cfengine", "meta-mender", clientPipelineTestSHA) {
+		if status.GetContext() == statusContext {
+			return status
+		}
+	}
+	return nil
+}
+
+func TestClientPipelineStatus(t *testing.T) {
+	env, pr := clientPipelineStatusEnvironment(t)
+	log := getCustomLoggerFromContext(&gin.Context{})
+
+	err := updateClientPipelineStatus(&gin.Context{}, log, githubClient, env.conf, pr)
+	require.NoError(t, err)
+	required := latestStatus(env, clientPipelineStatusContext)
+	require.NotNil(t, required)
+	assert.Equal(t, "pending", required.GetState())
+	assert.Equal(t, "Waiting for a successful client pipeline for master", required.GetDescription())
+
+	err = processGitLabPipelineEvent(&gin.Context{}, clientPipelineEvent(string(gitlab.Failed)),
+		githubClient, env.conf)
+	require.NoError(t, err)
+	assert.Equal(t, "failure", latestStatus(env, clientPipelineReleaseContext("master")).GetState())
+	assert.Equal(t, "pending", latestStatus(env, clientPipelineStatusContext).GetState())
+
+	err = processGitLabPipelineEvent(&gin.Context{}, clientPipelineEvent(string(gitlab.Success)),
+		githubClient, env.conf)
+	require.NoError(t, err)
+	release := latestStatus(env, clientPipelineReleaseContext("master"))
+	assert.Equal(t, "success", release.GetState())
+	assert.Equal(t, "Pipeline #42 success", release.GetDescription())
+	assert.Equal(t, clientPipelineEvent("").ObjectAttributes.URL, release.GetTargetURL())
+	required = latestStatus(env, clientPipelineStatusContext)
+	assert.Equal(t, "success", required.GetState())
+	assert.Equal(t, "The client pipelines succeeded for master", required.GetDescription())
+}
+
+func TestClientPipelineStatusBusyHead(t *testing.T) {
+	env, pr := clientPipelineStatusEnvironment(t)
+	env.github.AddStatus("cfengine", "meta-mender", clientPipelineTestSHA, &github.RepoStatus{
+		Context: github.String(clientPipelineReleaseContext("master")),
+		State:   github.String("success"),
+	})
+	// the success of the release is older than the statuses of many pushes
+	for i := 0; i < 150; i++ {
+		env.github.AddStatus("cfengine", "meta-mender", clientPipelineTestSHA,
+			&github.RepoStatus{
+				Context: github.String(commitLintStatusContext),
+				State:   github.String("success"),
+			})
+	}
+
+	err := updateClientPipelineStatus(&gin.Context{}, getCustomLoggerFromContext(&gin.Context{}),
+		githubClient, env.conf, pr)
+	require.NoError(t, err)
+	assert.Equal(t, "success", latestStatus(env, clientPipelineStatusContext).GetState())
+}
+
+func TestClientPipelineStatusIgnoresStaleHead(t *testing.T) {
+	env, pr := clientPipelineStatusEnvironment(t)
+	pr.PullRequest.Head.SHA = github.String("0000000000000000000000000000000000000000")
+
+	err := processGitLabPipelineEvent(&gin.Context{}, clientPipelineEvent(string(gitlab.Success)),
+		githubClient, env.conf)
+	require.NoError(t, err)
+	assert.Equal(t, "success", latestStatus(env, clientPipelineReleaseContext("master")).GetState())
+	assert.Nil(t, latestStatus(env, clientPipelineStatusContext))
+}
+
+func TestClientPipelineStatusNotNeeded(t *testing.T) {
+	env, pr := clientPipelineStatusEnvironment(t)
+	pr.PullRequest.Base.Label = github.String("cfengine:master-next")
+
+	err := updateClientPipelineStatus(&gin.Context{}, getCustomLoggerFromContext(&gin.Context{}),
+		githubClient, env.conf, pr)
+	require.NoError(t, err)
+	required := latestStatus(env, clientPipelineStatusContext)
+	assert.Equal(t, "success", required.GetState())
+	assert.Equal(t, "No client pipeline is needed", required.GetDescription())
+}
+
+func TestWaiveClientPipeline(t *testing.T) {
+	env, pr := clientPipelineStatusEnvironment(t)
+	log := getCustomLoggerFromContext(&gin.Context{})
+
+	err := waiveClientPipeline(&gin.Context{}, log, githubClient, env.conf, pr, "alice", "")
+	require.NoError(t, err)
+	assert.Nil(t, latestStatus(env, clientPipelineStatusContext))
+	comments := env.github.Comments("cfengine", "meta-mender", 7)
+	require.Len(t, comments, 1)
+	assert.Contains(t, comments[0].GetBody(), "Please tell me why")
+
+	err = waiveClientPipeline(&gin.Context{}, log, githubClient, env.conf, pr, "alice",
+		"docs only change")
+	require.NoError(t, err)
+	required := latestStatus(env, clientPipelineStatusContext)
+	assert.Equal(t, "success", required.GetState())
+	assert.Equal(t, "Waived by @alice: docs only change", required.GetDescription())
+
+	// a waiver outlives the following updates of the same head
+	err = updateClientPipelineStatus(&gin.Context{}, log, githubClient, env.conf, pr)
+	require.NoError(t, err)
+	assert.Equal(t, required, latestStatus(env, clientPipelineStatusContext))
+}
+
+func TestWaiveClientPipelineNotRequired(t *testing.T) {
+	env, pr := clientPipelineStatusEnvironment(t)
+	env.conf.clientPipelineStatus = false
+
+	err := waiveClientPipeline(&gin.Context{}, getCustomLoggerFromContext(&gin.Context{}),
+		githubClient, env.conf, pr, "alice", "docs only change")
+	require.NoError(t, err)
+	assert.Nil(t, latestStatus(env, clientPipelineStatusContext))
+	comments := env.github.Comments("cfengine", "meta-mender", 7)
+	require.Len(t, comments, 1)
+	assert.Equal(t, "The client pipeline is not required in this repository.",
+		comments[0].GetBody())
+}
+
+func TestParseWaiveReason(t *testing.T) {
+	testCases := map[string]string{
+		"@mender-test-bot waive client pipeline docs only change":  "docs only change",
+		"@mender-test-bot waive client pipeline":                   "",
+		"@mender-test-bot waive client pipeline \n  flaky\trunner": "flaky runner",
+		"@mender-test-bot start client pipeline":                   "",
+	}
+	for body, reason := range testCases {
+		t.Run(body, func(t *testing.T) {
+			assert.Equal(t, reason, parseWaiveReason(body))
+		})
+	}
+}
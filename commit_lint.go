@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mendersoftware/integration-test-runner/changelog"
+	clientgithub "github.com/mendersoftware/integration-test-runner/client/github"
+)
+
+// commitLintStatusContext is the commit status set on every commit of the
+// pull requests with the problems of its message, and on their head with
+// the problems of all of them
+const commitLintStatusContext = "commit messages"
+
+// CommitLintRules are the rules the messages of the commits of the pull
+// requests of an organization follow
+type CommitLintRules struct {
+	// Types are the types of the conventional commit headers allowed, any
+	// type is allowed if empty
+	Types []string `json:"types"`
+	// RequireConventionalHeader requires the titles to be conventional
+	// commit headers, e.g. "fix(auth): refresh the token"
+	RequireConventionalHeader bool `json:"require_conventional_header"`
+	// RequireChangelog requires a Changelog trailer, possibly None
+	RequireChangelog bool `json:"require_changelog"`
+	// RequireTicket requires a Ticket trailer, possibly None
+	RequireTicket bool `json:"require_ticket"`
+	// TicketPattern is the pattern the tickets match, e.g. "^[A-Z]+-[0-9]+$"
+	TicketPattern string `json:"ticket_pattern"`
+	// HelpURL is the target of the statuses, documenting the rules
+	HelpURL string `json:"help_url"`
+
+	ticketPattern *regexp.Regexp
+}
+
+// loadCommitLintConfig reads the rules of the organizations from the JSON
+// file at path, keyed by organization
+func loadCommitLintConfig(path string) (map[string]*CommitLintRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules map[string]*CommitLintRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("invalid commit lint config %s: %w", path, err)
+	}
+	for org, orgRules := range rules {
+		if orgRules == nil {
+			return nil, fmt.Errorf("invalid commit lint config %s: no rules for %s", path, org)
+		}
+		if orgRules.TicketPattern != "" {
+			orgRules.ticketPattern, err = regexp.Compile(orgRules.TicketPattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid commit lint config %s: ticket pattern of %s: %w",
+					path, org, err)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// lintCommitMessage returns the problems of the commit message, none if it
+// follows the rules
+func lintCommitMessage(rules *CommitLintRules, message string) []string {
+	problems := []string{}
+	m := changelog.ParseMessage(message)
+	switch {
+	case m.Header == nil && rules.RequireConventionalHeader:
+		problems = append(problems, "the title is not a conventional commit header")
+	case m.Header != nil && len(rules.Types) > 0 && !slices.Contains(rules.Types, m.Header.Type):
+		problems = append(problems, fmt.Sprintf("the type %q is not allowed", m.Header.Type))
+	}
+	if len(m.Changelogs) == 0 && rules.RequireChangelog {
+		problems = append(problems, "missing Changelog trailer")
+	}
+	for _, text := range m.Changelogs {
+		if text == "" {
+			problems = append(problems, "empty Changelog trailer")
+		}
+	}
+	if len(m.Tickets) == 0 && rules.RequireTicket {
+		problems = append(problems, "missing Ticket trailer")
+	}
+	for _, ticket := range m.Tickets {
+		if rules.ticketPattern != nil && !strings.EqualFold(ticket, changelog.KeywordNone) &&
+			!rules.ticketPattern.MatchString(ticket) {
+			problems = append(problems, fmt.Sprintf("the ticket %q is malformed", ticket))
+		}
+	}
+	return problems
+}
+
+// updateCommitLintStatus lints the messages of the commits of the pull
+// request, as returned by getPullRequestCommits, setting the commit messages
+// status of every commit; the status of the head sums them up, so that it
+// can gate the merge
+func updateCommitLintStatus(
+	ctx context.Context,
+	log *logrus.Entry,
+	githubClient clientgithub.Client,
+	conf *config,
+	pr *github.PullRequestEvent,
+	commits []changelog.Commit,
+) error {
+	rules := conf.commitLintRules[conf.githubOrganization]
+	if rules == nil {
+		return nil
+	}
+	if len(commits) == 0 {
+		log.Debugf("No commit to lint in PR %d", pr.GetNumber())
+		return nil
+	}
+
+	repo := pr.GetRepo().GetName()
+	headSHA := pr.GetPullRequest().GetHead().GetSHA()
+	var failed []string
+	for _, commit := range commits {
+		problems := lintCommitMessage(rules, commit.Message)
+		if len(problems) > 0 {
+			failed = append(failed, fmt.Sprintf("%.7s: %s", commit.SHA,
+				strings.Join(problems, ", ")))
+		}
+		if commit.SHA == headSHA {
+			continue
+		}
+		state, description := "success", "The commit message is valid"
+		if len(problems) > 0 {
+			state, description = "failure", strings.Join(problems, ", ")
+		}
+		if err := setCommitStatus(ctx, githubClient, conf, repo, commit.SHA,
+			commitLintStatusContext, state, description, rules.HelpURL); err != nil {
+			return err
+		}
+	}
+
+	state := "success"
+	description := fmt.Sprintf("The %d commit messages are valid", len(commits))
+	if len(failed) > 0 {
+		state = "failure"
+		description = fmt.Sprintf("%d of %d commits have problems: %s",
+			len(failed), len(commits), strings.Join(failed, "; "))
+	}
+	return setCommitStatus(ctx, githubClient, conf, repo, headSHA, commitLintStatusContext,
+		state, description, rules.HelpURL)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v28/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const commitLintTestConfig = `{
+  "cfengine": {
+    "types": ["feat", "fix", "chore"],
+    "require_conventional_header": true,
+    "require_changelog": true,
+    "require_ticket": true,
+    "ticket_pattern": "^[A-Z]+-[0-9]+$",
+    "help_url": "https://example.com/commit-messages"
+  }
+}`
+
+func loadTestCommitLintConfig(t *testing.T, content string) (map[string]*CommitLintRules, error) {
+	path := filepath.Join(t.TempDir(), "commit_lint_config.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return loadCommitLintConfig(path)
+}
+
+func TestLoadCommitLintConfig(t *testing.T) {
+	rules, err := loadTestCommitLintConfig(t, commitLintTestConfig)
+	require.NoError(t, err)
+	require.Contains(t, rules, "cfengine")
+	assert.Equal(t, []string{"feat", "fix", "chore"}, rules["cfengine"].Types)
+	assert.NotNil(t, rules["cfengine"].ticketPattern)
+
+	_, err = loadTestCommitLintConfig(t, `{"cfengine": {"ticket_pattern": "("}}`)
+	assert.ErrorContains(t, err, "ticket pattern of cfengine")
+	_, err = loadTestCommitLintConfig(t, `{"cfengine": null}`)
+	assert.ErrorContains(t, err, "no rules for cfengine")
+
+	// the example configuration is valid
+	_, err = loadCommitLintConfig("commit_lint_config.json")
+	assert.NoError(t, err)
+}
+
+func TestLintCommitMessage(t *testing.T) {
+	rules, err := loadTestCommitLintConfig(t, commitLintTestConfig)
+	require.NoError(t, err)
+	testCases := map[string]struct {
+		message  string
+		problems []string
+	}{
+		"valid": {
+			message:  "fix(auth): refresh the token\n\nChangelog: Title\nTicket: MEN-1234\n",
+			problems: []string{},
+		},
+		"valid without ticket": {
+			message:  "chore: tidy up\n\nChangelog: None\nTicket: None\n",
+			problems: []string{},
+		},
+		"not conventional": {
+			message:  "Refresh the token\n\nChangelog: Title\nTicket: MEN-1234\n",
+			problems: []string{"the title is not a conventional commit header"},
+		},
+		"type not allowed": {
+			message:  "docs: fix a typo\n\nChangelog: None\nTicket: None\n",
+			problems: []string{`the type "docs" is not allowed`},
+		},
+		"missing trailers": {
+			message:  "feat: add a token refresh\n",
+			problems: []string{"missing Changelog trailer", "missing Ticket trailer"},
+		},
+		"empty changelog": {
+			message:  "fix: a crash\n\nChangelog:\nTicket: MEN-1\n",
+			problems: []string{"empty Changelog trailer"},
+		},
+		"malformed ticket": {
+			message:  "fix: a crash\n\nChangelog: Title\nTicket: MEN-1, men_2\n",
+			problems: []string{`the ticket "men_2" is malformed`},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.problems, lintCommitMessage(rules["cfengine"], tc.message))
+		})
+	}
+
+	// without rules, anything goes
+	assert.Empty(t, lintCommitMessage(&CommitLintRules{}, "Update the README\n"))
+}
+
+func TestUpdateCommitLintStatus(t *testing.T) {
+	env := newE2EEnvironment(t)
+	rules, err := loadTestCommitLintConfig(t, commitLintTestConfig)
+	require.NoError(t, err)
+	githubRepo := env.remote.GitHubRepo("cfengine", "core")
+	env.remote.Init(githubRepo)
+	baseSHA := env.remote.Commit(githubRepo, "master", "README.md", "core", "initial commit")
+	env.remote.Git(githubRepo, "update-ref", pullRequestRef(7), baseSHA)
+	validSHA := env.remote.Commit(githubRepo, pullRequestRef(7), "README.md", "fixed",
+		"fix: a bug\n\nChangelog: Fix a crash on startup\nTicket: MEN-1234")
+	invalidSHA := env.remote.Commit(githubRepo, pullRequestRef(7), "main.c", "tidy",
+		"Tidy up\n\nTicket: None")
+	headSHA := env.remote.Commit(githubRepo, pullRequestRef(7), "main.c", "tidier",
+		"chore: tidy up more\n\nChangelog: None\nTicket: None")
+
+	pr := env.pullRequestEvent("synchronize", 7)
+	pr.PullRequest.Head.SHA = github.String(headSHA)
+	pr.PullRequest.Base.SHA = github.String(baseSHA)
+	pr.PullRequest.Base.Repo = &github.Repository{
+		Name:  github.String("core"),
+		Owner: &github.User{Login: github.String("cfengine")},
+	}
+	log := getCustomLoggerFromContext(&gin.Context{})
+	commits, err := getPullRequestCommits(log, pr, env.conf)
+	require.NoError(t, err)
+
+	// the organizations without rules are not linted
+	err = updateCommitLintStatus(&gin.Context{}, log, githubClient, env.conf, pr, commits)
+	require.NoError(t, err)
+	assert.Empty(t, env.github.Statuses("cfengine", "core", headSHA))
+
+	env.conf.commitLintRules = rules
+	err = updateCommitLintStatus(&gin.Context{}, log, githubClient, env.conf, pr, commits)
+	require.NoError(t, err)
+
+	statuses := env.github.Statuses("cfengine", "core", validSHA)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, commitLintStatusContext, statuses[0].GetContext())
+	assert.Equal(t, "success", statuses[0].GetState())
+	assert.Equal(t, "https://example.com/commit-messages", statuses[0].GetTargetURL())
+
+	statuses = env.github.Statuses("cfengine", "core", invalidSHA)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "failure", statuses[0].GetState())
+	assert.Equal(t, "the title is not a conventional commit header, missing Changelog trailer",
+		statuses[0].GetDescription())
+
+	statuses = env.github.Statuses("cfengine", "core", headSHA)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "failure", statuses[0].GetState())
+	assert.Equal(t, "1 of 3 commits have problems: "+invalidSHA[:7]+
+		": the title is not a conventional commit header, missing Changelog trailer",
+		statuses[0].GetDescription())
+}
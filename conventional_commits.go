@@ -1,25 +1,76 @@
 package main
 
 import (
-	"context"
 	"fmt"
+	"os"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/google/go-github/v28/github"
 	"github.com/sirupsen/logrus"
 
+	"github.com/mendersoftware/integration-test-runner/changelog"
 	clientgithub "github.com/mendersoftware/integration-test-runner/client/github"
 	"github.com/mendersoftware/integration-test-runner/git"
 )
 
 const (
-	footer = `Changelog: All
-Ticket: None`
 	commentErrorPrefix = "I did my very best, but:\n"
 )
 
-func conventionalComittifyDependabotPr(
+// conventionalCommitConfig configures the rewrite of the commits of the bot
+// pull requests into conventional commits by the mark-pr as command
+type conventionalCommitConfig struct {
+	// bots are the logins of the authors of the pull requests rewritten
+	bots []string
+	// types are the type keywords the command accepts
+	types []string
+	// trailers are the trailers added to the messages missing them, e.g.
+	// "Ticket: None"
+	trailers []string
+}
+
+// getConventionalCommitConfig returns the configuration of the mark-pr as
+// command, the defaults can be overridden with the CONVENTIONAL_COMMIT_* env
+// variables: comma separated lists of the bots and types, and a semicolon
+// separated list of the trailers
+func getConventionalCommitConfig() conventionalCommitConfig {
+	c := conventionalCommitConfig{
+		bots:     []string{"dependabot[bot]", "renovate[bot]"},
+		types:    []string{"fix", "feat"},
+		trailers: []string{"Changelog: All", "Ticket: None"},
+	}
+	for _, variable := range []struct {
+		name      string
+		separator string
+		value     *[]string
+	}{
+		{"CONVENTIONAL_COMMIT_BOTS", ",", &c.bots},
+		{"CONVENTIONAL_COMMIT_TYPES", ",", &c.types},
+		{"CONVENTIONAL_COMMIT_TRAILERS", ";", &c.trailers},
+	} {
+		raw := os.Getenv(variable.name)
+		if raw == "" {
+			continue
+		}
+		*variable.value = []string{}
+		for _, item := range strings.Split(raw, variable.separator) {
+			if item = strings.TrimSpace(item); item != "" {
+				*variable.value = append(*variable.value, item)
+			}
+		}
+	}
+	return c
+}
+
+// isConventionalCommitBot tells whether the mark-pr as command rewrites the
+// pull requests of the user
+func isConventionalCommitBot(conf *config, login string) bool {
+	return slices.Contains(conf.conventionalCommits.bots, login)
+}
+
+func conventionalComittifyBotPr(
 	log *logrus.Entry,
 	comment *github.IssueCommentEvent,
 	pr *github.PullRequest,
@@ -27,7 +78,7 @@ func conventionalComittifyDependabotPr(
 	body string,
 	githubClient clientgithub.Client,
 ) error {
-	err := attemptConventionalComittifyDependabotPr(log, pr, body)
+	err := attemptConventionalComittifyBotPr(log, pr, conf, body)
 
 	if err == nil {
 		return nil
@@ -35,7 +86,7 @@ func conventionalComittifyDependabotPr(
 
 	commentBody := commentErrorPrefix + err.Error()
 	if err := githubClient.CreateComment(
-		context.Background(),
+		traceContext(log),
 		conf.githubOrganization,
 		comment.GetRepo().GetName(),
 		pr.GetNumber(),
@@ -46,22 +97,52 @@ func conventionalComittifyDependabotPr(
 	return err
 }
 
-func attemptConventionalComittifyDependabotPr(
+// botCommit is a commit of a bot pull request to rewrite
+type botCommit struct {
+	sha     string
+	parents []string
+	message string
+}
+
+// parseBotCommits parses the output of git log in the
+// %H%x1f%P%x1f%B%x00 format
+func parseBotCommits(out []byte) []botCommit {
+	commits := []botCommit{}
+	for _, record := range strings.Split(string(out), "\x00") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x1f", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, botCommit{
+			sha:     fields[0],
+			parents: strings.Fields(fields[1]),
+			message: fields[2],
+		})
+	}
+	return commits
+}
+
+// attemptConventionalComittifyBotPr rewrites the messages of all the commits
+// of the pull request, from the oldest one, by cherry-picking them on the
+// parent of the oldest one; the push fails if the branch moved meanwhile
+func attemptConventionalComittifyBotPr(
 	log *logrus.Entry,
 	pr *github.PullRequest,
+	conf *config,
 	body string,
 ) error {
 
-	typeKeyword, err := getTypeKeyword(body)
+	typeKeyword, err := getTypeKeyword(body, conf.conventionalCommits.types)
 	if err != nil {
 		return err
 	}
 
-	// take message, and conventional committify it
+	// take the messages, and conventional committify them
 	headBranch := pr.GetHead().GetRef()
 	sshCloneUrl := pr.GetHead().GetRepo().GetSSHURL()
-	state, err := git.Commands(
+	state, err := git.CommandsContext(traceContext(log),
 		git.Command("clone", "--branch", headBranch, "--single-branch", sshCloneUrl, "."),
+		git.Command("fetch", pr.GetBase().GetRepo().GetSSHURL(), pr.GetBase().GetRef()),
 	)
 	defer state.Cleanup()
 
@@ -70,49 +151,89 @@ func attemptConventionalComittifyDependabotPr(
 			headBranch, sshCloneUrl, err)
 	}
 
-	messageBytes, err := git.Command("--no-pager", "show", "--no-patch", "--format=%B", "HEAD").
-		With(state).CombinedOutput()
+	out, err := git.Command("log", "--reverse", "--format=%H%x1f%P%x1f%B%x00",
+		"FETCH_HEAD..HEAD").With(state).CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("could not retrieve last commit message with error:\n%w", err)
+		return fmt.Errorf("could not retrieve the commit messages with error:\n%w", err)
+	}
+	commits := parseBotCommits(out)
+	if len(commits) == 0 {
+		return fmt.Errorf("could not find the commits of the pull request")
 	}
 
-	message := string(messageBytes)
-
-	newMessage := conventionalComittifyDependabotMessage(message, typeKeyword)
-
-	if err := git.CommandsWithState(
-		state,
-		git.Command("commit", "--amend", "-m", newMessage),
-		git.Command("push", "--force"),
-	); err != nil {
-		return fmt.Errorf("could not amend and push with error:\n%w", err)
+	cmds := []*git.Cmd{}
+	for i, commit := range commits {
+		if len(commit.parents) != 1 {
+			return fmt.Errorf("could not rewrite the merge commit %s", commit.sha)
+		}
+		if i == 0 {
+			cmds = append(cmds, git.Command("reset", "--hard", commit.parents[0]))
+		}
+		newMessage := conventionalComittifyMessage(commit.message, typeKeyword,
+			conf.conventionalCommits.trailers)
+		cmds = append(cmds,
+			git.Command("cherry-pick", commit.sha),
+			git.Command("commit", "--amend", "-m", newMessage),
+		)
+	}
+	cmds = append(cmds, git.Command("push", "--force-with-lease"))
+	if err := git.CommandsWithState(state, cmds...); err != nil {
+		return fmt.Errorf("could not rewrite and push with error:\n%w", err)
 	}
 
 	return nil
 }
 
-func conventionalComittifyDependabotMessage(message string, typeKeyword string) string {
+// conventionalComittifyMessage makes the title of the message a
+// conventional commit header of the type, keeping its scope, and adds the
+// trailers it is missing before the sign-off
+func conventionalComittifyMessage(message string, typeKeyword string, trailers []string) string {
 	message = strings.TrimSpace(message)
 	message = strings.TrimPrefix(message, "Changelog:All: ")
-	message = strings.TrimPrefix(message, "chore: ")
-	message = typeKeyword + ": " + message
+	title, rest, _ := strings.Cut(message, "\n")
+	if header := changelog.ParseHeader(title); header != nil {
+		title = typeKeyword
+		if header.Scope != "" {
+			title += "(" + header.Scope + ")"
+		}
+		if header.Breaking {
+			title += "!"
+		}
+		title += ": " + header.Description
+	} else {
+		title = typeKeyword + ": " + title
+	}
+	message = title
+	if rest != "" {
+		message += "\n" + rest
+	}
 
+	footer := ""
+	for _, trailer := range trailers {
+		key, _, _ := strings.Cut(trailer, ":")
+		pattern := regexp.MustCompile(`(?im)^` + regexp.QuoteMeta(strings.TrimSpace(key)) + `\s*:`)
+		if !pattern.MatchString(rest) {
+			footer += trailer + "\n"
+		}
+	}
+	if footer == "" {
+		return message
+	}
 	fi := strings.Index(message, "Signed-off-by")
 	if fi < 0 {
-		fi = len(message)
+		return message + "\n\n" + strings.TrimSpace(footer)
 	}
-	message = message[:fi] + footer + "\n" + message[fi:]
+	message = message[:fi] + footer + message[fi:]
 	return strings.TrimSpace(message)
 }
 
-func getTypeKeyword(s string) (string, error) {
-	r := regexp.MustCompile(commandConventionalCommit +
-		`(?::\s*|\s+)(fix|feat|([[:word:]]+))\s*$`)
+func getTypeKeyword(s string, types []string) (string, error) {
+	r := regexp.MustCompile(commandConventionalCommit + `(?::\s*|\s+)([[:word:]]+)\s*$`)
 	matches := r.FindStringSubmatch(s)
 	if matches == nil {
 		return "", fmt.Errorf("could not parse the body for some reason")
-	} else if matches[2] != "" {
-		return "", fmt.Errorf("type keyword %s not allowed", matches[2])
+	} else if !slices.Contains(types, matches[1]) {
+		return "", fmt.Errorf("type keyword %s not allowed", matches[1])
 	}
 	return matches[1], nil
 }
@@ -6,6 +6,8 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+var defaultConventionalCommitTrailers = []string{"Changelog: All", "Ticket: None"}
+
 func TestGetTypeKeyword(t *testing.T) {
 	tests := map[string]struct {
 		body     string
@@ -45,17 +47,23 @@ mark pr as feat`,
 	}
 	for name, test := range tests {
 		t.Log(name)
-		res, _ := getTypeKeyword(test.body)
+		res, _ := getTypeKeyword(test.body, []string{"fix", "feat"})
 		assert.Equal(t, test.expected, res)
 	}
 	for name := range testErrors {
 		t.Log(name)
-		_, err := getTypeKeyword(testErrors[name])
+		_, err := getTypeKeyword(testErrors[name], []string{"fix", "feat"})
 		assert.True(t, err != nil)
 	}
+
+	res, err := getTypeKeyword("mark-pr as chore", []string{"chore"})
+	assert.NoError(t, err)
+	assert.Equal(t, "chore", res)
+	_, err = getTypeKeyword("mark-pr as feat", []string{"chore"})
+	assert.EqualError(t, err, "type keyword feat not allowed")
 }
 
-func TestConventionalComittifyDependabotMessage(t *testing.T) {
+func TestConventionalComittifyMessage(t *testing.T) {
 	tests := map[string]struct {
 		typeKeyword string
 		body        string
@@ -107,10 +115,67 @@ Changelog: All
 Ticket: None
 Signed-off-by: dependabot[bot]`,
 		},
+		"renovate with scope and existing trailer": {
+			"fix",
+			`chore(deps): update module github.com/stretchr/testify to v1.9.0
+
+Ticket: MEN-1234
+Signed-off-by: renovate[bot]`,
+			`fix(deps): update module github.com/stretchr/testify to v1.9.0
+
+Ticket: MEN-1234
+Changelog: All
+Signed-off-by: renovate[bot]`,
+		},
+		"without sign-off": {
+			"feat",
+			"Update the dependencies",
+			`feat: Update the dependencies
+
+Changelog: All
+Ticket: None`,
+		},
+		"with all the trailers": {
+			"fix",
+			`chore: update the dependencies
+
+Changelog: None
+Ticket: None`,
+			`fix: update the dependencies
+
+Changelog: None
+Ticket: None`,
+		},
 	}
 	for name, test := range tests {
 		t.Log(name)
-		res := conventionalComittifyDependabotMessage(test.body, test.typeKeyword)
+		res := conventionalComittifyMessage(test.body, test.typeKeyword,
+			defaultConventionalCommitTrailers)
 		assert.Equal(t, test.expected, res)
 	}
 }
+
+func TestParseBotCommits(t *testing.T) {
+	out := "1111111\x1f0000000\x1fchore: first\n\n\x00\n" +
+		"2222222\x1f1111111 3333333\x1fMerge branch\n\x00\n"
+	assert.Equal(t, []botCommit{
+		{sha: "1111111", parents: []string{"0000000"}, message: "chore: first\n\n"},
+		{sha: "2222222", parents: []string{"1111111", "3333333"}, message: "Merge branch\n"},
+	}, parseBotCommits([]byte(out)))
+	assert.Empty(t, parseBotCommits(nil))
+}
+
+func TestGetConventionalCommitConfig(t *testing.T) {
+	c := getConventionalCommitConfig()
+	assert.Equal(t, []string{"dependabot[bot]", "renovate[bot]"}, c.bots)
+	assert.Equal(t, []string{"fix", "feat"}, c.types)
+	assert.Equal(t, defaultConventionalCommitTrailers, c.trailers)
+
+	t.Setenv("CONVENTIONAL_COMMIT_BOTS", "renovate[bot], mender-bot")
+	t.Setenv("CONVENTIONAL_COMMIT_TYPES", "fix,feat,chore")
+	t.Setenv("CONVENTIONAL_COMMIT_TRAILERS", "Changelog: None; Ticket: None")
+	c = getConventionalCommitConfig()
+	assert.Equal(t, []string{"renovate[bot]", "mender-bot"}, c.bots)
+	assert.Equal(t, []string{"fix", "feat", "chore"}, c.types)
+	assert.Equal(t, []string{"Changelog: None", "Ticket: None"}, c.trailers)
+}
@@ -0,0 +1,118 @@
+package git
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Result is the outcome of a git command as produced by an Executor
+type Result struct {
+	Stdout   []byte
+	Stderr   []byte
+	ExitCode int
+}
+
+// Executor produces the output of git commands in dry-run mode
+type Executor interface {
+	Execute(dir string, args []string) Result
+}
+
+// ExitError is returned by a dry-run command exiting with a non-zero code
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// Rule maps git arguments matching a pattern to a canned result
+type Rule struct {
+	// Args is a regular expression matched against the space separated
+	// git arguments, e.g. "^log --pretty=format:%s "
+	Args     string `json:"args"`
+	Stdout   string `json:"stdout,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+	ExitCode int    `json:"exit_code,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// FakeExecutor is an Executor answering git commands from a list of rules;
+// the first matching rule wins, commands matching no rule succeed silently
+type FakeExecutor struct {
+	mutex sync.Mutex
+	rules []Rule
+}
+
+// NewFakeExecutor returns a new FakeExecutor for the given rules
+func NewFakeExecutor(rules []Rule) (*FakeExecutor, error) {
+	f := &FakeExecutor{}
+	if err := f.SetRules(rules); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// LoadFakeExecutor returns a new FakeExecutor for the rules in a JSON file
+func LoadFakeExecutor(path string) (*FakeExecutor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := ParseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load git rules from %s: %w", path, err)
+	}
+	return NewFakeExecutor(rules)
+}
+
+// ParseRules parses a JSON list of rules
+func ParseRules(data []byte) ([]Rule, error) {
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// SetRules replaces the rules of the executor
+func (f *FakeExecutor) SetRules(rules []Rule) error {
+	compiled := make([]Rule, len(rules))
+	for i, rule := range rules {
+		re, err := regexp.Compile(rule.Args)
+		if err != nil {
+			return fmt.Errorf("invalid git rule %q: %w", rule.Args, err)
+		}
+		compiled[i] = rule
+		compiled[i].re = re
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.rules = compiled
+	return nil
+}
+
+// Execute returns the result of the first rule matching the arguments
+func (f *FakeExecutor) Execute(dir string, args []string) Result {
+	line := strings.Join(args, " ")
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for _, rule := range f.rules {
+		if rule.re.MatchString(line) {
+			res := Result{ExitCode: rule.ExitCode}
+			if rule.Stdout != "" {
+				res.Stdout = []byte(rule.Stdout)
+			}
+			if rule.Stderr != "" {
+				res.Stderr = []byte(rule.Stderr)
+			}
+			return res
+		}
+	}
+	return Result{}
+}
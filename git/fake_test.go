@@ -0,0 +1,113 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/integration-test-runner/logger"
+)
+
+func TestFakeExecutor(t *testing.T) {
+	executor, err := NewFakeExecutor([]Rule{
+		{
+			Args:   "^--no-pager show --no-patch --format=%B HEAD$",
+			Stdout: "chore: bump the version\n",
+		},
+		{
+			Args:     "^cherry-pick ",
+			Stderr:   "error: could not apply 1234abc... fix: crash\n",
+			ExitCode: 1,
+		},
+		{
+			Args:   "^log ",
+			Stdout: "first match wins",
+		},
+		{
+			Args:   "^log --pretty",
+			Stdout: "never reached",
+		},
+	})
+	assert.NoError(t, err)
+
+	testCases := map[string]struct {
+		args     []string
+		expected Result
+	}{
+		"stdout": {
+			args:     []string{"--no-pager", "show", "--no-patch", "--format=%B", "HEAD"},
+			expected: Result{Stdout: []byte("chore: bump the version\n")},
+		},
+		"stderr and exit code": {
+			args: []string{"cherry-pick", "-x", "abc"},
+			expected: Result{
+				Stderr:   []byte("error: could not apply 1234abc... fix: crash\n"),
+				ExitCode: 1,
+			},
+		},
+		"first match wins": {
+			args:     []string{"log", "--pretty=format:%s", "a..b"},
+			expected: Result{Stdout: []byte("first match wins")},
+		},
+		"no match": {
+			args:     []string{"push", "origin"},
+			expected: Result{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, executor.Execute("", tc.args))
+		})
+	}
+}
+
+func TestFakeExecutorInvalidRule(t *testing.T) {
+	_, err := NewFakeExecutor([]Rule{{Args: "("}})
+	assert.Error(t, err)
+}
+
+func TestLoadFakeExecutor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	err := os.WriteFile(path, []byte(`[{"args": "^log", "stdout": "abc", "exit_code": 2}]`), 0644)
+	assert.NoError(t, err)
+
+	executor, err := LoadFakeExecutor(path)
+	assert.NoError(t, err)
+	assert.Equal(t, Result{Stdout: []byte("abc"), ExitCode: 2},
+		executor.Execute("", []string{"log"}))
+
+	_, err = LoadFakeExecutor(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestRunDryRunExecutor(t *testing.T) {
+	requestLogger := logger.NewRequestLogger()
+	logger.SetRequestLogger(requestLogger)
+	SetDryRunMode(true)
+	defer SetDryRunMode(false)
+	executor, _ := NewFakeExecutor([]Rule{
+		{Args: "^status$", Stdout: "clean"},
+		{Args: "^push", Stderr: "rejected", ExitCode: 1},
+	})
+	SetDryRunExecutor(executor)
+	defer SetDryRunExecutor(nil)
+
+	out, err := Command("status").CombinedOutput()
+	assert.NoError(t, err)
+	assert.Equal(t, "clean", string(out))
+
+	out, err = Command("push").CombinedOutput()
+	assert.EqualError(t, err, "exit status 1")
+	assert.Equal(t, "rejected", string(out))
+
+	err = Command("push").Run()
+	assert.Contains(t, err.Error(), "rejected")
+
+	entries := requestLogger.Entries(logger.Filter{Kind: logger.KindGit})
+	assert.Len(t, entries, 3)
+	assert.Equal(t, "Run", entries[0].Operation)
+	assert.Equal(t, logger.Args{"dir": "", "args": []string{"status"}}, entries[0].Args)
+}
@@ -1,17 +1,25 @@
 package git
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/mendersoftware/integration-test-runner/logger"
+	"github.com/mendersoftware/integration-test-runner/metrics"
+	"github.com/mendersoftware/integration-test-runner/tracing"
 )
 
 var dryRunMode bool
 
+var dryRunExecutor Executor
+
 func init() {
 	dryRunMode = false
 }
@@ -21,11 +29,18 @@ func SetDryRunMode(value bool) {
 	dryRunMode = value
 }
 
+// SetDryRunExecutor sets the executor producing the output of the git
+// commands in dry run mode; with no executor, commands return no output
+func SetDryRunExecutor(e Executor) {
+	dryRunExecutor = e
+}
+
 // Cmd is a git command
 type Cmd struct {
 	Dir     string
 	Args    []string
 	Process *cmdProcess
+	ctx     context.Context
 	cmd     *exec.Cmd
 	out     []byte
 	err     error
@@ -40,12 +55,24 @@ func (p *cmdProcess) Kill() error {
 // With sets the git command state
 func (g *Cmd) With(s *State) *Cmd {
 	g.Dir = s.Dir
+	if s.Context != nil {
+		g.ctx = s.Context
+	}
+	return g
+}
+
+// WithContext sets the context carrying the span the command is traced under
+func (g *Cmd) WithContext(ctx context.Context) *Cmd {
+	g.ctx = ctx
 	return g
 }
 
 // State holds the git command state
 type State struct {
 	Dir string
+	// Context carries the span the commands run with the state are
+	// traced under
+	Context context.Context
 }
 
 // Cleanup cleans up the statee
@@ -57,18 +84,24 @@ func (s *State) Cleanup() {
 
 // Commands runs multiple git commands
 func Commands(cmds ...*Cmd) (*State, error) {
+	return CommandsContext(context.Background(), cmds...)
+}
+
+// CommandsContext runs multiple git commands traced under the span carried
+// by ctx; the returned state keeps the context for the following commands
+func CommandsContext(ctx context.Context, cmds ...*Cmd) (*State, error) {
 	tdir, err := os.MkdirTemp("", "gitcmd")
 	if err != nil {
-		return &State{}, err
+		return &State{Context: ctx}, err
 	}
-	s := &State{Dir: tdir}
+	s := &State{Dir: tdir, Context: ctx}
 	err = CommandsWithState(s, cmds...)
 	return s, err
 }
 
 func CommandsWithState(s *State, cmds ...*Cmd) error {
 	for _, cmd := range cmds {
-		cmd.Dir = s.Dir
+		cmd.With(s)
 		out, err := cmd.CombinedOutput()
 		if err != nil {
 			return errors.Wrapf(err,
@@ -92,14 +125,33 @@ func Command(args ...string) *Cmd {
 func (g *Cmd) Run() error {
 	if dryRunMode {
 		msg := fmt.Sprintf("git.Run: %s", g.cmd)
-		logger.GetRequestLogger().Push(msg)
+		logger.GetRequestLogger().PushEntry(logger.Entry{
+			Kind:      logger.KindGit,
+			Operation: "Run",
+			Args:      logger.Args{"dir": g.Dir, "args": g.Args},
+			Message:   msg,
+		})
 		g.out = []byte{}
+		if dryRunExecutor == nil {
+			return nil
+		}
+		res := dryRunExecutor.Execute(g.Dir, g.Args)
+		g.out = append(append(g.out, res.Stdout...), res.Stderr...)
+		if res.ExitCode != 0 {
+			g.err = &ExitError{Code: res.ExitCode}
+			return fmt.Errorf("%v returned error: %s: %s", g.cmd.Args, g.out, g.err.Error())
+		}
 		return nil
 	}
 	if g.Dir != "" {
 		g.cmd.Dir = g.Dir
 	}
+	_, span := tracing.Start(g.ctx, "git "+g.operation(),
+		attribute.String("git.operation", g.operation()))
+	start := time.Now()
 	out, err := g.cmd.CombinedOutput()
+	metrics.ObserveGitOperation(g.operation(), start, err)
+	tracing.End(span, err)
 	g.out = out
 	g.err = err
 	if err != nil {
@@ -108,6 +160,16 @@ func (g *Cmd) Run() error {
 	return nil
 }
 
+// operation returns the git subcommand, e.g. "fetch"
+func (g *Cmd) operation() string {
+	for _, arg := range g.Args {
+		if !strings.HasPrefix(arg, "-") {
+			return arg
+		}
+	}
+	return ""
+}
+
 func (g *Cmd) CombinedOutput() ([]byte, error) {
 	_ = g.Run()
 	return g.out, g.err
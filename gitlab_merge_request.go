@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v28/github"
+	"github.com/sirupsen/logrus"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"go.opentelemetry.io/otel/attribute"
+
+	clientgithub "github.com/mendersoftware/integration-test-runner/client/github"
+	clientgitlab "github.com/mendersoftware/integration-test-runner/client/gitlab"
+	"github.com/mendersoftware/integration-test-runner/tracing"
+)
+
+// mergeRequestMirrorPrefix starts the last line of the description of the
+// merge requests, linking them to the pull request they mirror
+const mergeRequestMirrorPrefix = "Mirror of "
+
+var regexMergeRequestMirror = regexp.MustCompile(
+	`(?m)^` + mergeRequestMirrorPrefix +
+		`https://github\.com/([\w.-]+)/([\w.-]+)/pull/(\d+)\s*$`,
+)
+
+// getMergeRequestDescription returns the description of the merge request
+// mirroring the pull request
+func getMergeRequestDescription(org string, event *github.PullRequestEvent) string {
+	link := fmt.Sprintf("%shttps://github.com/%s/%s/pull/%d",
+		mergeRequestMirrorPrefix, org, event.GetRepo().GetName(), event.GetNumber())
+	body := strings.TrimSpace(event.GetPullRequest().GetBody())
+	if body == "" {
+		return link
+	}
+	return body + "\n\n---\n\n" + link
+}
+
+// parseMergeRequestMirror returns the pull request a merge request mirrors,
+// from the link in its description
+func parseMergeRequestMirror(description string) (org, repo string, number int, ok bool) {
+	matches := regexMergeRequestMirror.FindAllStringSubmatch(description, -1)
+	if len(matches) == 0 {
+		return "", "", 0, false
+	}
+	// the link is appended after the body of the pull request
+	match := matches[len(matches)-1]
+	number, err := strconv.Atoi(match[3])
+	if err != nil {
+		return "", "", 0, false
+	}
+	return match[1], match[2], number, true
+}
+
+// findOpenMergeRequest returns the open merge request from the branch, or
+// nil if there is none
+func findOpenMergeRequest(
+	client clientgitlab.Client,
+	path string,
+	branch string,
+) (*gitlab.BasicMergeRequest, error) {
+	mergeRequests, err := client.ListProjectMergeRequests(path,
+		&gitlab.ListProjectMergeRequestsOptions{
+			State:        gitlab.Ptr("opened"),
+			SourceBranch: gitlab.Ptr(branch),
+		})
+	if err != nil || len(mergeRequests) == 0 {
+		return nil, err
+	}
+	return mergeRequests[0], nil
+}
+
+// getUpdateMergeRequestOptions returns the fields of the merge request
+// mirrored from the pull request
+func getUpdateMergeRequestOptions(
+	event *github.PullRequestEvent,
+	conf *config,
+) *gitlab.UpdateMergeRequestOptions {
+	pr := event.GetPullRequest()
+	labels := gitlab.LabelOptions{}
+	for _, label := range pr.Labels {
+		labels = append(labels, label.GetName())
+	}
+	return &gitlab.UpdateMergeRequestOptions{
+		Title:        gitlab.Ptr(strings.TrimSpace(pr.GetTitle())),
+		Description:  gitlab.Ptr(getMergeRequestDescription(conf.githubOrganization, event)),
+		TargetBranch: gitlab.Ptr(pr.GetBase().GetRef()),
+		Labels:       &labels,
+	}
+}
+
+// syncMergeRequest opens the merge request from the pr_ branch into the base
+// branch in GitLab, or updates the existing one, mirroring the title, the
+// description and the labels of the pull request; it returns its IID
+func syncMergeRequest(
+	log *logrus.Entry,
+	ref string,
+	event *github.PullRequestEvent,
+	conf *config,
+) (int64, error) {
+	client, err := newGitLabClient(traceContext(log), conf)
+	if err != nil {
+		return 0, err
+	}
+	path, err := getGitLabProjectPath(conf.githubOrganization, event.GetRepo().GetName())
+	if err != nil {
+		return 0, err
+	}
+	existing, err := findOpenMergeRequest(client, path, ref)
+	if err != nil {
+		return 0, err
+	}
+	options := getUpdateMergeRequestOptions(event, conf)
+	if existing != nil {
+		if _, err := client.UpdateMergeRequest(path, existing.IID, options); err != nil {
+			return 0, err
+		}
+		log.Debugf("updated merge request %s!%d", path, existing.IID)
+		return existing.IID, nil
+	}
+
+	mergeRequest, err := client.CreateMergeRequest(path, &gitlab.CreateMergeRequestOptions{
+		Title:        options.Title,
+		Description:  options.Description,
+		SourceBranch: &ref,
+		TargetBranch: options.TargetBranch,
+		Labels:       options.Labels,
+	})
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Created merge request: %s!%d", path, mergeRequest.IID)
+	return mergeRequest.IID, nil
+}
+
+// updateMergeRequest mirrors the edited title, description or labels of the
+// pull request to its merge request, if already opened
+func updateMergeRequest(log *logrus.Entry, event *github.PullRequestEvent, conf *config) error {
+	client, err := newGitLabClient(traceContext(log), conf)
+	if err != nil {
+		return err
+	}
+	path, err := getGitLabProjectPath(conf.githubOrganization, event.GetRepo().GetName())
+	if err != nil {
+		return err
+	}
+	existing, err := findOpenMergeRequest(client, path, fmt.Sprintf("pr_%d", event.GetNumber()))
+	if err != nil || existing == nil {
+		return err
+	}
+	_, err = client.UpdateMergeRequest(path, existing.IID,
+		getUpdateMergeRequestOptions(event, conf))
+	if err == nil {
+		log.Debugf("updated merge request %s!%d", path, existing.IID)
+	}
+	return err
+}
+
+// closeMergeRequest closes the merge request mirroring a closed pull request
+func closeMergeRequest(log *logrus.Entry, event *github.PullRequestEvent, conf *config) error {
+	client, err := newGitLabClient(traceContext(log), conf)
+	if err != nil {
+		return err
+	}
+	path, err := getGitLabProjectPath(conf.githubOrganization, event.GetRepo().GetName())
+	if err != nil {
+		return err
+	}
+	existing, err := findOpenMergeRequest(client, path, fmt.Sprintf("pr_%d", event.GetNumber()))
+	if err != nil || existing == nil {
+		return err
+	}
+	_, err = client.UpdateMergeRequest(path, existing.IID, &gitlab.UpdateMergeRequestOptions{
+		StateEvent: gitlab.Ptr("close"),
+	})
+	if err == nil {
+		log.Infof("Closed merge request: %s!%d", path, existing.IID)
+	}
+	return err
+}
+
+func processGitLabWebhookRequest(
+	ctx *gin.Context,
+	eventType gitlab.EventType,
+	payload []byte,
+	githubClient clientgithub.Client,
+	conf *config,
+) {
+	log := getCustomLoggerFromContext(ctx)
+	event, err := gitlab.ParseWebhook(eventType, payload)
+	if err != nil {
+		log.Debugf("ignoring GitLab event %q: %s", eventType, err.Error())
+		return
+	}
+	switch event := event.(type) {
+	case *gitlab.MergeCommentEvent:
+		_ = processGitLabMergeRequestComment(ctx, event, githubClient, conf)
+	case *gitlab.PipelineEvent:
+		_ = processGitLabPipelineEvent(ctx, event, githubClient, conf)
+	default:
+		log.Debugf("ignoring GitLab event %q", eventType)
+	}
+}
+
+// processGitLabMergeRequestComment cross-posts the comments on a merge
+// request opened by the runner to the pull request it mirrors
+func processGitLabMergeRequestComment(
+	ctx *gin.Context,
+	comment *gitlab.MergeCommentEvent,
+	githubClient clientgithub.Client,
+	conf *config,
+) error {
+	spanCtx, span := tracing.Start(context.Background(), "webhook gitlab note",
+		attribute.String("gitlab.project", comment.Project.PathWithNamespace),
+		attribute.Int64("gitlab.merge_request", comment.MergeRequest.IID),
+	)
+	ctx.Set(tracing.ContextKey, spanCtx)
+	err := crossPostMergeRequestComment(ctx, comment, githubClient, conf)
+	tracing.End(span, err)
+	return err
+}
+
+func crossPostMergeRequestComment(
+	ctx *gin.Context,
+	comment *gitlab.MergeCommentEvent,
+	githubClient clientgithub.Client,
+	conf *config,
+) error {
+	log := getCustomLoggerFromContext(ctx).
+		WithField("merge_request", comment.MergeRequest.IID)
+	note := comment.ObjectAttributes
+	if note.System || (note.Action != "" && note.Action != "create") {
+		log.Debugf("ignoring merge request note %d", note.ID)
+		return nil
+	}
+
+	// only the merge requests of the pr_ branches mirror pull requests
+	org, repo, number, ok := parseMergeRequestMirror(comment.MergeRequest.Description)
+	if !ok || comment.MergeRequest.SourceBranch != fmt.Sprintf("pr_%d", number) {
+		log.Debugf("ignoring comment on merge request %s!%d, not mirroring a pull request",
+			comment.Project.PathWithNamespace, comment.MergeRequest.IID)
+		return nil
+	}
+	path, err := getGitLabProjectPath(org, repo)
+	if err != nil || path != comment.Project.PathWithNamespace {
+		log.Warnf("merge request %s!%d mirrors %s/%s#%d of another project, ignoring",
+			comment.Project.PathWithNamespace, comment.MergeRequest.IID, org, repo, number)
+		return nil
+	}
+
+	author := "someone"
+	if comment.User != nil && comment.User.Username != "" {
+		author = comment.User.Username
+	}
+	body := fmt.Sprintf("**%s** commented on the GitLab merge request [!%d](%s):\n\n%s",
+		author, comment.MergeRequest.IID, note.URL, quoteMarkdown(note.Note))
+	if err := githubClient.CreateComment(
+		ctx,
+		org,
+		repo,
+		number,
+		&github.IssueComment{Body: github.String(body)},
+	); err != nil {
+		log.Errorf("Failed to cross-post the merge request comment to %s/%s#%d: %s",
+			org, repo, number, err.Error())
+		return err
+	}
+	return nil
+}
+
+// quoteMarkdown quotes every line of the text
+func quoteMarkdown(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("> "+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
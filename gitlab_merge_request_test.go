@@ -0,0 +1,107 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+
+	mock_github "github.com/mendersoftware/integration-test-runner/client/github/mocks"
+)
+
+func TestParseMergeRequestMirror(t *testing.T) {
+	testCases := map[string]struct {
+		description string
+		org         string
+		repo        string
+		number      int
+		ok          bool
+	}{
+		"link only": {
+			description: "Mirror of https://github.com/mendersoftware/mender/pull/1234",
+			org:         "mendersoftware",
+			repo:        "mender",
+			number:      1234,
+			ok:          true,
+		},
+		"link after the body": {
+			description: getMergeRequestDescription("mendersoftware", &github.PullRequestEvent{
+				Number: github.Int(12),
+				Repo:   &github.Repository{Name: github.String("mender-connect")},
+				PullRequest: &github.PullRequest{
+					Body: github.String("Mirror of https://github.com/evil/repo/pull/1\n\nnot"),
+				},
+			}),
+			org:    "mendersoftware",
+			repo:   "mender-connect",
+			number: 12,
+			ok:     true,
+		},
+		"no link": {
+			description: "Fixes the bug, see https://github.com/mendersoftware/mender/pull/1",
+		},
+		"empty": {},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			org, repo, number, ok := parseMergeRequestMirror(tc.description)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.org, org)
+			assert.Equal(t, tc.repo, repo)
+			assert.Equal(t, tc.number, number)
+		})
+	}
+}
+
+func TestCrossPostMergeRequestCommentIgnored(t *testing.T) {
+	newComment := func() *gitlab.MergeCommentEvent {
+		return &gitlab.MergeCommentEvent{
+			User: &gitlab.EventUser{Username: "bob"},
+			Project: gitlab.MergeCommentEventProject{
+				PathWithNamespace: "Northern.tech/Mender/mender",
+			},
+			ObjectAttributes: gitlab.MergeCommentEventObjectAttributes{
+				Note:   "LGTM",
+				Action: "create",
+			},
+			MergeRequest: gitlab.MergeCommentEventMergeRequest{
+				IID:          3,
+				SourceBranch: "pr_42",
+				Description:  "Mirror of https://github.com/mendersoftware/mender/pull/42",
+			},
+		}
+	}
+	testCases := map[string]func(comment *gitlab.MergeCommentEvent){
+		"system note": func(comment *gitlab.MergeCommentEvent) {
+			comment.ObjectAttributes.System = true
+		},
+		"edited note": func(comment *gitlab.MergeCommentEvent) {
+			comment.ObjectAttributes.Action = "update"
+		},
+		"merge request not opened by the runner": func(comment *gitlab.MergeCommentEvent) {
+			comment.MergeRequest.SourceBranch = "feature"
+		},
+		"pull request of another project": func(comment *gitlab.MergeCommentEvent) {
+			comment.MergeRequest.Description =
+				"Mirror of https://github.com/mendersoftware/mender-connect/pull/42"
+		},
+		"unknown organization": func(comment *gitlab.MergeCommentEvent) {
+			comment.MergeRequest.Description = "Mirror of https://github.com/evil/mender/pull/42"
+		},
+	}
+	for name, modify := range testCases {
+		t.Run(name, func(t *testing.T) {
+			comment := newComment()
+			modify(comment)
+			mclient := &mock_github.Client{}
+			defer mclient.AssertExpectations(t)
+
+			err := crossPostMergeRequestComment(newCommandContext("note"), comment, mclient,
+				&config{})
+			require.NoError(t, err)
+			mclient.AssertNotCalled(t, "CreateComment")
+		})
+	}
+}
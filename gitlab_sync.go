@@ -8,6 +8,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/mendersoftware/integration-test-runner/git"
+	"github.com/mendersoftware/integration-test-runner/metrics"
 )
 
 type branchDepth int
@@ -49,7 +50,7 @@ func syncRemoteRef(log *logrus.Entry, org, repo, ref string, conf *config) error
 		return fmt.Errorf("getRemoteURLGitLab returned error: %s", err.Error())
 	}
 
-	state, err := git.Commands(
+	state, err := git.CommandsContext(traceContext(log),
 		git.Command("init", "."),
 		git.Command("remote", "add", "github",
 			getRemoteURLGitHub(conf.githubProtocol, conf.githubOrganization, repo)),
@@ -65,6 +66,7 @@ func syncRemoteRef(log *logrus.Entry, org, repo, ref string, conf *config) error
 
 		log.Infof("Fetching branch at depth: %d", depth)
 		err := fetchCmd(ref, repo, state, depth)
+		metrics.ObserveSyncAttempt(int(depth), err)
 		if err == nil {
 			break
 		}
@@ -0,0 +1,90 @@
+#!/usr/bin/env python3
+"""Records the outputs of release_tool.py on the integration fixture.
+
+Builds a clone of the integration repository with a remote branch per
+directory of testdata/integration, runs the release_tool.py of an integration
+checkout on it for each call of release_tool.json, and writes their outputs
+back to release_tool.json:
+
+    ./record_release_tool.py /path/to/integration/extra/release_tool.py
+
+The calls failing keep the "error" of the reader they expect, which is to be
+set by hand for new failing calls.
+"""
+
+import json
+import os
+import shutil
+import subprocess
+import sys
+import tempfile
+
+TESTDATA = os.path.dirname(os.path.abspath(__file__))
+FIXTURE = os.path.join(TESTDATA, "integration")
+CALLS = os.path.join(TESTDATA, "release_tool.json")
+# release_tool.py finds the upstream remote by its URL
+UPSTREAM_URL = "https://github.com/mendersoftware/integration.git"
+
+
+def git(cwd, *args):
+    return subprocess.run(
+        ["git"] + list(args), cwd=cwd, check=True, capture_output=True, text=True
+    ).stdout
+
+
+def build_clone(tmp):
+    bare = os.path.join(tmp, "integration.git")
+    git(tmp, "init", "--quiet", "--bare", bare)
+    for branch in sorted(os.listdir(FIXTURE)):
+        work = os.path.join(tmp, "work-" + branch)
+        shutil.copytree(os.path.join(FIXTURE, branch), work)
+        git(work, "init", "--quiet")
+        git(work, "add", ".")
+        git(work, "commit", "--quiet", "-m", "integration " + branch)
+        git(work, "push", "--quiet", bare, "HEAD:refs/heads/" + branch)
+    clone = os.path.join(tmp, "integration")
+    git(tmp, "clone", "--quiet", "--branch", "master", bare, clone)
+    git(clone, "remote", "set-url", "origin", UPSTREAM_URL)
+    return clone
+
+
+def main():
+    if len(sys.argv) != 2:
+        sys.exit("usage: %s /path/to/release_tool.py" % sys.argv[0])
+    os.environ.update(
+        GIT_AUTHOR_NAME="Test Author",
+        GIT_AUTHOR_EMAIL="author@example.com",
+        GIT_COMMITTER_NAME="Test Committer",
+        GIT_COMMITTER_EMAIL="committer@example.com",
+    )
+    with open(CALLS) as f:
+        calls = json.load(f)
+    with tempfile.TemporaryDirectory() as tmp:
+        clone = build_clone(tmp)
+        # release_tool.py reads the component maps of its own checkout
+        tool = os.path.join(clone, "extra", "release_tool.py")
+        shutil.copy(sys.argv[1], tool)
+        for call in calls:
+            res = subprocess.run(
+                [sys.executable, tool] + call["args"],
+                cwd=clone,
+                capture_output=True,
+                text=True,
+            )
+            if res.returncode == 0:
+                call.pop("error", None)
+                call["stdout"] = res.stdout
+            elif "error" in call:
+                call.pop("stdout", None)
+            else:
+                sys.exit(
+                    "release_tool.py %s failed, set the error the reader is "
+                    "expected to return:\n%s" % (" ".join(call["args"]), res.stderr)
+                )
+    with open(CALLS, "w") as f:
+        json.dump(calls, f, indent=2)
+        f.write("\n")
+
+
+if __name__ == "__main__":
+    main()
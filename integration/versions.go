@@ -0,0 +1,304 @@
+// Package integration reads the versions of the Mender components from a
+// clone of the integration repository, in place of its release_tool.py
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"gopkg.in/yaml.v3"
+
+	"github.com/mendersoftware/integration-test-runner/tracing"
+)
+
+const (
+	// ComponentMapsPath is the file mapping the git repositories to the
+	// docker images built from them
+	ComponentMapsPath = "extra/component-maps.yml"
+
+	// FeatureBranchPrefix is the prefix of the integration feature branches
+	FeatureBranchPrefix = "feature-"
+)
+
+var (
+	// ErrUnknownRef is returned when the integration version does not
+	// exist in the clone
+	ErrUnknownRef = errors.New("unknown integration version")
+	// ErrUnknownComponent is returned when the repository is not a
+	// component of the integration version
+	ErrUnknownComponent = errors.New("unknown component")
+	// ErrNoVersion is returned when none of the version files of the
+	// integration version pins the component
+	ErrNoVersion = errors.New("no version of the component")
+	// ErrMalformed is returned when a version file can not be parsed
+	ErrMalformed = errors.New("malformed version file")
+)
+
+// Error is the error of a query, wrapping one of the Err* errors
+type Error struct {
+	Op        string
+	Ref       string
+	Component string
+	Err       error
+}
+
+func (e *Error) Error() string {
+	msg := "integration: " + e.Op
+	if e.Component != "" {
+		msg += " " + e.Component
+	}
+	if e.Ref != "" {
+		msg += " in " + e.Ref
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// versionFilePattern matches the files at the root of the integration
+// repository pinning the versions of the components
+var versionFilePattern = regexp.MustCompile(
+	`^(docker-compose|other-components|git-versions).*\.ya?ml$`)
+
+// releaseBranchPattern matches the integration branches
+// --integration-versions-including looks at, besides the feature branches
+var releaseBranchPattern = regexp.MustCompile(`^(master|staging|\d+\.\d+\.x)$`)
+
+// defaultValuePattern matches the ${VARIABLE:-default} expansions of the
+// images, which resolve to their default
+var defaultValuePattern = regexp.MustCompile(`\$\{[A-Za-z_][A-Za-z0-9_]*:?-([^}]*)\}`)
+
+// Reader reads the version files of the integration repository cloned in
+// Dir through git objects, at any ref and without checking it out
+type Reader struct {
+	Dir string
+	// Remote is the remote whose branches are the integration versions,
+	// "origin" if empty
+	Remote string
+}
+
+// NewReader returns a reader of the integration repository cloned in dir
+func NewReader(dir string) *Reader {
+	return &Reader{Dir: dir}
+}
+
+type componentMaps struct {
+	Git map[string]struct {
+		DockerImage      []string `yaml:"docker_image"`
+		ReleaseComponent bool     `yaml:"release_component"`
+	} `yaml:"git"`
+}
+
+type versionFile struct {
+	Services map[string]struct {
+		Image string `yaml:"image"`
+	} `yaml:"services"`
+}
+
+// versions holds the versions pinned by an integration version
+type versions struct {
+	maps componentMaps
+	// git are the versions of the repositories set in the git-versions
+	// files, taking precedence over the images
+	git map[string]string
+	// images are the tags of the images
+	images map[string]string
+}
+
+func (r *Reader) remote() string {
+	if r.Remote == "" {
+		return "origin"
+	}
+	return r.Remote
+}
+
+func (r *Reader) git(ctx context.Context, args ...string) ([]byte, error) {
+	c := exec.Command("git", args...)
+	c.Dir = r.Dir
+	_, span := tracing.Start(ctx, "exec git",
+		attribute.StringSlice("process.command_args", c.Args))
+	out, err := c.Output()
+	tracing.End(span, err)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("%v: %w (%s)", c.Args, err,
+				strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *Reader) show(ctx context.Context, ref, file string) ([]byte, error) {
+	return r.git(ctx, "show", ref+":"+file)
+}
+
+// read reads the component maps and the version files of ref, for the
+// query op of component
+func (r *Reader) read(ctx context.Context, op, component, ref string) (*versions, error) {
+	if _, err := r.git(ctx, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return nil, &Error{Op: op, Ref: ref, Component: component, Err: ErrUnknownRef}
+	}
+	v := &versions{git: map[string]string{}, images: map[string]string{}}
+	content, err := r.show(ctx, ref, ComponentMapsPath)
+	if err != nil {
+		return nil, &Error{Op: op, Ref: ref, Component: component,
+			Err: fmt.Errorf("%w: %s is missing", ErrMalformed, ComponentMapsPath)}
+	}
+	if err := yaml.Unmarshal(content, &v.maps); err != nil {
+		return nil, &Error{Op: op, Ref: ref, Component: component,
+			Err: fmt.Errorf("%w: %s: %s", ErrMalformed, ComponentMapsPath, err)}
+	}
+
+	out, err := r.git(ctx, "ls-tree", "--name-only", ref)
+	if err != nil {
+		return nil, &Error{Op: op, Ref: ref, Component: component, Err: err}
+	}
+	// the files and their services are sorted, the first one pinning a
+	// version wins
+	for _, file := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if !versionFilePattern.MatchString(file) {
+			continue
+		}
+		content, err := r.show(ctx, ref, file)
+		if err != nil {
+			return nil, &Error{Op: op, Ref: ref, Component: component, Err: err}
+		}
+		var f versionFile
+		if err := yaml.Unmarshal(content, &f); err != nil {
+			return nil, &Error{Op: op, Ref: ref, Component: component,
+				Err: fmt.Errorf("%w: %s: %s", ErrMalformed, file, err)}
+		}
+		pinned := v.images
+		if strings.HasPrefix(file, "git-versions") {
+			pinned = v.git
+		}
+		services := make([]string, 0, len(f.Services))
+		for service := range f.Services {
+			services = append(services, service)
+		}
+		sort.Strings(services)
+		for _, service := range services {
+			name, tag := parseImage(f.Services[service].Image)
+			if _, exists := pinned[name]; name != "" && tag != "" && !exists {
+				pinned[name] = tag
+			}
+		}
+	}
+	return v, nil
+}
+
+// parseImage returns the name and the tag of an image, e.g. "deployments"
+// and "master" for registry.mender.io/mendersoftware/deployments:master
+func parseImage(image string) (string, string) {
+	image = defaultValuePattern.ReplaceAllString(image, "$1")
+	name, tag := image, ""
+	if idx := strings.LastIndex(image, ":"); idx > strings.LastIndex(image, "/") {
+		name, tag = image[:idx], image[idx+1:]
+	}
+	return path.Base(name), tag
+}
+
+func (v *versions) versionOf(repo string) (string, error) {
+	if version, exists := v.git[repo]; exists {
+		return version, nil
+	}
+	component, exists := v.maps.Git[repo]
+	if !exists {
+		return "", ErrUnknownComponent
+	}
+	for _, image := range component.DockerImage {
+		if version, exists := v.images[image]; exists {
+			return version, nil
+		}
+	}
+	return "", ErrNoVersion
+}
+
+// VersionOf returns the version of the repository in the integration
+// version ref, e.g. "origin/3.7.x"; the equivalent of
+// release_tool.py --version-of repo --in-integration-version ref
+func (r *Reader) VersionOf(ctx context.Context, repo, ref string) (string, error) {
+	const op = "version of"
+	v, err := r.read(ctx, op, repo, ref)
+	if err != nil {
+		return "", err
+	}
+	version, err := v.versionOf(repo)
+	if err != nil {
+		return "", &Error{Op: op, Ref: ref, Component: repo, Err: err}
+	}
+	return version, nil
+}
+
+// VersionedRepositories returns the repositories released in the integration
+// version ref, sorted; the equivalent of
+// release_tool.py --list --in-integration-version ref
+func (r *Reader) VersionedRepositories(ctx context.Context, ref string) ([]string, error) {
+	v, err := r.read(ctx, "list", "", ref)
+	if err != nil {
+		return nil, err
+	}
+	repos := []string{}
+	for repo, component := range v.maps.Git {
+		if component.ReleaseComponent {
+			repos = append(repos, repo)
+		}
+	}
+	sort.Strings(repos)
+	return repos, nil
+}
+
+// VersionsIncluding returns the integration branches, e.g. "3.7.x", where
+// the version of the repository is version, in the order of their names;
+// the feature branches are only looked at if featureBranches is set, and
+// the branches whose version files can't be read are skipped. It is
+// the equivalent of release_tool.py --integration-versions-including repo
+// --version version [--feature-branches]
+func (r *Reader) VersionsIncluding(
+	ctx context.Context,
+	repo, version string,
+	featureBranches bool,
+) ([]string, error) {
+	const op = "versions including"
+	prefix := "refs/remotes/" + r.remote() + "/"
+	out, err := r.git(ctx, "for-each-ref", "--format=%(refname)", prefix)
+	if err != nil {
+		return nil, &Error{Op: op, Component: repo, Err: err}
+	}
+	branches := []string{}
+	for _, ref := range strings.Fields(string(out)) {
+		branch := strings.TrimPrefix(ref, prefix)
+		if !releaseBranchPattern.MatchString(branch) &&
+			(!featureBranches || !strings.HasPrefix(branch, FeatureBranchPrefix)) {
+			continue
+		}
+		v, err := r.read(ctx, op, repo, r.remote()+"/"+branch)
+		if errors.Is(err, ErrMalformed) {
+			// the old release branches predate the component maps
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		branchVersion, err := v.versionOf(repo)
+		if errors.Is(err, ErrUnknownComponent) || errors.Is(err, ErrNoVersion) {
+			continue
+		} else if err != nil {
+			return nil, &Error{Op: op, Ref: branch, Component: repo, Err: err}
+		}
+		if branchVersion == version {
+			branches = append(branches, branch)
+		}
+	}
+	return branches, nil
+}
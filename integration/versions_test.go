@@ -0,0 +1,151 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mendersoftware/integration-test-runner/testing/fakegit"
+)
+
+// releaseToolCall is a release_tool.py invocation on the integration
+// fixture and its output, as recorded by testdata/record_release_tool.py
+type releaseToolCall struct {
+	Args   []string `json:"args"`
+	Stdout string   `json:"stdout"`
+	// Error is the error expected from the reader when release_tool.py
+	// fails
+	Error string `json:"error"`
+}
+
+var sentinelErrors = map[string]error{
+	ErrUnknownRef.Error():       ErrUnknownRef,
+	ErrUnknownComponent.Error(): ErrUnknownComponent,
+	ErrNoVersion.Error():        ErrNoVersion,
+	ErrMalformed.Error():        ErrMalformed,
+}
+
+// newFixtureReader returns a reader of a repository with a remote branch
+// per directory of testdata/integration
+func newFixtureReader(t *testing.T) *Reader {
+	remote := fakegit.NewRemote(t)
+	dir := filepath.Join(t.TempDir(), "integration.git")
+	remote.Init(dir)
+	root := filepath.Join("testdata", "integration")
+	err := filepath.WalkDir(root, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		branch, name, _ := strings.Cut(filepath.ToSlash(rel), "/")
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		remote.Commit(dir, "refs/remotes/origin/"+branch, name, string(content), "add "+name)
+		return nil
+	})
+	require.NoError(t, err)
+	return NewReader(dir)
+}
+
+// releaseToolLines splits the output of release_tool.py as the callers did,
+// removing the remote from the branches
+func releaseToolLines(stdout string) []string {
+	lines := []string{}
+	for _, line := range strings.Fields(stdout) {
+		if _, branch, found := strings.Cut(line, "/"); found {
+			line = branch
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestReleaseToolParity(t *testing.T) {
+	reader := newFixtureReader(t)
+	content, err := os.ReadFile(filepath.Join("testdata", "release_tool.json"))
+	require.NoError(t, err)
+	var calls []releaseToolCall
+	require.NoError(t, json.Unmarshal(content, &calls))
+
+	for _, call := range calls {
+		t.Run(strings.Join(call.Args, " "), func(t *testing.T) {
+			var (
+				got []string
+				err error
+			)
+			ctx := context.Background()
+			switch call.Args[0] {
+			case "--version-of":
+				var version string
+				version, err = reader.VersionOf(ctx, call.Args[1], call.Args[3])
+				got = []string{version}
+			case "--list":
+				got, err = reader.VersionedRepositories(ctx, call.Args[2])
+			case "--integration-versions-including":
+				got, err = reader.VersionsIncluding(ctx, call.Args[1], call.Args[3],
+					len(call.Args) > 4 && call.Args[4] == "--feature-branches")
+			default:
+				t.Fatalf("unsupported release_tool.py call %v", call.Args)
+			}
+			if call.Error != "" {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, sentinelErrors[call.Error])
+				var queryErr *Error
+				assert.True(t, errors.As(err, &queryErr))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, releaseToolLines(call.Stdout), got)
+		})
+	}
+}
+
+func TestVersionOfMalformed(t *testing.T) {
+	reader := newFixtureReader(t)
+	_, err := reader.VersionOf(context.Background(), "mender", "origin/old-stuff")
+	assert.ErrorIs(t, err, ErrMalformed)
+	assert.EqualError(t, err, "integration: version of mender in origin/old-stuff: malformed version file: "+
+		"extra/component-maps.yml is missing")
+}
+
+func TestVersionsIncludingSkipsMalformed(t *testing.T) {
+	reader := newFixtureReader(t)
+	// 2.6.x has no component maps
+	_, err := reader.VersionOf(context.Background(), "deployments", "origin/2.6.x")
+	assert.ErrorIs(t, err, ErrMalformed)
+	branches, err := reader.VersionsIncluding(context.Background(), "deployments", "1.9.x", false)
+	assert.NoError(t, err)
+	assert.Empty(t, branches)
+}
+
+func TestParseImage(t *testing.T) {
+	testCases := map[string][2]string{
+		"mendersoftware/deployments:4.4.x": {"deployments", "4.4.x"},
+		"${MENDER_REGISTRY:-registry.mender.io}/mendersoftware/deployments:master": {
+			"deployments", "master",
+		},
+		"localhost:5000/mendersoftware/mender-client-qemu:${TAG:-mender-3.7.x}": {
+			"mender-client-qemu", "mender-3.7.x",
+		},
+		"localhost:5000/mendersoftware/mender-artifact": {"mender-artifact", ""},
+	}
+	for image, expected := range testCases {
+		t.Run(image, func(t *testing.T) {
+			name, tag := parseImage(image)
+			assert.Equal(t, expected, [2]string{name, tag})
+		})
+	}
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"bytes"
-	"context"
 	"strconv"
 	"strings"
 	"text/template"
@@ -45,11 +44,7 @@ func triggerIntegrationBuild(
 	pr *github.PullRequestEvent,
 	buildOptions *BuildOptions,
 ) error {
-	gitlabIntegration, err := clientgitlab.NewGitLabClient(
-		conf.gitlabToken,
-		conf.gitlabBaseURL,
-		conf.dryRunMode,
-	)
+	gitlabIntegration, err := newGitLabClient(traceContext(log), conf)
 	if err != nil {
 		return err
 	}
@@ -59,9 +54,29 @@ func triggerIntegrationBuild(
 		return err
 	}
 
-	// first stop old pipelines with the same buildParameters
-	stopStalePipelines(integrationPipelinePath, log, gitlabIntegration, buildParameters)
+	sortPipelineVariables(buildParameters)
+	// first stop the old pipelines of the same build, unless --no-supersede
+	supersede := buildOptions == nil || !buildOptions.NoSupersede
+	if supersede {
+		stopStalePipelines(integrationPipelinePath, log, gitlabIntegration, buildParameters)
+	}
+
+	return schedulePipeline(log, conf, gitlabIntegration, integrationPipelinePath,
+		buildParameters, supersede, build.baseBranch, pr,
+		func(log *logrus.Entry, conf *config, gitlabIntegration clientgitlab.Client) error {
+			return createIntegrationPipeline(log, conf, gitlabIntegration, pr, buildParameters)
+		})
+}
 
+// createIntegrationPipeline creates the integration pipeline and comments its
+// link on the pull request
+func createIntegrationPipeline(
+	log *logrus.Entry,
+	conf *config,
+	gitlabIntegration clientgitlab.Client,
+	pr *github.PullRequestEvent,
+	buildParameters []*gitlab.PipelineVariableOptions,
+) error {
 	// trigger the new pipeline
 	ref := "pr_" + strconv.Itoa(pr.GetNumber()) + "_protected"
 	opt := &gitlab.CreatePipelineOptions{
@@ -128,7 +143,7 @@ Hello :smiley_cat: I created a pipeline for you here: [Pipeline-{{.Pipeline.ID}}
 		Body: &commentBody,
 	}
 
-	err = githubClient.CreateComment(context.Background(),
+	err = githubClient.CreateComment(traceContext(log),
 		conf.githubOrganization, pr.GetRepo().GetName(), pr.GetNumber(), &comment)
 	if err != nil {
 		log.Infof("Failed to comment on the pr: %v, Error: %s", pr, err.Error())
@@ -160,6 +175,8 @@ func getIntegrationBuildParameters(
 			Value: &readHead,
 		})
 
+	buildParameters = append(buildParameters, getPipelineIdentityParameters(build)...)
+
 	return buildParameters, nil
 
 }
@@ -1,51 +1,162 @@
 package logger
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Kind is the kind of a log entry
+type Kind string
+
+const (
+	// KindLog is a log message
+	KindLog Kind = "log"
+	// KindGit is a git command
+	KindGit Kind = "git"
+	// KindGitHub is a GitHub API call
+	KindGitHub Kind = "github"
+	// KindGitLab is a GitLab API call
+	KindGitLab Kind = "gitlab"
+)
+
+// Args are the arguments of an operation
+type Args map[string]interface{}
+
+// Entry is a log entry of the request logger; entries recording an outward
+// action (git command, GitHub or GitLab API call) carry the operation name
+// and its arguments
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Delivery  string    `json:"delivery"`
+	Kind      Kind      `json:"kind"`
+	Operation string    `json:"operation,omitempty"`
+	Args      Args      `json:"args,omitempty"`
+	Message   string    `json:"message"`
+}
+
+// Filter selects log entries; empty fields match all the entries
+type Filter struct {
+	Delivery string
+	Kind     Kind
+}
+
+// Match returns true if the entry is selected by the filter
+func (f Filter) Match(entry Entry) bool {
+	return (f.Delivery == "" || f.Delivery == entry.Delivery) &&
+		(f.Kind == "" || f.Kind == entry.Kind)
+}
 
 // RequestLogger is the request logger interface
 type RequestLogger interface {
 	Push(string)
+	PushEntry(Entry)
 	Write(p []byte) (n int, err error)
 	Get() []string
+	Entries(Filter) []Entry
 	Clear()
+	StartDelivery(delivery string) (done func())
 }
 
 // RequestLoggerObject is an object implementing the request logger
 type RequestLoggerObject struct {
-	logs []string
+	mutex    sync.Mutex
+	logs     []Entry
+	delivery string
+
+	deliveryMutex sync.Mutex
 }
 
 // NewRequestLogger returns a new request logger
 func NewRequestLogger() RequestLogger {
 	return &RequestLoggerObject{
-		logs: []string{},
+		logs: []Entry{},
 	}
 }
 
-// Push pushes a new log to the logger
+// Push pushes a new log message to the logger
 func (r *RequestLoggerObject) Push(msg string) {
-	r.logs = append(r.logs, msg)
+	r.PushEntry(Entry{Kind: KindLog, Message: msg})
+}
+
+// PushEntry pushes a new entry to the logger; the entry is tagged with the
+// current time and delivery unless they are already set
+func (r *RequestLoggerObject) PushEntry(entry Entry) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if entry.Delivery == "" {
+		entry.Delivery = r.delivery
+	}
+	if entry.Kind == "" {
+		entry.Kind = KindLog
+	}
+	r.logs = append(r.logs, entry)
 }
 
-// Get retrieves the logs from the logger
+// Get retrieves the log messages from the logger
 func (r *RequestLoggerObject) Get() []string {
-	return r.logs
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	logs := make([]string, len(r.logs))
+	for i, entry := range r.logs {
+		logs[i] = entry.Message
+	}
+	return logs
+}
+
+// Entries retrieves the log entries selected by the filter
+func (r *RequestLoggerObject) Entries(filter Filter) []Entry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	entries := []Entry{}
+	for _, entry := range r.logs {
+		if filter.Match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
 }
 
 // Clear removes all the logs from the logger
 func (r *RequestLoggerObject) Clear() {
-	r.logs = []string{}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.logs = []Entry{}
+}
+
+// StartDelivery tags the entries pushed without a delivery with the given
+// one until done is called. Deliveries are serialized: a second call blocks
+// until the previous delivery is done.
+func (r *RequestLoggerObject) StartDelivery(delivery string) (done func()) {
+	r.deliveryMutex.Lock()
+	r.mutex.Lock()
+	r.delivery = delivery
+	r.mutex.Unlock()
+	return func() {
+		r.mutex.Lock()
+		r.delivery = ""
+		r.mutex.Unlock()
+		r.deliveryMutex.Unlock()
+	}
 }
 
 // Write parses a JSON log message and add it to the logs (io.Writer interface)
 func (r *RequestLoggerObject) Write(p []byte) (n int, err error) {
 	log := &struct {
-		Time    string `json:"time"`
-		Level   string `json:"level"`
-		Message string `json:"message"`
+		Time     string      `json:"time"`
+		Level    string      `json:"level"`
+		Message  string      `json:"message"`
+		Delivery interface{} `json:"delivery"`
 	}{}
 	if err := json.Unmarshal(p, log); err == nil {
-		r.Push(log.Level + ":" + log.Message)
+		entry := Entry{Kind: KindLog, Message: log.Level + ":" + log.Message}
+		if delivery, ok := log.Delivery.(string); ok && delivery != "nil" {
+			entry.Delivery = delivery
+		}
+		r.PushEntry(entry)
 	}
 	return len(p), nil
 }
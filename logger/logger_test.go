@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -21,3 +23,65 @@ func TestRequestLogger(t *testing.T) {
 	logs = logger.Get()
 	assert.Equal(t, logs, []string{})
 }
+
+func TestRequestLoggerEntries(t *testing.T) {
+	logger := NewRequestLogger()
+	logger.Push("before")
+
+	done := logger.StartDelivery("delivery-1")
+	logger.Push("log 1")
+	logger.PushEntry(Entry{Kind: KindGitHub, Message: "github 1"})
+	_, _ = logger.Write([]byte(`{"level":"info","message":"json 1"}`))
+	_, _ = logger.Write([]byte(`{"level":"info","message":"json 2","delivery":"delivery-2"}`))
+	done()
+
+	logger.PushEntry(Entry{Kind: KindGit, Message: "git 2", Delivery: "delivery-2"})
+	_, _ = logger.Write([]byte(`{"level":"warning","message":"json 3","delivery":"nil"}`))
+	_, _ = logger.Write([]byte(`not json`))
+
+	messages := func(entries []Entry) []string {
+		res := []string{}
+		for _, entry := range entries {
+			assert.False(t, entry.Time.IsZero())
+			res = append(res, entry.Message)
+		}
+		return res
+	}
+
+	assert.Equal(t, []string{
+		"before", "log 1", "github 1", "info:json 1", "info:json 2", "git 2", "warning:json 3",
+	}, messages(logger.Entries(Filter{})))
+	assert.Equal(t, []string{
+		"log 1", "github 1", "info:json 1",
+	}, messages(logger.Entries(Filter{Delivery: "delivery-1"})))
+	assert.Equal(t, []string{
+		"info:json 2", "git 2",
+	}, messages(logger.Entries(Filter{Delivery: "delivery-2"})))
+	assert.Equal(t, []string{
+		"github 1",
+	}, messages(logger.Entries(Filter{Delivery: "delivery-1", Kind: KindGitHub})))
+	assert.Equal(t, []string{
+		"before", "log 1", "info:json 1", "info:json 2", "warning:json 3",
+	}, messages(logger.Entries(Filter{Kind: KindLog})))
+}
+
+func TestRequestLoggerConcurrency(t *testing.T) {
+	logger := NewRequestLogger()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			delivery := fmt.Sprintf("delivery-%d", i)
+			for j := 0; j < 100; j++ {
+				logger.PushEntry(Entry{Delivery: delivery, Message: "msg"})
+				_ = logger.Get()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Len(t, logger.Get(), 1000)
+	assert.Len(t, logger.Entries(Filter{Delivery: "delivery-3"}), 100)
+}
@@ -2,12 +2,14 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,26 +17,44 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/go-github/v28/github"
 	"github.com/sirupsen/logrus"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/sys/unix"
 
 	clientgithub "github.com/mendersoftware/integration-test-runner/client/github"
+	clientgitlab "github.com/mendersoftware/integration-test-runner/client/gitlab"
 	"github.com/mendersoftware/integration-test-runner/git"
 	"github.com/mendersoftware/integration-test-runner/logger"
+	"github.com/mendersoftware/integration-test-runner/metrics"
+	"github.com/mendersoftware/integration-test-runner/tracing"
 )
 
 type config struct {
 	dryRunMode             bool
+	dryRunGitRules         string
 	githubSecret           []byte
 	githubProtocol         gitProtocol
 	githubOrganization     string
 	githubToken            string
+	githubBaseURL          string
 	gitlabToken            string
 	gitlabBaseURL          string
+	gitlabRetryPolicy      clientgitlab.RetryPolicy
+	gitlabMergeRequests    bool
+	gitlabWebhookSecret    string
+	pipelineLimits         map[string]int
+	clientPipelineLabel    string
+	clientPipelineStatus   bool
+	commitLintRules        map[string]*CommitLintRules
+	conventionalCommits    conventionalCommitConfig
+	cherryPickConflictPRs  bool
 	integrationDirectory   string
 	isProcessPushEvents    bool
 	isProcessPREvents      bool
 	isProcessCommentEvents bool
 	reposSyncList          []string
+	tracingEndpoint        string
+	tracingStdout          bool
 }
 
 type buildOptions struct {
@@ -114,6 +134,13 @@ const (
 	gitOperationTimeout = 30
 )
 
+// Formats of the dry-run logs: the legacy log messages, or the structured
+// events recording the operation name and arguments of every outward action
+const (
+	logsFormatMessages = "messages"
+	logsFormatEvents   = "events"
+)
+
 const (
 	featureBranchPrefix = "feature-"
 )
@@ -125,6 +152,8 @@ const (
 const (
 	commandStartIntegrationPipeline = "start integration pipeline"
 	commandStartClientPipeline      = "start client pipeline"
+	commandPlanClientPipeline       = "plan client pipeline"
+	commandWaiveClientPipeline      = "waive client pipeline"
 	commandStartReviewApp           = "start review app"
 	commandStartReviewTests         = "start review tests"
 	commandCherryPickBranch         = "cherry-pick to:"
@@ -135,12 +164,60 @@ const (
 )
 
 func getConfig() (*config, error) {
+	conf, err := getConfigWithDryRunMode(os.Getenv("DRY_RUN") != "")
+	if err != nil {
+		return conf, err
+	}
+	// the secret is needed to validate the webhooks only
+	if len(conf.githubSecret) == 0 && !conf.dryRunMode {
+		return &config{}, fmt.Errorf("set GITHUB_SECRET")
+	}
+	return conf, nil
+}
+
+func getConfigWithDryRunMode(dryRunMode bool) (*config, error) {
 	var reposSyncList []string
-	dryRunMode := os.Getenv("DRY_RUN") != ""
+	// JSON file with the rules scripting the git commands output in dry-run mode
+	dryRunGitRules := os.Getenv("DRY_RUN_GIT_RULES")
 	githubSecret := os.Getenv("GITHUB_SECRET")
 	githubToken := os.Getenv("GITHUB_TOKEN")
+	// optional, to talk to a GitHub API other than api.github.com
+	githubBaseURL := os.Getenv("GITHUB_BASE_URL")
 	gitlabToken := os.Getenv("GITLAB_TOKEN")
 	gitlabBaseURL := os.Getenv("GITLAB_BASE_URL")
+	gitlabRetryPolicy, err := getGitLabRetryPolicy()
+	if err != nil {
+		return &config{}, err
+	}
+	pipelineLimits, err := getPipelineLimits()
+	if err != nil {
+		return &config{}, err
+	}
+	// optional, open GitLab merge requests for the pr_ branches, and token of
+	// the GitLab webhook cross-posting their comments to the pull requests
+	gitlabMergeRequests := os.Getenv("GITLAB_MERGE_REQUESTS") != ""
+	gitlabWebhookSecret := os.Getenv("GITLAB_WEBHOOK_SECRET")
+	// optional, label of the pull requests starting the client pipelines on
+	// every push
+	clientPipelineLabel := os.Getenv("CLIENT_PIPELINE_LABEL")
+	// optional, gate the merge of the pull requests with the client pipeline
+	// status, set from the GitLab pipeline webhook
+	clientPipelineStatus := os.Getenv("CLIENT_PIPELINE_STATUS") != ""
+	// optional, open draft pull requests with the conflict markers of the
+	// failed cherry-picks
+	cherryPickConflictPRs := os.Getenv("CHERRY_PICK_CONFLICT_PRS") != ""
+	// optional, JSON file with the rules of the commit messages of the pull
+	// requests, keyed by organization
+	var commitLintRules map[string]*CommitLintRules
+	if path := os.Getenv("COMMIT_LINT_CONFIG_PATH"); path != "" {
+		if commitLintRules, err = loadCommitLintConfig(path); err != nil {
+			return &config{}, err
+		}
+	}
+	// optional, OTLP/HTTP collector receiving the traces, and export of the
+	// traces to stdout when no collector is configured
+	tracingEndpoint := os.Getenv("TRACING_ENDPOINT")
+	tracingStdout := os.Getenv("TRACING_STDOUT") != ""
 	integrationDirectory := "/integration/"
 	if integrationDirEnv := os.Getenv("INTEGRATION_DIRECTORY"); integrationDirEnv != "" {
 		integrationDirectory = integrationDirEnv
@@ -182,8 +259,6 @@ func getConfig() (*config, error) {
 	}
 
 	switch {
-	case githubSecret == "" && !dryRunMode:
-		return &config{}, fmt.Errorf("set GITHUB_SECRET")
 	case githubToken == "":
 		return &config{}, fmt.Errorf("set GITHUB_TOKEN")
 	case gitlabToken == "":
@@ -196,25 +271,84 @@ func getConfig() (*config, error) {
 
 	return &config{
 		dryRunMode:             dryRunMode,
+		dryRunGitRules:         dryRunGitRules,
 		githubSecret:           []byte(githubSecret),
 		githubProtocol:         gitProtocolSSH,
 		githubToken:            githubToken,
+		githubBaseURL:          githubBaseURL,
 		gitlabToken:            gitlabToken,
 		gitlabBaseURL:          gitlabBaseURL,
+		gitlabRetryPolicy:      gitlabRetryPolicy,
+		gitlabMergeRequests:    gitlabMergeRequests,
+		gitlabWebhookSecret:    gitlabWebhookSecret,
+		pipelineLimits:         pipelineLimits,
+		clientPipelineLabel:    clientPipelineLabel,
+		clientPipelineStatus:   clientPipelineStatus,
+		commitLintRules:        commitLintRules,
+		conventionalCommits:    getConventionalCommitConfig(),
+		cherryPickConflictPRs:  cherryPickConflictPRs,
 		integrationDirectory:   integrationDirectory,
 		isProcessPushEvents:    isProcessPushEvents,
 		isProcessPREvents:      isProcessPREvents,
 		isProcessCommentEvents: isProcessCommentEvents,
 		reposSyncList:          reposSyncList,
+		tracingEndpoint:        tracingEndpoint,
+		tracingStdout:          tracingStdout,
 	}, nil
 }
 
+// getGitLabRetryPolicy returns the policy of the retries of the GitLab calls,
+// the defaults can be overridden with the GITLAB_RETRY_* env variables
+func getGitLabRetryPolicy() (clientgitlab.RetryPolicy, error) {
+	policy := clientgitlab.DefaultRetryPolicy()
+	if value := os.Getenv("GITLAB_RETRY_MAX_ATTEMPTS"); value != "" {
+		maxAttempts, err := strconv.Atoi(value)
+		if err != nil || maxAttempts < 1 {
+			return policy, fmt.Errorf("invalid GITLAB_RETRY_MAX_ATTEMPTS: %q", value)
+		}
+		policy.MaxAttempts = maxAttempts
+	}
+	for name, delay := range map[string]*time.Duration{
+		"GITLAB_RETRY_BASE_DELAY": &policy.BaseDelay,
+		"GITLAB_RETRY_MAX_DELAY":  &policy.MaxDelay,
+	} {
+		if value := os.Getenv(name); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return policy, fmt.Errorf("invalid %s: %q", name, value)
+			}
+			*delay = d
+		}
+	}
+	return policy, nil
+}
+
+// getPipelineLimits returns the maximum number of concurrent pipelines of
+// the bot in the pipeline projects, from the *_PIPELINE_MAX_CONCURRENT env
+// variables; the projects without a limit are not in the map
+func getPipelineLimits() (map[string]int, error) {
+	limits := map[string]int{}
+	for name, path := range map[string]string{
+		"CLIENT_PIPELINE_MAX_CONCURRENT":      clientPipelinePath,
+		"INTEGRATION_PIPELINE_MAX_CONCURRENT": integrationPipelinePath,
+	} {
+		if value := os.Getenv(name); value != "" {
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit < 1 {
+				return nil, fmt.Errorf("invalid %s: %q", name, value)
+			}
+			limits[path] = limit
+		}
+	}
+	return limits, nil
+}
+
 func getCustomLoggerFromContext(ctx *gin.Context) *logrus.Entry {
 	deliveryID, ok := ctx.Get("delivery")
 	if !ok || !isStringType(deliveryID) {
-		return logrus.WithField("delivery", "nil")
+		return logrus.WithField("delivery", "nil").WithContext(ctx)
 	}
-	return logrus.WithField("delivery", deliveryID)
+	return logrus.WithField("delivery", deliveryID).WithContext(ctx)
 }
 
 func isStringType(i interface{}) bool {
@@ -243,6 +377,26 @@ func processGitHubWebhook(
 	webhookEvent interface{},
 	githubClient clientgithub.Client,
 	conf *config,
+) error {
+	defer metrics.ObserveWebhook(webhookType, getWebhookAction(webhookEvent), time.Now())
+	deliveryID, _ := ctx.Get("delivery")
+	spanCtx, span := tracing.Start(context.Background(), "webhook "+webhookType,
+		attribute.String("github.delivery", fmt.Sprint(deliveryID)),
+		attribute.String("github.event", webhookType),
+		attribute.String("github.action", getWebhookAction(webhookEvent)),
+	)
+	ctx.Set(tracing.ContextKey, spanCtx)
+	err := processGitHubWebhookEvent(ctx, webhookType, webhookEvent, githubClient, conf)
+	tracing.End(span, err)
+	return err
+}
+
+func processGitHubWebhookEvent(
+	ctx *gin.Context,
+	webhookType string,
+	webhookEvent interface{},
+	githubClient clientgithub.Client,
+	conf *config,
 ) error {
 	githubOrganization, err := getGitHubOrganization(webhookType, webhookEvent)
 	if err != nil {
@@ -295,7 +449,25 @@ func setupLogging(conf *config, requestLogger logger.RequestLogger) {
 	logrus.SetFormatter(formatter)
 }
 
+// setupTracing sets up the export of the traces to the configured collector,
+// or to stdout when requested and no collector is configured
+func setupTracing(conf *config, stdout io.Writer) (func(context.Context) error, error) {
+	tracingConf := tracing.Config{Endpoint: conf.tracingEndpoint}
+	if conf.tracingStdout {
+		tracingConf.Stdout = stdout
+	}
+	return tracing.Setup(context.Background(), tracingConf)
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			if err := run(os.Args[2:], os.Stdout); err != nil {
+				logrus.Fatalf("%s failed: %s", os.Args[1], err.Error())
+			}
+			return
+		}
+	}
 	doMain()
 }
 
@@ -313,9 +485,38 @@ func doMain() {
 	setupLogging(conf, requestLogger)
 	git.SetDryRunMode(conf.dryRunMode)
 
+	gitExecutor, err := git.NewFakeExecutor(nil)
+	if conf.dryRunGitRules != "" {
+		gitExecutor, err = git.LoadFakeExecutor(conf.dryRunGitRules)
+	}
+	if err != nil {
+		logrus.Fatalf("failed to load the dry-run git rules: %s", err.Error())
+	}
+	if conf.dryRunMode {
+		git.SetDryRunExecutor(gitExecutor)
+	}
+
 	logrus.Infoln("using settings: ", spew.Sdump(conf))
 
-	githubClient = clientgithub.NewGitHubClient(conf.githubToken, conf.dryRunMode)
+	shutdownTracing, err := setupTracing(conf, os.Stdout)
+	if err != nil {
+		logrus.Fatalf("failed to set up the tracing: %s", err.Error())
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logrus.Errorf("failed to flush the traces: %s", err.Error())
+		}
+	}()
+
+	githubClient, err = newGitHubClient(conf)
+	if err != nil {
+		logrus.Fatalf("failed to create the GitHub client: %s", err.Error())
+	}
+
+	if len(conf.pipelineLimits) > 0 {
+		pipelinesQueue = newPipelineQueue(conf.pipelineLimits)
+		go pipelinesQueue.run(context.Background(), pipelineQueueInterval)
+	}
 
 	r := gin.Default()
 	filter := "/_health"
@@ -338,15 +539,58 @@ func doMain() {
 			context.Status(http.StatusForbidden)
 			return
 		}
-		context.Set("delivery", github.DeliveryID(context.Request))
+		deliveryID := github.DeliveryID(context.Request)
+		context.Set("delivery", deliveryID)
 		if conf.dryRunMode {
+			done := requestLogger.StartDelivery(deliveryID)
 			processGitHubWebhookRequest(context, payload, githubClient, conf)
+			done()
 		} else {
-			go processGitHubWebhookRequest(context, payload, githubClient, conf)
+			// gin recycles the context once the handler returns, the
+			// delivery keeps a copy holding its span
+			go processGitHubWebhookRequest(context.Copy(), payload, githubClient, conf)
 		}
 		context.Status(http.StatusAccepted)
 	})
 
+	// webhook for GitLab, cross-posting the merge request comments and
+	// setting the client pipeline statuses
+	if conf.gitlabWebhookSecret != "" {
+		r.POST("/gitlab", func(context *gin.Context) {
+			token := gitlab.HookEventToken(context.Request)
+			if subtle.ConstantTimeCompare([]byte(token), []byte(conf.gitlabWebhookSecret)) != 1 {
+				logrus.Warnln("GitLab webhook token is invalid, ignoring.")
+				context.Status(http.StatusForbidden)
+				return
+			}
+			payload, err := io.ReadAll(context.Request.Body)
+			if err != nil {
+				var mbErr *http.MaxBytesError
+				if errors.As(err, &mbErr) {
+					context.Status(http.StatusRequestEntityTooLarge)
+					return
+				}
+				context.Status(http.StatusBadRequest)
+				return
+			}
+			eventType := gitlab.HookEventType(context.Request)
+			deliveryID := context.GetHeader("X-Gitlab-Event-UUID")
+			context.Set("delivery", deliveryID)
+			if conf.dryRunMode {
+				done := requestLogger.StartDelivery(deliveryID)
+				processGitLabWebhookRequest(context, eventType, payload, githubClient, conf)
+				done()
+			} else {
+				// gin recycles the context once the handler returns
+				go processGitLabWebhookRequest(context.Copy(), eventType, payload,
+					githubClient, conf)
+			}
+			context.Status(http.StatusAccepted)
+		})
+	}
+
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
 	// 200 replay for the loadbalancer
 	r.GET("/_health", func(_ *gin.Context) {})
 	r.GET("/", func(_ *gin.Context) {})
@@ -354,14 +598,52 @@ func doMain() {
 	// dry-run mode, end-point to retrieve and clear logs
 	if conf.dryRunMode {
 		r.GET("/logs", func(context *gin.Context) {
-			logs := requestLogger.Get()
-			context.JSON(http.StatusOK, logs)
+			filter := logger.Filter{
+				Delivery: context.Query("delivery"),
+				Kind:     logger.Kind(context.Query("kind")),
+			}
+			entries := requestLogger.Entries(filter)
+			switch context.Query("format") {
+			case "", logsFormatMessages:
+				logs := []string{}
+				for _, entry := range entries {
+					logs = append(logs, entry.Message)
+				}
+				context.JSON(http.StatusOK, logs)
+			case logsFormatEvents:
+				context.JSON(http.StatusOK, entries)
+			default:
+				context.String(http.StatusBadRequest, "unknown format")
+			}
 		})
 
 		r.DELETE("/logs", func(context *gin.Context) {
 			requestLogger.Clear()
 			context.Writer.WriteHeader(http.StatusNoContent)
 		})
+
+		// end-points to script the output of the git commands
+		r.PUT("/git-rules", func(context *gin.Context) {
+			body, err := io.ReadAll(context.Request.Body)
+			if err != nil {
+				context.Status(http.StatusBadRequest)
+				return
+			}
+			rules, err := git.ParseRules(body)
+			if err == nil {
+				err = gitExecutor.SetRules(rules)
+			}
+			if err != nil {
+				context.String(http.StatusBadRequest, err.Error())
+				return
+			}
+			context.Writer.WriteHeader(http.StatusNoContent)
+		})
+
+		r.DELETE("/git-rules", func(context *gin.Context) {
+			_ = gitExecutor.SetRules(nil)
+			context.Writer.WriteHeader(http.StatusNoContent)
+		})
 	}
 
 	srv := &http.Server{
@@ -3,7 +3,6 @@ package main
 import (
 	"errors"
 	"fmt"
-	"slices"
 	"strconv"
 	"strings"
 
@@ -16,6 +15,7 @@ import (
 
 	clientgithub "github.com/mendersoftware/integration-test-runner/client/github"
 	clientgitlab "github.com/mendersoftware/integration-test-runner/client/gitlab"
+	"github.com/mendersoftware/integration-test-runner/metrics"
 )
 
 // nolint: gocyclo
@@ -81,9 +81,21 @@ func processGitHubComment(
 		return err
 	}
 
+	// record the command found in the comment and its outcome
+	var (
+		command    string
+		commandErr error
+	)
+	defer func() {
+		if command != "" {
+			metrics.ObserveCommand(command, commandErr)
+		}
+	}()
+
 	// extract the command and check it is valid
 	switch {
 	case strings.Contains(commentBody, commandStartIntegrationPipeline):
+		command = commandStartIntegrationPipeline
 		prRequest := &github.PullRequestEvent{
 			Repo:        comment.GetRepo(),
 			Number:      github.Int(pr.GetNumber()),
@@ -102,15 +114,19 @@ func processGitHubComment(
 				log,
 				conf,
 				prRequest)
+			commandErr = err
 			return err
 
 		}
 
-		// start the build
-		if err := triggerIntegrationBuild(log, conf, &build, prRequest, nil); err != nil {
+		// start the build; of the options, only --no-supersede applies
+		buildOptions, _ := parseBuildOptions(commentBody)
+		if err := triggerIntegrationBuild(log, conf, &build, prRequest, buildOptions); err != nil {
 			log.Errorf("Could not start build: %s", err.Error())
+			commandErr = err
 		}
 	case strings.Contains(commentBody, commandStartClientPipeline):
+		command = commandStartClientPipeline
 		buildOptions, err := parseBuildOptions(commentBody)
 		// get the list of builds
 		prRequest := &github.PullRequestEvent{
@@ -128,93 +144,47 @@ func processGitHubComment(
 				log,
 				conf,
 				prRequest)
+			commandErr = err
 			return err
 		}
 
-		// Logic for protected pipeline when we have --pr integration/xxx
-		integrationRev, hasIntegration := buildOptions.PullRequests["integration"]
-		if hasIntegration {
-
-			// Split pull/xxx/head
-			parts := strings.Split(integrationRev, "/")
-
-			// Get the xxx part
-			integrationPRNum, err := strconv.Atoi(parts[1])
-			if err != nil {
-				log.Errorf("Failed to convert String to int: %s", err.Error())
-				return err
-			}
-
-			// Get the PR from integration
-			integrationPR, err := githubClient.GetPullRequest(
-				ctx,
-				conf.githubOrganization,
-				"integration",
-				integrationPRNum,
-			)
-			if err != nil {
-				log.Errorf("Unable to retrieve the pull request: %s", err.Error())
-				return err
-			}
-
-			// Create the PR request
-			integrationPRRequest := &github.PullRequestEvent{
-				Repo: &github.Repository{
-					Name: github.String("integration"),
-				},
-				Number:      github.Int(integrationPRNum),
-				PullRequest: integrationPR,
-			}
-
-			build := getIntegrationBuild(log, conf, integrationPRRequest)
-
-			_, err = syncProtectedBranch(log, integrationPRRequest, conf, integrationPipelinePath)
-			if err != nil {
-				_ = say(ctx, "There was an error while syncing branhces: {{.ErrorMessage}}",
-					struct {
-						ErrorMessage string
-					}{
-						ErrorMessage: err.Error(),
-					},
-					log,
-					conf,
-					prRequest) // Report failure at the comment repo
-				return err
-			}
-
-			err = triggerIntegrationBuild(log, conf, &build, integrationPRRequest, nil)
-			if err != nil {
-				log.Errorf("Could not start build: %s", err.Error())
-			}
+		commandErr = startClientPipelines(ctx, log, githubClient, conf, prRequest, buildOptions)
+		return commandErr
+	case strings.Contains(commentBody, commandWaiveClientPipeline):
+		command = commandWaiveClientPipeline
+		prRequest := &github.PullRequestEvent{
+			Repo:        comment.GetRepo(),
+			Number:      github.Int(pr.GetNumber()),
+			PullRequest: pr,
 		}
-
-		builds := parseClientPullRequest(log, conf, "opened", prRequest)
-		log.Infof(
-			"%s:%d will trigger %d builds",
-			comment.GetRepo().GetName(),
-			pr.GetNumber(),
-			len(builds),
-		)
-
-		// start the builds
-		for idx, build := range builds {
-			log.Infof("%d: "+spew.Sdump(build)+"\n", idx+1)
-			if build.repo == "meta-mender" && build.baseBranch == "master-next" {
-				log.Info("Skipping build targeting meta-mender:master-next")
-				continue
-			}
-			if len(buildOptions.Releases) > 0 && !slices.Contains(
-				buildOptions.Releases,
-				build.baseBranch,
-			) {
-				log.Infof("Skipping build for %s (not in --release)", build.baseBranch)
-				continue
-			}
-			if err := triggerClientBuild(log, conf, &build, prRequest, buildOptions); err != nil {
-				log.Errorf("Could not start build: %s", err.Error())
-			}
+		commandErr = waiveClientPipeline(ctx, log, githubClient, conf, prRequest,
+			comment.Sender.GetLogin(), parseWaiveReason(commentBody))
+		return commandErr
+	case strings.Contains(commentBody, commandPlanClientPipeline):
+		command = commandPlanClientPipeline
+		buildOptions, err := parseBuildOptions(commentBody)
+		prRequest := &github.PullRequestEvent{
+			Repo:        comment.GetRepo(),
+			Number:      github.Int(pr.GetNumber()),
+			PullRequest: pr,
 		}
+		if err != nil {
+			_ = say(ctx, "There was an error while parsing arguments: {{.ErrorMessage}}",
+				struct {
+					ErrorMessage string
+				}{
+					ErrorMessage: err.Error(),
+				},
+				log,
+				conf,
+				prRequest)
+			commandErr = err
+			return err
+		}
+		commandErr = planClientPipelines(log, githubClient, conf, prRequest, buildOptions)
+		return commandErr
 	case strings.Contains(commentBody, commandCherryPickBranch):
+		command = commandCherryPickBranch
 		log.Infof("Attempting to cherry-pick the changes in PR: %s/%d",
 			comment.GetRepo().GetName(),
 			pr.GetNumber(),
@@ -223,19 +193,23 @@ func processGitHubComment(
 		if err != nil {
 			log.Error(err)
 		}
+		commandErr = err
 	case strings.Contains(commentBody, commandConventionalCommit) &&
-		strings.Contains(pr.GetUser().GetLogin(), "dependabot"):
+		isConventionalCommitBot(conf, pr.GetUser().GetLogin()):
+		command = commandConventionalCommit
 		log.Infof(
-			"Attempting to make the PR: %s/%d and commit: %s a conventional commit",
+			"Attempting to make the commits of the PR: %s/%d up to: %s conventional commits",
 			comment.GetRepo().GetName(),
 			pr.GetNumber(),
 			pr.GetHead().GetSHA(),
 		)
-		err = conventionalComittifyDependabotPr(log, comment, pr, conf, commentBody, githubClient)
+		err = conventionalComittifyBotPr(log, comment, pr, conf, commentBody, githubClient)
 		if err != nil {
 			log.Error(err)
 		}
+		commandErr = err
 	case strings.Contains(commentBody, commandStartReviewApp):
+		command = commandStartReviewApp
 		prRequest := &github.PullRequestEvent{
 			Repo:        comment.GetRepo(),
 			Number:      github.Int(pr.GetNumber()),
@@ -247,12 +221,14 @@ func processGitHubComment(
 			log, conf, prRequest, sender, enterprise, githubClient,
 		); err != nil {
 			log.Errorf("Could not start review deploy: %s", err.Error())
+			commandErr = err
 			errBody := fmt.Sprintf("Failed to start review app deploy: %s", err.Error())
 			errComment := github.IssueComment{Body: &errBody}
 			_ = githubClient.CreateComment(ctx, conf.githubOrganization,
 				comment.GetRepo().GetName(), pr.GetNumber(), &errComment)
 		}
 	case strings.Contains(commentBody, commandStartReviewTests):
+		command = commandStartReviewTests
 		prRequest := &github.PullRequestEvent{
 			Repo:        comment.GetRepo(),
 			Number:      github.Int(pr.GetNumber()),
@@ -264,15 +240,21 @@ func processGitHubComment(
 		)
 		if err != nil {
 			log.Errorf("Could not start review e2e tests: %s", err.Error())
+			commandErr = err
 			errBody := fmt.Sprintf("Failed to start review e2e tests: %s", err.Error())
 			errComment := github.IssueComment{Body: &errBody}
 			_ = githubClient.CreateComment(ctx, conf.githubOrganization,
 				comment.GetRepo().GetName(), pr.GetNumber(), &errComment)
 		}
 	case strings.Contains(commentBody, commandSyncRepos):
+		command = commandSyncRepos
 		syncPRBranch(ctx, comment, pr, log, conf)
 	case strings.Contains(commentBody, commandPrintFullPRStats) ||
 		strings.Contains(commentBody, commandPrintPRStats):
+		command = commandPrintPRStats
+		if strings.Contains(commentBody, commandPrintFullPRStats) {
+			command = commandPrintFullPRStats
+		}
 		handlePRStatsCommand(ctx, comment, pr, githubClient, conf, log, commentBody)
 	default:
 		log.Warnf("no command found: %s", commentBody)
@@ -282,6 +264,113 @@ func processGitHubComment(
 	return nil
 }
 
+// startClientPipelines starts the client pipelines for the pull request, as
+// requested by the "start client pipeline" command
+func startClientPipelines(
+	ctx *gin.Context,
+	log *logrus.Entry,
+	githubClient clientgithub.Client,
+	conf *config,
+	prRequest *github.PullRequestEvent,
+	buildOptions *BuildOptions,
+) error {
+	buildOptions = getPullRequestOptions(prRequest.GetPullRequest()).getBuildOptions(buildOptions)
+
+	// Logic for protected pipeline when we have --pr integration/xxx; a
+	// branch of integration is used as it is
+	integrationRev, hasIntegration := buildOptions.PullRequests["integration"]
+	integrationPRNum, isIntegrationPR := pullRequestRevisionNumber(integrationRev)
+	if hasIntegration && !isIntegrationPR {
+		log.Infof("integration revision %s is not a pull request, not syncing it", integrationRev)
+	}
+	if isIntegrationPR {
+		// Get the PR from integration
+		integrationPR, err := githubClient.GetPullRequest(
+			ctx,
+			conf.githubOrganization,
+			"integration",
+			integrationPRNum,
+		)
+		if err != nil {
+			log.Errorf("Unable to retrieve the pull request: %s", err.Error())
+			return err
+		}
+
+		// Create the PR request
+		integrationPRRequest := &github.PullRequestEvent{
+			Repo: &github.Repository{
+				Name: github.String("integration"),
+			},
+			Number:      github.Int(integrationPRNum),
+			PullRequest: integrationPR,
+		}
+
+		build := getIntegrationBuild(log, conf, integrationPRRequest)
+
+		_, err = syncProtectedBranch(log, integrationPRRequest, conf, integrationPipelinePath)
+		if err != nil {
+			_ = say(ctx, "There was an error while syncing branhces: {{.ErrorMessage}}",
+				struct {
+					ErrorMessage string
+				}{
+					ErrorMessage: err.Error(),
+				},
+				log,
+				conf,
+				prRequest) // Report failure at the comment repo
+			return err
+		}
+
+		err = triggerIntegrationBuild(log, conf, &build, integrationPRRequest, buildOptions)
+		if err != nil {
+			log.Errorf("Could not start build: %s", err.Error())
+		}
+	}
+
+	triggerClientBuilds(log, conf, prRequest, buildOptions)
+	return nil
+}
+
+// pullRequestRevisionNumber returns the number of the pull request of a
+// pull/N/head revision; false for the other revisions, e.g. branches
+func pullRequestRevisionNumber(revision string) (int, bool) {
+	parts := strings.Split(revision, "/")
+	if len(parts) != 3 || parts[0] != "pull" || parts[2] != "head" {
+		return 0, false
+	}
+	number, err := strconv.Atoi(parts[1])
+	return number, err == nil
+}
+
+// triggerClientBuilds starts the client builds of the pull request, for the
+// releases of the build options if given
+func triggerClientBuilds(
+	log *logrus.Entry,
+	conf *config,
+	prRequest *github.PullRequestEvent,
+	buildOptions *BuildOptions,
+) {
+	builds := parseClientPullRequest(log, conf, "opened", prRequest)
+	log.Infof(
+		"%s:%d will trigger %d builds",
+		prRequest.GetRepo().GetName(),
+		prRequest.GetNumber(),
+		len(builds),
+	)
+
+	// start the builds
+	for idx, build := range builds {
+		log.Infof("%d: "+spew.Sdump(build)+"\n", idx+1)
+		if reason := skipClientBuildReason(&build, buildOptions); reason != "" {
+			log.Infof("Skipping build for %s:%s (%s)", build.repo, build.baseBranch, reason)
+			continue
+		}
+		if err := triggerClientBuild(log, conf, &build, prRequest, buildOptions); err != nil {
+			log.Errorf("Could not start build: %s", err.Error())
+		}
+	}
+}
+
 func protectBranch(
 	client clientgitlab.Client, branchName string, pipelinePath string,
 ) error {
@@ -340,11 +429,7 @@ func syncProtectedBranch(
 	conf *config,
 	pipelinePath string,
 ) (string, error) {
-	client, err := clientgitlab.NewGitLabClient(
-		conf.gitlabToken,
-		conf.gitlabBaseURL,
-		conf.dryRunMode,
-	)
+	client, err := newGitLabClient(traceContext(log), conf)
 	if err != nil {
 		return "", err
 	}
@@ -520,33 +605,17 @@ func parseBuildOptions(commentBody string) (*BuildOptions, error) {
 	for id, word := range words {
 		if word == "--pr" && id < (tokensCount-1) {
 			userInput := strings.TrimSpace(words[id+1])
-			userInputParts := strings.Split(userInput, "/")
-
 			if len(userInput) > 0 {
-				var revision string
-				switch len(userInputParts) {
-				case 2: // we can have both deviceauth/1 and mender/3.1.x syntax
-					// repo/<pr_number> syntax
-					if _, err := strconv.Atoi(userInputParts[1]); err == nil {
-						revision = "pull/" + userInputParts[1] + "/head"
-					} else {
-						// feature branch
-						revision = userInputParts[1]
-					}
-				case 3: // deviceconnect/pull/12 syntax
-					revision = strings.Join(userInputParts[1:], "/") + "/head"
-				case 4: // deviceauth/pull/1/head syntax
-					revision = strings.Join(userInputParts[1:], "/")
-				default:
-					err = errors.New(
-						"parse error near '" + userInput + "', I need, e.g.: start client" +
-							" pipeline --pr somerepo/pull/12/head --pr somerepo/1.0.x ",
-					)
+				repo, revision, parseErr := parsePullRequestRevision(userInput)
+				if parseErr != nil {
+					err = parseErr
 				}
-				buildOptions.PullRequests[userInputParts[0]] = revision
+				buildOptions.PullRequests[repo] = revision
 			}
 		} else if word == "--fast" {
 			buildOptions.Fast = true
+		} else if word == "--no-supersede" {
+			buildOptions.NoSupersede = true
 		} else if word == "--release" && id < (tokensCount-1) {
 			buildOptions.Releases = append(buildOptions.Releases, strings.TrimSpace(words[id+1]))
 		}
@@ -555,6 +624,32 @@ func parseBuildOptions(commentBody string) (*BuildOptions, error) {
 	return buildOptions, err
 }
 
+// parsePullRequestRevision parses a `--pr` option, e.g. mender-connect/255,
+// into the repository and the revision to build
+func parsePullRequestRevision(userInput string) (repo string, revision string, err error) {
+	userInputParts := strings.Split(userInput, "/")
+	switch len(userInputParts) {
+	case 2: // we can have both deviceauth/1 and mender/3.1.x syntax
+		// repo/<pr_number> syntax
+		if _, err := strconv.Atoi(userInputParts[1]); err == nil {
+			revision = "pull/" + userInputParts[1] + "/head"
+		} else {
+			// feature branch
+			revision = userInputParts[1]
+		}
+	case 3: // deviceconnect/pull/12 syntax
+		revision = strings.Join(userInputParts[1:], "/") + "/head"
+	case 4: // deviceauth/pull/1/head syntax
+		revision = strings.Join(userInputParts[1:], "/")
+	default:
+		err = errors.New(
+			"parse error near '" + userInput + "', I need, e.g.: start client" +
+				" pipeline --pr somerepo/pull/12/head --pr somerepo/1.0.x ",
+		)
+	}
+	return userInputParts[0], revision, err
+}
+
 func parseReviewAppEnterprise(commentBody string) (isEnterprise bool) {
 	idx := strings.Index(commentBody, commandStartReviewApp)
 	if idx < 0 {
@@ -762,6 +762,13 @@ func TestParseBuildOptions(t *testing.T) {
 				Releases: []string{"6.0.x"},
 			},
 		},
+		"start client pipeline with --no-supersede": {
+			StartPipelineComment: "start client pipeline --no-supersede",
+			BuildOptions: &BuildOptions{
+				PullRequests: map[string]string{},
+				NoSupersede:  true,
+			},
+		},
 	}
 
 	for name, tc := range testCases {
@@ -884,3 +891,37 @@ func TestSyncProtectedBranchWithClientPushFailurePropagated(t *testing.T) {
 
 	assert.ErrorContains(t, err, "git push failed")
 }
+
+
+func TestPullRequestRevisionNumber(t *testing.T) {
+	testCases := map[string]struct {
+		number int
+		ok     bool
+	}{
+		"pull/12/head":   {number: 12, ok: true},
+		"feature-x":      {},
+		"feature/x":      {},
+		"pull/x/head":    {},
+		"pull/12/merge":  {},
+		"pull/12/head/x": {},
+		"":               {},
+	}
+	for revision, tc := range testCases {
+		t.Run(revision, func(t *testing.T) {
+			number, ok := pullRequestRevisionNumber(revision)
+			assert.Equal(t, tc.number, number)
+			assert.Equal(t, tc.ok, ok)
+		})
+	}
+}
+
+func TestStartClientPipelinesIntegrationBranch(t *testing.T) {
+	env := newE2EEnvironment(t)
+	pr := env.pullRequestEvent("synchronize", 7)
+	pr.PullRequest.Title = github.String("[with:integration/feature-x] fix: a bug")
+
+	err := startClientPipelines(&gin.Context{}, getCustomLoggerFromContext(&gin.Context{}),
+		githubClient, env.conf, pr, NewBuildOptions())
+	assert.NoError(t, err)
+	assert.Empty(t, env.gitlab.Pipelines(integrationPipelinePath))
+}
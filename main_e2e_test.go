@@ -0,0 +1,378 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v28/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	clientgithub "github.com/mendersoftware/integration-test-runner/client/github"
+	"github.com/mendersoftware/integration-test-runner/git"
+	"github.com/mendersoftware/integration-test-runner/testing/fakegit"
+	"github.com/mendersoftware/integration-test-runner/testing/fakegithub"
+	"github.com/mendersoftware/integration-test-runner/testing/fakegitlab"
+)
+
+// e2eEnvironment wires the webhook handlers to fake GitHub and GitLab
+// servers and to local git remotes, so whole flows run without network
+type e2eEnvironment struct {
+	github *fakegithub.Server
+	gitlab *fakegitlab.Server
+	remote *fakegit.Remote
+	conf   *config
+}
+
+func newE2EEnvironment(t *testing.T) *e2eEnvironment {
+	env := &e2eEnvironment{
+		github: fakegithub.NewServer(),
+		gitlab: fakegitlab.NewServer(),
+		remote: fakegit.NewRemote(t),
+	}
+	t.Cleanup(env.github.Close)
+	t.Cleanup(env.gitlab.Close)
+
+	env.conf = &config{
+		githubProtocol:       gitProtocolHTTP,
+		githubOrganization:   "cfengine",
+		githubToken:          "github-token",
+		githubBaseURL:        env.github.BaseURL(),
+		gitlabToken:          "gitlab-token",
+		gitlabBaseURL:        env.gitlab.BaseURL(),
+		integrationDirectory: t.TempDir(),
+	}
+
+	git.SetDryRunMode(false)
+	client, err := clientgithub.NewGitHubClientWithBaseURL(
+		env.conf.githubToken,
+		env.conf.githubBaseURL,
+		false,
+	)
+	require.NoError(t, err)
+	previousClient := githubClient
+	githubClient = client
+	t.Cleanup(func() { githubClient = previousClient })
+
+	return env
+}
+
+// pullRequestRef returns the ref of the head of a pull request in the GitHub
+// remote
+func pullRequestRef(number int) string {
+	return fmt.Sprintf("refs/pull/%d/head", number)
+}
+
+func (env *e2eEnvironment) pullRequestEvent(action string, number int) *github.PullRequestEvent {
+	return &github.PullRequestEvent{
+		Action: github.String(action),
+		Number: github.Int(number),
+		Organization: &github.Organization{
+			Login: github.String("cfengine"),
+		},
+		Repo: &github.Repository{
+			Name:     github.String("core"),
+			FullName: github.String("cfengine/core"),
+		},
+		Sender: &github.User{
+			Login: github.String("alice"),
+		},
+		PullRequest: &github.PullRequest{
+			Number: github.Int(number),
+			Title:  github.String("fix: a bug"),
+			Merged: github.Bool(false),
+			Head: &github.PullRequestBranch{
+				Ref: github.String("fix-bug"),
+				SHA: github.String(env.remote.RefSHA(
+					env.remote.GitHubRepo("cfengine", "core"),
+					pullRequestRef(number),
+				)),
+				Repo: &github.Repository{FullName: github.String("alice/core")},
+			},
+			Base: &github.PullRequestBranch{
+				Ref:   github.String("master"),
+				Label: github.String("cfengine:master"),
+			},
+		},
+	}
+}
+
+func TestE2EPushSyncsBranch(t *testing.T) {
+	env := newE2EEnvironment(t)
+	githubRepo := env.remote.GitHubRepo("cfengine", "core")
+	gitlabRepo := env.remote.GitLabRepo("Northern.tech/CFEngine/core")
+	env.remote.Init(githubRepo)
+	env.remote.Init(gitlabRepo)
+	sha := env.remote.Commit(githubRepo, "master", "README.md", "core", "initial commit")
+
+	err := processGitHubPush(&gin.Context{}, &github.PushEvent{
+		Ref: github.String("refs/heads/master"),
+		Repo: &github.PushEventRepository{
+			Name:         github.String("core"),
+			Organization: github.String("cfengine"),
+		},
+	}, githubClient, env.conf)
+	assert.NoError(t, err)
+	assert.Equal(t, sha, env.remote.RefSHA(gitlabRepo, "refs/heads/master"))
+}
+
+func TestE2EWebhookTraced(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previousProvider) })
+
+	env := newE2EEnvironment(t)
+	env.conf.isProcessPushEvents = true
+	githubRepo := env.remote.GitHubRepo("cfengine", "core")
+	env.remote.Init(githubRepo)
+	env.remote.Init(env.remote.GitLabRepo("Northern.tech/CFEngine/core"))
+	env.remote.Commit(githubRepo, "master", "README.md", "core", "initial commit")
+
+	err := processGitHubWebhook(newCommandContext("delivery-1"), "push", &github.PushEvent{
+		Ref: github.String("refs/heads/master"),
+		Repo: &github.PushEventRepository{
+			Name:         github.String("core"),
+			Organization: github.String("cfengine"),
+		},
+	}, githubClient, env.conf)
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.NotEmpty(t, spans)
+	root := spans[len(spans)-1]
+	assert.Equal(t, "webhook push", root.Name())
+	assert.Contains(t, root.Attributes(), attribute.String("github.delivery", "delivery-1"))
+	names := []string{}
+	for _, span := range spans[:len(spans)-1] {
+		assert.Equal(t, root.SpanContext().SpanID(), span.Parent().SpanID(), span.Name())
+		names = append(names, span.Name())
+	}
+	assert.Equal(t, []string{
+		"git init", "git remote", "git remote", "git fetch", "git checkout", "git push",
+	}, names)
+}
+
+func TestE2EPullRequestOpened(t *testing.T) {
+	env := newE2EEnvironment(t)
+	githubRepo := env.remote.GitHubRepo("cfengine", "core")
+	gitlabRepo := env.remote.GitLabRepo("Northern.tech/CFEngine/core")
+	env.remote.Init(githubRepo)
+	env.remote.Init(gitlabRepo)
+	env.remote.Commit(githubRepo, "master", "README.md", "core", "initial commit")
+	sha := env.remote.Commit(githubRepo, pullRequestRef(7), "README.md", "fixed", "fix: a bug")
+	env.github.AddMember("cfengine", "alice")
+
+	pr := env.pullRequestEvent("opened", 7)
+	err := processGitHubPullRequest(&gin.Context{}, pr, githubClient, env.conf)
+	assert.NoError(t, err)
+
+	assert.Equal(t, sha, env.remote.RefSHA(gitlabRepo, "refs/heads/pr_7"))
+	pipelines := env.gitlab.Pipelines("Northern.tech/CFEngine/core")
+	require.Len(t, pipelines, 1)
+	assert.Equal(t, "pr_7", pipelines[0].Ref)
+	variables := map[string]string{}
+	for _, variable := range pipelines[0].Variables {
+		variables[variable.Key] = variable.Value
+	}
+	assert.Equal(t, "7", variables["CI_EXTERNAL_PULL_REQUEST_IID"])
+	assert.Equal(t, sha, variables["CI_EXTERNAL_PULL_REQUEST_SOURCE_BRANCH_SHA"])
+	assert.Equal(t, "master", variables["CI_EXTERNAL_PULL_REQUEST_TARGET_BRANCH_NAME"])
+	assert.Empty(t, env.github.Comments("cfengine", "core", 7))
+}
+
+func TestE2EPullRequestOpenedNoCI(t *testing.T) {
+	env := newE2EEnvironment(t)
+	githubRepo := env.remote.GitHubRepo("cfengine", "core")
+	gitlabRepo := env.remote.GitLabRepo("Northern.tech/CFEngine/core")
+	env.remote.Init(githubRepo)
+	env.remote.Init(gitlabRepo)
+	sha := env.remote.Commit(githubRepo, pullRequestRef(7), "README.md", "fixed", "fix: a bug")
+
+	pr := env.pullRequestEvent("opened", 7)
+	pr.PullRequest.Title = github.String("[NoCI] fix: a bug")
+	err := processGitHubPullRequest(&gin.Context{}, pr, githubClient, env.conf)
+	assert.NoError(t, err)
+
+	assert.Equal(t, sha, env.remote.RefSHA(gitlabRepo, "refs/heads/pr_7"))
+	assert.Empty(t, env.gitlab.Pipelines("Northern.tech/CFEngine/core"))
+}
+
+func TestE2EPullRequestClosed(t *testing.T) {
+	env := newE2EEnvironment(t)
+	env.remote.Init(env.remote.GitHubRepo("cfengine", "core"))
+	env.gitlab.AddBranch("Northern.tech/CFEngine/core", "master")
+	env.gitlab.AddBranch("Northern.tech/CFEngine/core", "pr_7")
+
+	pr := env.pullRequestEvent("closed", 7)
+	err := processGitHubPullRequest(&gin.Context{}, pr, githubClient, env.conf)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"master"}, env.gitlab.Branches("Northern.tech/CFEngine/core"))
+}
+
+func TestE2EPullRequestMergeRequest(t *testing.T) {
+	env := newE2EEnvironment(t)
+	env.conf.gitlabMergeRequests = true
+	githubRepo := env.remote.GitHubRepo("cfengine", "core")
+	gitlabRepo := env.remote.GitLabRepo("Northern.tech/CFEngine/core")
+	env.remote.Init(githubRepo)
+	env.remote.Init(gitlabRepo)
+	env.remote.Commit(githubRepo, "master", "README.md", "core", "initial commit")
+	env.remote.Commit(githubRepo, pullRequestRef(7), "README.md", "fixed", "fix: a bug")
+	env.github.AddMember("cfengine", "alice")
+	const path = "Northern.tech/CFEngine/core"
+
+	pr := env.pullRequestEvent("opened", 7)
+	pr.PullRequest.Body = github.String("Fixes the bug.")
+	pr.PullRequest.Labels = []*github.Label{{Name: github.String("bug")}}
+	err := processGitHubPullRequest(&gin.Context{}, pr, githubClient, env.conf)
+	assert.NoError(t, err)
+
+	mergeRequests := env.gitlab.MergeRequests(path)
+	require.Len(t, mergeRequests, 1)
+	assert.Equal(t, "fix: a bug", mergeRequests[0].Title)
+	assert.Equal(t, "pr_7", mergeRequests[0].SourceBranch)
+	assert.Equal(t, "master", mergeRequests[0].TargetBranch)
+	assert.Equal(t, "Fixes the bug.\n\n---\n\nMirror of https://github.com/cfengine/core/pull/7",
+		mergeRequests[0].Description)
+	assert.Equal(t, gitlab.Labels{"bug"}, mergeRequests[0].Labels)
+	pipelines := env.gitlab.Pipelines(path)
+	require.Len(t, pipelines, 1)
+	assert.Equal(t, "merge_request_event", string(pipelines[0].Source))
+	assert.Equal(t, "refs/merge-requests/1/head", pipelines[0].Ref)
+
+	pr = env.pullRequestEvent("edited", 7)
+	pr.PullRequest.Title = github.String("fix: the bug")
+	err = processGitHubPullRequest(&gin.Context{}, pr, githubClient, env.conf)
+	assert.NoError(t, err)
+	pr = env.pullRequestEvent("synchronize", 7)
+	pr.PullRequest.Title = github.String("fix: the bug")
+	err = processGitHubPullRequest(&gin.Context{}, pr, githubClient, env.conf)
+	assert.NoError(t, err)
+
+	mergeRequests = env.gitlab.MergeRequests(path)
+	require.Len(t, mergeRequests, 1)
+	assert.Equal(t, "fix: the bug", mergeRequests[0].Title)
+	assert.Empty(t, mergeRequests[0].Labels)
+	assert.Len(t, env.gitlab.Pipelines(path), 2)
+
+	env.gitlab.AddBranch(path, "pr_7")
+	err = processGitHubPullRequest(&gin.Context{}, env.pullRequestEvent("closed", 7),
+		githubClient, env.conf)
+	assert.NoError(t, err)
+	mergeRequests = env.gitlab.MergeRequests(path)
+	require.Len(t, mergeRequests, 1)
+	assert.Equal(t, "closed", mergeRequests[0].State)
+	assert.Empty(t, env.gitlab.Branches(path))
+}
+
+func TestE2EMergeRequestCommentCrossPosted(t *testing.T) {
+	env := newE2EEnvironment(t)
+
+	comment := &gitlab.MergeCommentEvent{
+		User: &gitlab.EventUser{Username: "bob"},
+		Project: gitlab.MergeCommentEventProject{
+			PathWithNamespace: "Northern.tech/CFEngine/core",
+		},
+		ObjectAttributes: gitlab.MergeCommentEventObjectAttributes{
+			Note:         "The tests fail on arm.\nCan you have a look?",
+			NoteableType: "MergeRequest",
+			URL:          "https://gitlab.com/Northern.tech/CFEngine/core/-/merge_requests/1#note_1",
+		},
+		MergeRequest: gitlab.MergeCommentEventMergeRequest{
+			IID:          1,
+			SourceBranch: "pr_7",
+			Description:  "Mirror of https://github.com/cfengine/core/pull/7",
+		},
+	}
+	err := processGitLabMergeRequestComment(newCommandContext("note-1"), comment,
+		githubClient, env.conf)
+	require.NoError(t, err)
+
+	comments := env.github.Comments("cfengine", "core", 7)
+	require.Len(t, comments, 1)
+	assert.Equal(t, "**bob** commented on the GitLab merge request "+
+		"[!1](https://gitlab.com/Northern.tech/CFEngine/core/-/merge_requests/1#note_1):\n\n"+
+		"> The tests fail on arm.\n> Can you have a look?", comments[0].GetBody())
+}
+
+func TestE2EPullRequestChangelogComment(t *testing.T) {
+	env := newE2EEnvironment(t)
+	githubRepo := env.remote.GitHubRepo("mendersoftware", "core")
+	env.remote.Init(githubRepo)
+	baseSHA := env.remote.Commit(githubRepo, "master", "README.md", "core", "initial commit")
+	env.remote.Git(githubRepo, "update-ref", pullRequestRef(7), baseSHA)
+	env.remote.Commit(githubRepo, pullRequestRef(7), "README.md", "fixed",
+		"fix: a bug\n\nChangelog: Fix a crash on startup\nTicket: MEN-1234")
+	headSHA := env.remote.Commit(githubRepo, pullRequestRef(7), "main.c", "tidy",
+		"chore: tidy up")
+
+	pr := env.pullRequestEvent("opened", 7)
+	pr.PullRequest.Head.SHA = github.String(headSHA)
+	pr.PullRequest.Base.SHA = github.String(baseSHA)
+	pr.PullRequest.Base.Repo = &github.Repository{
+		Name:  github.String("core"),
+		Owner: &github.User{Login: github.String("mendersoftware")},
+	}
+	log := getCustomLoggerFromContext(&gin.Context{})
+	commits, err := getPullRequestCommits(log, pr, env.conf)
+	require.NoError(t, err)
+	handleChangelogComments(log, &gin.Context{}, githubClient, pr, env.conf, commits)
+
+	comments := env.github.Comments("cfengine", "core", 7)
+	require.Len(t, comments, 1)
+	assert.Equal(t, changelogPrefix+`### Changelogs
+
+#### core (fix-bug)
+
+New changes in core since master:
+
+* Fix a crash on startup
+  ([MEN-1234](https://northerntech.atlassian.net/browse/MEN-1234))
+
+`, comments[0].GetBody())
+}
+
+func TestE2EConventionalCommitRewrite(t *testing.T) {
+	env := newE2EEnvironment(t)
+	env.conf.conventionalCommits = getConventionalCommitConfig()
+	githubRepo := env.remote.GitHubRepo("cfengine", "core")
+	env.remote.Init(githubRepo)
+	baseSHA := env.remote.Commit(githubRepo, "master", "README.md", "core", "initial commit")
+	env.remote.Git(githubRepo, "branch", "renovate/deps", baseSHA)
+	env.remote.Commit(githubRepo, "renovate/deps", "go.mod", "testify v1.9.0",
+		"chore(deps): update module testify to v1.9.0\n\nTicket: MEN-1234\n"+
+			"Signed-off-by: renovate[bot] <bot@renovateapp.com>")
+	env.remote.Commit(githubRepo, "renovate/deps", "go.sum", "testify v1.9.0",
+		"chore(deps): update go.sum\n\nSigned-off-by: renovate[bot] <bot@renovateapp.com>")
+
+	repo := &github.Repository{SSHURL: github.String("https://github.com/cfengine/core.git")}
+	pr := &github.PullRequest{
+		Number: github.Int(7),
+		User:   &github.User{Login: github.String("renovate[bot]")},
+		Head:   &github.PullRequestBranch{Ref: github.String("renovate/deps"), Repo: repo},
+		Base:   &github.PullRequestBranch{Ref: github.String("master"), Repo: repo},
+	}
+	require.True(t, isConventionalCommitBot(env.conf, pr.GetUser().GetLogin()))
+	err := attemptConventionalComittifyBotPr(getCustomLoggerFromContext(&gin.Context{}), pr,
+		env.conf, "@mender-test-bot mark-pr as fix")
+	require.NoError(t, err)
+
+	messages := env.remote.Git(githubRepo, "log", "--reverse", "--format=%B%x00",
+		"master..renovate/deps")
+	assert.Equal(t, "fix(deps): update module testify to v1.9.0\n\nTicket: MEN-1234\n"+
+		"Changelog: All\nSigned-off-by: renovate[bot] <bot@renovateapp.com>\n\x00\n"+
+		"fix(deps): update go.sum\n\nChangelog: All\nTicket: None\n"+
+		"Signed-off-by: renovate[bot] <bot@renovateapp.com>\n\x00\n", messages)
+	// the contents are unchanged
+	assert.Equal(t, "testify v1.9.0", env.remote.Git(githubRepo, "show", "renovate/deps:go.sum"))
+	assert.Equal(t, baseSHA, strings.TrimSpace(env.remote.Git(githubRepo, "rev-parse", "renovate/deps~2")))
+}
@@ -6,14 +6,15 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/go-github/v28/github"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
+	"github.com/mendersoftware/integration-test-runner/changelog"
 	clientgithub "github.com/mendersoftware/integration-test-runner/client/github"
+	"github.com/mendersoftware/integration-test-runner/git"
 )
 
 var (
@@ -27,49 +28,142 @@ var (
 
 const externalContributionLabel = "external contribution"
 
-type retryParams struct {
-	retryFunc func() error
-	compFunc  func(error) bool
+// TitleOptions are the options of a pull request, given as bracket tags
+// prefixing its title, e.g. "[NoCI] [release:6.0.x] fix: ...", or as labels
+// with the same names, e.g. "fast"
+type TitleOptions struct {
+	SkipCI             bool
+	Fast               bool
+	Releases           []string
+	PullRequests       map[string]string
+	SkipChangelogCheck bool
 }
 
 const (
-	doRetry bool = true
-	noRetry      = false
+	titleOptionSkipCI             = "noci"
+	titleOptionFast               = "fast"
+	titleOptionRelease            = "release:"
+	titleOptionWith               = "with:"
+	titleOptionSkipChangelogCheck = "no-changelog-check"
 )
 
-func retryOnError(args retryParams) error {
-	var maxBackoff int = 8 * 8
-	err := args.retryFunc()
-	i := 1
-	for i <= maxBackoff && args.compFunc(err) {
-		err = args.retryFunc()
-		i = i * 2
-		time.Sleep(time.Duration(i) * time.Second)
+func getTitleOptions(title string) (titleOptions TitleOptions) {
+	// Each option within the brackets at the start of the title
+	for strings.HasPrefix(title, "[") {
+		end := strings.Index(title, "]")
+		if end < 0 {
+			return
+		}
+		for _, option := range strings.Fields(title[1:end]) {
+			titleOptions.parse(option)
+		}
+		title = strings.TrimSpace(title[end+1:])
 	}
-	return err
+	return
 }
 
-type TitleOptions struct {
-	SkipCI bool
+// getPullRequestOptions returns the options of the pull request from both its
+// title and its labels
+func getPullRequestOptions(pr *github.PullRequest) TitleOptions {
+	options := getTitleOptions(strings.TrimSpace(pr.GetTitle()))
+	for _, label := range pr.Labels {
+		options.parse(label.GetName())
+	}
+	return options
 }
 
+// parse sets the option, the unknown ones are ignored
+func (o *TitleOptions) parse(option string) {
+	name := strings.ToLower(option)
+	switch {
+	case name == titleOptionSkipCI:
+		o.SkipCI = true
+	case name == titleOptionFast:
+		o.Fast = true
+	case name == titleOptionSkipChangelogCheck:
+		o.SkipChangelogCheck = true
+	case strings.HasPrefix(name, titleOptionRelease) && len(name) > len(titleOptionRelease):
+		o.Releases = append(o.Releases, option[len(titleOptionRelease):])
+	case strings.HasPrefix(name, titleOptionWith):
+		repo, revision, err := parsePullRequestRevision(option[len(titleOptionWith):])
+		if err == nil {
+			if o.PullRequests == nil {
+				o.PullRequests = make(map[string]string)
+			}
+			o.PullRequests[repo] = revision
+		}
+	}
+}
+
+// getBuildOptions merges the options of the pull request into the options of
+// a client pipeline: the ones of the command, if any, take precedence
+func (o TitleOptions) getBuildOptions(buildOptions *BuildOptions) *BuildOptions {
+	merged := NewBuildOptions()
+	if buildOptions != nil {
+		*merged = *buildOptions
+		merged.PullRequests = make(map[string]string)
+		for repo, revision := range buildOptions.PullRequests {
+			merged.PullRequests[repo] = revision
+		}
+	}
+	merged.Fast = merged.Fast || o.Fast
+	if len(merged.Releases) == 0 {
+		merged.Releases = o.Releases
+	}
+	for repo, revision := range o.PullRequests {
+		if _, exists := merged.PullRequests[repo]; !exists {
+			merged.PullRequests[repo] = revision
+		}
+	}
+	return merged
+}
+
+// clientPipelineLabelAction is what a pull request event does to the client
+// pipelines of a pull request opting in with the client pipeline label
+type clientPipelineLabelAction int
+
 const (
-	titleOptionSkipCI = "noci"
+	clientPipelineLabelNone clientPipelineLabelAction = iota
+	clientPipelineLabelStart
+	clientPipelineLabelStop
 )
 
-func getTitleOptions(title string) (titleOptions TitleOptions) {
-	start, end := strings.Index(title, "["), strings.Index(title, "]")
-	// First character must be '['
-	if start != 0 || end < start {
-		return
+func hasPullRequestLabel(pr *github.PullRequest, name string) bool {
+	for _, label := range pr.Labels {
+		if label.GetName() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// getClientPipelineLabelAction returns whether the event starts the client
+// pipelines, on every push to a pull request carrying the client pipeline
+// label and when the label is added, or stops them, when it is removed
+func getClientPipelineLabelAction(
+	conf *config,
+	pr *github.PullRequestEvent,
+	options TitleOptions,
+) clientPipelineLabelAction {
+	label := conf.clientPipelineLabel
+	if label == "" {
+		return clientPipelineLabelNone
 	}
-	for _, option := range strings.Fields(title[start+1 : end]) {
-		switch strings.ToLower(option) {
-		case titleOptionSkipCI:
-			titleOptions.SkipCI = true
+	switch pr.GetAction() {
+	case "synchronize":
+		if !options.SkipCI && hasPullRequestLabel(pr.GetPullRequest(), label) {
+			return clientPipelineLabelStart
+		}
+	case "labeled":
+		if !options.SkipCI && pr.GetLabel().GetName() == label {
+			return clientPipelineLabelStart
+		}
+	case "unlabeled":
+		if pr.GetLabel().GetName() == label {
+			return clientPipelineLabelStop
 		}
 	}
-	return
+	return clientPipelineLabelNone
 }
 
 func processGitHubPullRequest(
@@ -98,8 +192,7 @@ func processGitHubPullRequest(
 		)
 		return nil
 	}
-	title := strings.TrimSpace(req.GetTitle())
-	options := getTitleOptions(title)
+	options := getPullRequestOptions(req)
 
 	log.Debugf("Processing pull request action %s", action)
 	switch action {
@@ -121,26 +214,25 @@ func processGitHubPullRequest(
 					pr.Sender.GetLogin(),
 				)
 			}
-			if !options.SkipCI {
-				err = retryOnError(retryParams{
-					retryFunc: func() error {
-						return startPRPipeline(log, prBranchName, pr, conf, isOrgMember)
-					},
-					compFunc: func(compareError error) bool {
-						re := regexp.MustCompile("Missing CI config file|" +
-							"No stages / jobs for this pipeline")
-						switch {
-						case compareError == nil:
-							return noRetry
-						case re.MatchString(compareError.Error()):
-							log.Infof("start client pipeline for PR '%d' is skipped", pr.Number)
-							return noRetry
-						default:
-							log.Errorf("failed to start client pipeline for PR: %s", compareError)
-							return doRetry
-						}
-					},
-				})
+			var mergeRequestIID int64
+			if conf.gitlabMergeRequests {
+				mergeRequestIID, err = syncMergeRequest(log, prBranchName, pr, conf)
+				if err != nil {
+					log.Errorf("failed to sync the merge request for PR: %s", err)
+				}
+			}
+			if !options.SkipCI && err == nil {
+				// the GitLab client retries the failed pipeline creation
+				err = startPRPipeline(log, prBranchName, mergeRequestIID, pr, conf, isOrgMember)
+				re := regexp.MustCompile("Missing CI config file|" +
+					"No stages / jobs for this pipeline")
+				switch {
+				case err == nil:
+				case re.MatchString(err.Error()):
+					log.Infof("start client pipeline for PR '%d' is skipped", pr.GetNumber())
+				default:
+					log.Errorf("failed to start client pipeline for PR: %s", err)
+				}
 			}
 			if err != nil {
 				msg := "There was an error running your pipeline, " + msgDetailsKubernetesLog
@@ -148,9 +240,56 @@ func processGitHubPullRequest(
 			}
 		}
 
-		handleChangelogComments(log, ctx, githubClient, pr, conf)
+		if hasClientPipelineStatus(conf, pr.GetRepo().GetName()) {
+			if err := updateClientPipelineStatus(ctx, log, githubClient, conf, pr); err != nil {
+				log.Errorf("Failed to set the client pipeline status: %s", err.Error())
+			}
+		}
+
+		// the commits are fetched once, for both the commit messages status
+		// and the changelog comment
+		commentChangelog := false
+		if options.SkipChangelogCheck {
+			log.Infof("The changelog check is disabled for PR %d", pr.GetNumber())
+		} else {
+			commentChangelog = hasChangelogComments(log, pr)
+		}
+		if conf.commitLintRules[conf.githubOrganization] != nil || commentChangelog {
+			commits, err := getPullRequestCommits(log, pr, conf)
+			if err != nil {
+				log.Errorf("Failed to get the commits of the PR: %s", err.Error())
+			} else {
+				if err := updateCommitLintStatus(ctx, log, githubClient, conf, pr,
+					commits); err != nil {
+					log.Errorf("Failed to set the commit messages status: %s", err.Error())
+				}
+				if commentChangelog {
+					handleChangelogComments(log, ctx, githubClient, pr, conf, commits)
+				}
+			}
+		}
+
+	case "edited", "labeled", "unlabeled":
+		if conf.gitlabMergeRequests {
+			if err := updateMergeRequest(log, pr, conf); err != nil {
+				log.Errorf("Failed to update the merge request of the PR: %s", err.Error())
+			}
+		}
+
+		// a backport label added after the merge backports right away
+		if action == "labeled" {
+			if err := backportPR(log, pr, githubClient, conf); err != nil {
+				log.Errorf("Failed to backport the PR: %s", err.Error())
+			}
+		}
 
 	case "closed":
+		if conf.gitlabMergeRequests {
+			if err := closeMergeRequest(log, pr, conf); err != nil {
+				log.Errorf("Failed to close the merge request of the PR: %s", err.Error())
+			}
+		}
+
 		// Delete merged pr branches in GitLab
 		if err := deleteStaleGitlabPRBranch(log, pr, conf); err != nil {
 			log.Errorf(
@@ -161,6 +300,11 @@ func processGitHubPullRequest(
 			)
 		}
 
+		// If the pr was merged, backport it to the branches of its labels
+		if err := backportPR(log, pr, githubClient, conf); err != nil {
+			log.Errorf("Failed to backport the PR: %s", err.Error())
+		}
+
 		// If the pr was merged, suggest cherry-picks
 		if err := suggestCherryPicks(log, pr, githubClient, conf); err != nil {
 			log.Errorf("Failed to suggest cherry picks for the pr %v. Error: %v", pr, err)
@@ -196,6 +340,17 @@ func processGitHubPullRequest(
 		)
 	}
 
+	switch getClientPipelineLabelAction(conf, pr, options) {
+	case clientPipelineLabelStart:
+		log.Infof("The PR has the %q label, starting the client pipelines",
+			conf.clientPipelineLabel)
+		return startClientPipelines(ctx, log, githubClient, conf, pr, NewBuildOptions())
+	case clientPipelineLabelStop:
+		log.Infof("The %q label was removed from the PR, stopping the client pipelines",
+			conf.clientPipelineLabel)
+		return stopClientBuilds(log, pr, conf)
+	}
+
 	// get the list of builds
 	builds := parseClientPullRequest(log, conf, action, pr)
 	log.Infof("%s:%d would trigger %d builds", pr.GetRepo().GetName(), pr.GetNumber(), len(builds))
@@ -227,6 +382,13 @@ func processGitHubPullRequest(
    You can prevent me from automatically starting CI pipelines:
    - if your pull request title starts with "[NoCI] ..."
 
+   You can set the options of the client pipelines of your pull request, whether started by a comment or automatically, with tags at the start of its title or with labels of the same name:
+   - ` + "`" + `[fast]` + "`" + `, like ` + "`" + `--fast` + "`" + `
+   - ` + "`" + `[release:6.0.x]` + "`" + `, like ` + "`" + `--release 6.0.x` + "`" + `
+   - ` + "`" + `[with:mender-connect/255]` + "`" + `, like ` + "`" + `--pr mender-connect/255` + "`" + `
+   - ` + "`" + `[no-changelog-check]` + "`" + ` to skip the changelog check
+   - the options of a comment take precedence
+
    You can trigger a client pipeline on multiple prs with:
    - mentioning me and ` + "`" + `start client pipeline --pr mender/127 --pr mender-connect/255` + "`" + `
 
@@ -234,6 +396,15 @@ func processGitHubPullRequest(
    - mentioning me and ` + "`" + `start client pipeline --release 6.0.x` + "`" + ` (can be given multiple times)
    - by default, a pipeline is triggered for each supported release the component is a part of` + `
 
+   You can keep the previous pipelines of the same PR and release running with:
+   - mentioning me and ` + "`" + `start client pipeline --no-supersede` + "`" + ` (also for ` + "`" + commandStartIntegrationPipeline + "`" + `)
+
+   You can preview the client pipelines, without starting them, with:
+   - mentioning me and ` + "`" + commandPlanClientPipeline + "`" + ` (same options as ` + "`" + commandStartClientPipeline + "`" + `)
+
+   You can waive the required client pipeline of your pull request, where it gates the merge, with:
+   - mentioning me and ` + "`" + commandWaiveClientPipeline + ` <reason>` + "`" + `
+
    You can trigger GitHub->GitLab branch sync with:
    - mentioning me and ` + "`" + `sync` + "`" + `
 
@@ -316,7 +487,7 @@ func getFirstMatchingBotCommentInPR(
 ) *github.IssueComment {
 
 	comments, err := githubClient.ListComments(
-		context.Background(),
+		traceContext(log),
 		conf.githubOrganization,
 		pr.GetRepo().GetName(),
 		pr.GetNumber(),
@@ -341,86 +512,94 @@ func getFirstMatchingBotCommentInPR(
 	return nil
 }
 
+// hasChangelogComments tells whether the changelog of the pull request is
+// commented on it: only for the mendersoftware repositories
+func hasChangelogComments(log *logrus.Entry, pr *github.PullRequestEvent) bool {
+	if pr.GetPullRequest().GetBase().GetRepo().GetOwner().GetLogin() != "mendersoftware" {
+		log.Info("Not a mendersoftware repository. Ignoring.")
+		return false
+	}
+	return true
+}
+
 func handleChangelogComments(
 	log *logrus.Entry,
 	ctx *gin.Context,
 	githubClient clientgithub.Client,
 	pr *github.PullRequestEvent,
 	conf *config,
+	commits []changelog.Commit,
 ) {
-	// It would be semantically correct to update the integration repo
-	// here. However, this step is carried out on every PR update, causing a
-	// big amount of "git fetch" requests, which both reduces performance,
-	// and could result in rate limiting. Instead, we assume that the
-	// integration repo is recent enough, since it is still updated when
-	// doing mender-qa builds.
-	//
-	// // First update integration repo.
-	// err := updateIntegrationRepo(conf)
-	// if err != nil {
-	// 	log.Errorf("Could not update integration repo: %s", err.Error())
-	// 	// Should still be safe to continue though.
-	// }
-
-	// Only do changelog commenting for mendersoftware repositories.
-	if pr.GetPullRequest().GetBase().GetRepo().GetOwner().GetLogin() != "mendersoftware" {
-		log.Info("Not a mendersoftware repository. Ignoring.")
-		return
-	}
-
-	changelogText, warningText, err := fetchChangelogTextForPR(log, pr, conf)
-	if err != nil {
-		log.Errorf("Error while fetching changelog text: %s", err.Error())
-		return
-	}
-
+	changelogText, warningText := fetchChangelogTextForPR(log, pr, commits)
 	updatePullRequestChangelogComments(log, ctx, githubClient, pr, conf,
 		changelogText, warningText)
 }
 
+// fetchChangelogTextForPR renders the changelog of the commits of the pull
+// request, and the warnings of their changelog entries
 func fetchChangelogTextForPR(
 	log *logrus.Entry,
 	pr *github.PullRequestEvent,
-	conf *config,
-) (string, string, error) {
-
-	repo := pr.GetPullRequest().GetBase().GetRepo().GetName()
-	baseSHA := pr.GetPullRequest().GetBase().GetSHA()
-	headSHA := pr.GetPullRequest().GetHead().GetSHA()
-	baseRef := pr.GetPullRequest().GetBase().GetRef()
-	headRef := pr.GetPullRequest().GetHead().GetRef()
-	versionRange := fmt.Sprintf(
+	commits []changelog.Commit,
+) (string, string) {
+	// Render the ref names instead of the SHAs, so that the changelog text
+	// does not change on every commit amend.
+	generated := changelog.Generate(commits)
+	changelogText := changelog.Render(generated,
+		pr.GetPullRequest().GetBase().GetRepo().GetName(),
+		pr.GetPullRequest().GetBase().GetRef(),
+		pr.GetPullRequest().GetHead().GetRef())
+	warningText := changelog.RenderWarnings(generated)
+
+	log.Debugf("Prepared changelog text: %s", changelogText)
+	log.Debugf("Got warning text: %s", warningText)
+
+	return changelogText, warningText
+}
+
+// pullRequestRange returns the range of the commits of the pull request,
+// e.g. "<base sha>..<head sha>"
+func pullRequestRange(pr *github.PullRequestEvent) string {
+	return fmt.Sprintf(
 		"%s..%s",
-		baseSHA,
-		headSHA,
+		pr.GetPullRequest().GetBase().GetSHA(),
+		pr.GetPullRequest().GetHead().GetSHA(),
 	)
+}
 
-	log.Debugf("Getting changelog for repo (%s) and range (%s)", repo, versionRange)
-
-	// Generate the changelog text for this PR.
-	changelogText, warningText, err := getChangelogText(
-		repo, versionRange, conf)
+// getPullRequestCommits returns the commits of the pull request, from its
+// head down to its base, without the merge commits
+func getPullRequestCommits(
+	log *logrus.Entry,
+	pr *github.PullRequestEvent,
+	conf *config,
+) ([]changelog.Commit, error) {
+	base := pr.GetPullRequest().GetBase()
+	repo := base.GetRepo().GetName()
+	log.Debugf("Getting the commits of repo (%s) and range (%s)", repo, pullRequestRange(pr))
+	// The head of the PR may live in a personal fork, but it is always
+	// present in the repository you are merging into as the pull/N/head ref.
+	repoURL := getRemoteURLGitHub(conf.githubProtocol, base.GetRepo().GetOwner().GetLogin(), repo)
+	// This is carried out on every PR update: a full fetch of the repository
+	// each time would both reduce performance, and could result in rate
+	// limiting. Only the commits are fetched, without their trees and
+	// blobs, which is all git log needs for the messages.
+	state, err := git.CommandsContext(traceContext(log),
+		git.Command("init", "."),
+		git.Command("remote", "add", "github", repoURL),
+		git.Command("fetch", "--no-tags", "--filter=tree:0", "github", base.GetRef(),
+			"pull/"+strconv.Itoa(pr.GetNumber())+"/head"),
+	)
+	defer state.Cleanup()
 	if err != nil {
-		err = errors.Wrap(err, "Not able to get changelog text")
+		return nil, err
 	}
-
-	// Replace SHAs with the original ref names, so that the changelog text
-	// does not change on every commit amend. The reason we did not use ref
-	// names to begin with is that they may live in personal forks, so it
-	// complicates the fetching mechanism. SHAs however, are always present
-	// in the repository you are merging into.
-	//
-	// Fetching changelogs online from personal forks is pretty unlikely to
-	// be useful outside of the integration-test-runner niche (better to use
-	// the local version), therefore we do this replacement instead of
-	// making the changelog-generator "fork aware".
-	changelogText = strings.ReplaceAll(changelogText, baseSHA, baseRef)
-	changelogText = strings.ReplaceAll(changelogText, headSHA, headRef)
-
-	log.Debugf("Prepared changelog text: %s", changelogText)
-	log.Debugf("Got warning text: %s", warningText)
-
-	return changelogText, warningText, err
+	logCmd := git.Command(changelog.LogArgs(pullRequestRange(pr))...).With(state)
+	out, err := logCmd.CombinedOutput()
+	if err != nil {
+		return nil, errors.Wrapf(err, "%v returned %s", logCmd.Args, out)
+	}
+	return changelog.ParseLog(out), nil
 }
 
 func assembleCommentText(changelogText, warningText string) string {
@@ -449,6 +449,23 @@ func TestGetTitleOptions(t *testing.T) {
 			InputTitle: "[unknown options] This is a title",
 			Output:     TitleOptions{},
 		},
+		"Multiple options": {
+			InputTitle: "[fast release:6.0.x] [with:mender-connect/255] [No-Changelog-Check] fix: a bug",
+			Output: TitleOptions{
+				Fast:               true,
+				Releases:           []string{"6.0.x"},
+				PullRequests:       map[string]string{"mender-connect": "pull/255/head"},
+				SkipChangelogCheck: true,
+			},
+		},
+		"Options after the start of the title": {
+			InputTitle: "fix: a bug [NoCI]",
+			Output:     TitleOptions{},
+		},
+		"Invalid with": {
+			InputTitle: "[with:mender-connect] This is a title",
+			Output:     TitleOptions{},
+		},
 	}
 	for name := range testCases {
 		tc := testCases[name]
@@ -459,6 +476,120 @@ func TestGetTitleOptions(t *testing.T) {
 	}
 }
 
+func TestGetPullRequestOptions(t *testing.T) {
+	pr := &github.PullRequest{
+		Title: github.String("[release:6.0.x] feat: a feature"),
+		Labels: []*github.Label{
+			{Name: github.String("fast")},
+			{Name: github.String("with:mender-connect/255")},
+			{Name: github.String("release:7.0.x")},
+			{Name: github.String("bug")},
+		},
+	}
+	options := getPullRequestOptions(pr)
+	assert.Equal(t, TitleOptions{
+		Fast:         true,
+		Releases:     []string{"6.0.x", "7.0.x"},
+		PullRequests: map[string]string{"mender-connect": "pull/255/head"},
+	}, options)
+
+	// the options of the command take precedence
+	commandOptions, err := parseBuildOptions(
+		"start client pipeline --pr mender-connect/300 --pr mender/12 --release 5.0.x")
+	require.NoError(t, err)
+	assert.Equal(t, &BuildOptions{
+		Fast: true,
+		PullRequests: map[string]string{
+			"mender-connect": "pull/300/head",
+			"mender":         "pull/12/head",
+		},
+		Releases: []string{"5.0.x"},
+	}, options.getBuildOptions(commandOptions))
+	assert.Equal(t, map[string]string{
+		"mender-connect": "pull/300/head",
+		"mender":         "pull/12/head",
+	}, commandOptions.PullRequests)
+
+	assert.Equal(t, &BuildOptions{
+		Fast:         true,
+		PullRequests: map[string]string{"mender-connect": "pull/255/head"},
+		Releases:     []string{"6.0.x", "7.0.x"},
+	}, options.getBuildOptions(nil))
+}
+
+func TestGetClientPipelineLabelAction(t *testing.T) {
+	const label = "ci:client-pipeline"
+	event := func(action string, event string, labels ...string) *github.PullRequestEvent {
+		pr := &github.PullRequestEvent{
+			Action:      github.String(action),
+			PullRequest: &github.PullRequest{},
+		}
+		if event != "" {
+			pr.Label = &github.Label{Name: github.String(event)}
+		}
+		for _, name := range labels {
+			pr.PullRequest.Labels = append(pr.PullRequest.Labels,
+				&github.Label{Name: github.String(name)})
+		}
+		return pr
+	}
+	testCases := map[string]struct {
+		label   string
+		pr      *github.PullRequestEvent
+		options TitleOptions
+		action  clientPipelineLabelAction
+	}{
+		"push with the label": {
+			label:  label,
+			pr:     event("synchronize", "", "bug", label),
+			action: clientPipelineLabelStart,
+		},
+		"push without the label": {
+			label:  label,
+			pr:     event("synchronize", "", "bug"),
+			action: clientPipelineLabelNone,
+		},
+		"push with the label and NoCI": {
+			label:   label,
+			pr:      event("synchronize", "", label),
+			options: TitleOptions{SkipCI: true},
+			action:  clientPipelineLabelNone,
+		},
+		"label added": {
+			label:  label,
+			pr:     event("labeled", label, label),
+			action: clientPipelineLabelStart,
+		},
+		"other label added": {
+			label:  label,
+			pr:     event("labeled", "bug", "bug", label),
+			action: clientPipelineLabelNone,
+		},
+		"label removed": {
+			label:   label,
+			pr:      event("unlabeled", label),
+			options: TitleOptions{SkipCI: true},
+			action:  clientPipelineLabelStop,
+		},
+		"opened with the label": {
+			label:  label,
+			pr:     event("opened", "", label),
+			action: clientPipelineLabelNone,
+		},
+		"no label configured": {
+			pr:     event("synchronize", "", label),
+			action: clientPipelineLabelNone,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			action := getClientPipelineLabelAction(&config{clientPipelineLabel: tc.label},
+				tc.pr, tc.options)
+			assert.Equal(t, tc.action, action)
+		})
+	}
+}
+
 func TestLabelPR(t *testing.T) {
 	conf := &config{githubOrganization: "mendersoftware"}
 	pr := &github.PullRequestEvent{
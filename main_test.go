@@ -3,6 +3,11 @@ package main
 import (
 	"flag"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	clientgitlab "github.com/mendersoftware/integration-test-runner/client/gitlab"
 )
 
 var runAcceptanceTests bool
@@ -23,3 +28,43 @@ func TestRunMain(t *testing.T) {
 	}
 	doMain()
 }
+
+func TestGetGitLabRetryPolicy(t *testing.T) {
+	policy, err := getGitLabRetryPolicy()
+	assert.NoError(t, err)
+	assert.Equal(t, clientgitlab.DefaultRetryPolicy().MaxAttempts, policy.MaxAttempts)
+
+	t.Setenv("GITLAB_RETRY_MAX_ATTEMPTS", "2")
+	t.Setenv("GITLAB_RETRY_BASE_DELAY", "500ms")
+	t.Setenv("GITLAB_RETRY_MAX_DELAY", "10s")
+	policy, err = getGitLabRetryPolicy()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, policy.MaxAttempts)
+	assert.Equal(t, 500*time.Millisecond, policy.BaseDelay)
+	assert.Equal(t, 10*time.Second, policy.MaxDelay)
+	assert.NotNil(t, policy.Retryable)
+
+	t.Setenv("GITLAB_RETRY_MAX_ATTEMPTS", "0")
+	_, err = getGitLabRetryPolicy()
+	assert.EqualError(t, err, `invalid GITLAB_RETRY_MAX_ATTEMPTS: "0"`)
+
+	t.Setenv("GITLAB_RETRY_MAX_ATTEMPTS", "")
+	t.Setenv("GITLAB_RETRY_MAX_DELAY", "soon")
+	_, err = getGitLabRetryPolicy()
+	assert.EqualError(t, err, `invalid GITLAB_RETRY_MAX_DELAY: "soon"`)
+}
+
+func TestGetPipelineLimits(t *testing.T) {
+	limits, err := getPipelineLimits()
+	assert.NoError(t, err)
+	assert.Empty(t, limits)
+
+	t.Setenv("CLIENT_PIPELINE_MAX_CONCURRENT", "4")
+	limits, err = getPipelineLimits()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{clientPipelinePath: 4}, limits)
+
+	t.Setenv("INTEGRATION_PIPELINE_MAX_CONCURRENT", "none")
+	_, err = getPipelineLimits()
+	assert.EqualError(t, err, `invalid INTEGRATION_PIPELINE_MAX_CONCURRENT: "none"`)
+}
@@ -2,8 +2,8 @@ package main
 
 import (
 	"bytes"
-	"context"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"text/template"
@@ -49,8 +49,8 @@ func getClientBuilds(log *logrus.Entry, conf *config, pr *github.PullRequestEven
 
 	makeQEMU := false
 
-	// we need to have the latest integration/master branch in order to use the release_tool.py
-	if err := updateIntegrationRepo(conf); err != nil {
+	// we need to have the latest integration branches in order to read their versions
+	if err := updateIntegrationRepo(traceContext(log), conf); err != nil {
 		log.Warn(err.Error())
 	}
 
@@ -86,7 +86,7 @@ func getClientBuilds(log *logrus.Entry, conf *config, pr *github.PullRequestEven
 				builds = append(builds, build)
 			} else {
 				// Legacy: Mender Client 5.0.x and below.
-				// Uses release_tool.py to find integration branches.
+				// Reads the integration branches from the integration repo.
 				var integrationsToTest []string
 				var err error
 				if integrationsToTest, err = getIntegrationVersionsLegacy(
@@ -95,7 +95,7 @@ func getClientBuilds(log *logrus.Entry, conf *config, pr *github.PullRequestEven
 					baseBranch,
 					conf,
 				); err != nil {
-					// Non-fatal: release_tool.py may not know about repos
+					// Non-fatal: the integration repo may not know about repos
 					// that only exist in the new subcomponents process.
 					log.Warnf(
 						"legacy: failed to get integration versions for "+
@@ -126,7 +126,7 @@ func getClientBuilds(log *logrus.Entry, conf *config, pr *github.PullRequestEven
 				// Uses mender-client-subcomponents JSON to find releases.
 				if maintenanceBranchPattern.MatchString(baseBranch) {
 					releases, err := fetchMenderClientReleases(
-						context.Background(),
+						traceContext(log),
 						log,
 						githubClient,
 					)
@@ -162,6 +162,18 @@ func getClientBuilds(log *logrus.Entry, conf *config, pr *github.PullRequestEven
 	return builds
 }
 
+// skipClientBuildReason returns why a client build is not started, or the
+// empty string if it should be started
+func skipClientBuildReason(build *buildOptions, buildOptions *BuildOptions) string {
+	if build.repo == "meta-mender" && build.baseBranch == "master-next" {
+		return "builds targeting meta-mender:master-next are not supported"
+	}
+	if len(buildOptions.Releases) > 0 && !slices.Contains(buildOptions.Releases, build.baseBranch) {
+		return "not in --release"
+	}
+	return ""
+}
+
 func getMenderQARef(build *buildOptions, buildOptions *BuildOptions) string {
 	// Explicit override: --pr mender-qa/NNN (or a branch name) from any repo's PR
 	if rev, exists := buildOptions.PullRequests["mender-qa"]; exists {
@@ -184,30 +196,41 @@ func triggerClientBuild(
 	pr *github.PullRequestEvent,
 	buildOptions *BuildOptions,
 ) error {
-	gitlabClient, err := clientgitlab.NewGitLabClient(
-		conf.gitlabToken,
-		conf.gitlabBaseURL,
-		conf.dryRunMode,
-	)
+	gitlabClient, err := newGitLabClient(traceContext(log), conf)
 	if err != nil {
 		return err
 	}
 
-	// Builds produced by the new release process carry releaseData;
-	// builds from the legacy release_tool path don't
-	var buildParameters []*gitlab.PipelineVariableOptions
-	if build.releaseData != nil {
-		buildParameters, err = getMenderClientBuildParameters(log, build, buildOptions)
-	} else {
-		buildParameters, err = getMenderClientBuildParametersLegacy(log, conf, build, buildOptions)
-	}
+	buildParameters, err := getClientBuildParameters(log, conf, build, buildOptions)
 	if err != nil {
 		return err
 	}
 
-	// first stop old pipelines with the same buildParameters
-	stopStalePipelines(clientPipelinePath, log, gitlabClient, buildParameters)
+	sortPipelineVariables(buildParameters)
+	// first stop the old pipelines of the same build, unless --no-supersede
+	if !buildOptions.NoSupersede {
+		stopStalePipelines(clientPipelinePath, log, gitlabClient, buildParameters)
+	}
 
+	return schedulePipeline(log, conf, gitlabClient, clientPipelinePath, buildParameters,
+		!buildOptions.NoSupersede, build.baseBranch, pr,
+		func(log *logrus.Entry, conf *config, gitlabClient clientgitlab.Client) error {
+			return createClientPipeline(log, conf, gitlabClient, build, pr, buildOptions,
+				buildParameters)
+		})
+}
+
+// createClientPipeline creates the client pipeline and comments its link on
+// the pull request
+func createClientPipeline(
+	log *logrus.Entry,
+	conf *config,
+	gitlabClient clientgitlab.Client,
+	build *buildOptions,
+	pr *github.PullRequestEvent,
+	buildOptions *BuildOptions,
+	buildParameters []*gitlab.PipelineVariableOptions,
+) error {
 	// trigger the new pipeline
 	clientPipelinePath := "Northern.tech/Mender/mender-qa"
 	ref := getMenderQARef(build, buildOptions)
@@ -281,7 +304,7 @@ Hello :smiley_cat: I created {{.Description}} for you here: [Pipeline-{{.Pipelin
 		Body: &commentBody,
 	}
 
-	err = githubClient.CreateComment(context.Background(),
+	err = githubClient.CreateComment(traceContext(log),
 		conf.githubOrganization, pr.GetRepo().GetName(), pr.GetNumber(), &comment)
 	if err != nil {
 		log.Infof("Failed to comment on the pr: %v, Error: %s", pr, err.Error())
@@ -290,6 +313,38 @@ Hello :smiley_cat: I created {{.Description}} for you here: [Pipeline-{{.Pipelin
 	return err
 }
 
+// getClientBuildParameters returns the pipeline parameters of a client build,
+// tagged with its identity
+func getClientBuildParameters(
+	log *logrus.Entry,
+	conf *config,
+	build *buildOptions,
+	buildOptions *BuildOptions,
+) ([]*gitlab.PipelineVariableOptions, error) {
+	var (
+		buildParameters []*gitlab.PipelineVariableOptions
+		err             error
+	)
+	// Builds produced by the new release process carry releaseData;
+	// builds from the legacy integration versions path don't
+	if build.releaseData != nil {
+		buildParameters, err = getMenderClientBuildParameters(log, build, buildOptions)
+	} else {
+		buildParameters, err = getMenderClientBuildParametersLegacy(log, conf, build, buildOptions)
+	}
+	if err != nil {
+		return nil, err
+	}
+	buildParameters = append(buildParameters, getPipelineIdentityParameters(build)...)
+	if build.commitSHA != "" {
+		buildParameters = append(buildParameters, &gitlab.PipelineVariableOptions{
+			Key:   gitlab.Ptr(pipelineOriginSHAKey),
+			Value: gitlab.Ptr(build.commitSHA),
+		})
+	}
+	return buildParameters, nil
+}
+
 // getMenderClientBuildParameters builds pipeline parameters from the
 // mender-client-subcomponents JSON release data. Used for Mender Client
 // 6.0.x and above.
@@ -380,7 +435,7 @@ func getMenderClientBuildParameters(
 	return getClientAcceptanceBuildParameters(buildParameters, build)
 }
 
-// Legacy: Mender Client 5.0.x and below. Uses release_tool.py from the
+// Legacy: Mender Client 5.0.x and below. Reads the versions from the
 // integration repo. Remove when deprecating the old release process.
 func getMenderClientBuildParametersLegacy(
 	log *logrus.Entry,
@@ -395,9 +450,14 @@ func getMenderClientBuildParametersLegacy(
 	var versionedRepositories []string
 	if build.repo == "meta-mender" {
 		// For meta-mender, pick master versions of all Mender release repos.
-		versionedRepositories, err = getListOfVersionedRepositories("origin/master", conf)
+		versionedRepositories, err = getListOfVersionedRepositories(
+			traceContext(log),
+			"origin/master",
+			conf,
+		)
 	} else {
 		versionedRepositories, err = getListOfVersionedRepositories(
+			traceContext(log),
 			"origin/"+build.baseBranch,
 			conf,
 		)
@@ -430,6 +490,7 @@ func getMenderClientBuildParametersLegacy(
 				continue
 			}
 			version, err := getServiceRevisionFromIntegration(
+				traceContext(log),
 				versionedRepo,
 				"origin/"+build.baseBranch,
 				conf,
@@ -644,39 +705,32 @@ func stopBuildsOfStaleClientPRs(
 
 	log.Debug("stopBuildsOfStaleClientPRs: Find any running pipelines and kill mercilessly!")
 
+	return stopClientBuilds(log, pr, conf)
+}
+
+// stopClientBuilds stops the pending, running and queued client pipelines of
+// the pull request
+func stopClientBuilds(
+	log *logrus.Entry,
+	pr *github.PullRequestEvent,
+	conf *config) error {
+
 	for _, build := range getClientBuilds(log, conf, pr) {
 
-		gitlabClient, err := clientgitlab.NewGitLabClient(
-			conf.gitlabToken,
-			conf.gitlabBaseURL,
-			conf.dryRunMode,
-		)
+		gitlabClient, err := newGitLabClient(traceContext(log), conf)
 		if err != nil {
 			return err
 		}
 
-		var buildParams []*gitlab.PipelineVariableOptions
-		if build.releaseData != nil {
-			buildParams, err = getMenderClientBuildParameters(
-				log,
-				&build,
-				NewBuildOptions(),
-			)
-		} else {
-			buildParams, err = getMenderClientBuildParametersLegacy(
-				log,
-				conf,
-				&build,
-				NewBuildOptions(),
-			)
-		}
+		buildParams, err := getClientBuildParameters(log, conf, &build, NewBuildOptions())
 		if err != nil {
-			log.Debug("stopBuildsOfStaleClientPRs: Failed to get the" +
+			log.Debug("stopClientBuilds: Failed to get the" +
 				"build-parameters for the build")
 			return err
 		}
 
 		stopStalePipelines(clientPipelinePath, log, gitlabClient, buildParams)
+		pipelinesQueue.remove(clientPipelinePath, pipelineVariableOptionsMap(buildParams))
 	}
 
 	return nil
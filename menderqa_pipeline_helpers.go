@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os/exec"
 	"path"
 	"regexp"
 	"strings"
@@ -13,29 +12,22 @@ import (
 	"github.com/sirupsen/logrus"
 
 	clientgithub "github.com/mendersoftware/integration-test-runner/client/github"
+	"github.com/mendersoftware/integration-test-runner/integration"
 )
 
-func getServiceRevisionFromIntegration(repo, baseBranch string, conf *config) (string, error) {
-	c := exec.Command(
-		"python3",
-		"release_tool.py",
-		"--version-of",
-		repo,
-		"--in-integration-version",
-		baseBranch,
-	)
-	c.Dir = conf.integrationDirectory + "/extra/"
-	out, err := c.Output()
+// getServiceRevisionFromIntegration returns the version of the repository
+// in the integration version baseBranch, e.g. "origin/3.7.x"
+func getServiceRevisionFromIntegration(
+	ctx context.Context,
+	repo, baseBranch string,
+	conf *config,
+) (string, error) {
+	version, err := integration.NewReader(conf.integrationDirectory).
+		VersionOf(ctx, repo, baseBranch)
 	if err != nil {
-		err = fmt.Errorf("getServiceRevisionFromIntegration: Error: %v (%s)", err, out)
-	}
-	version := string(out)
-
-	// remove the remote (ex: "`origin`/1.0.x")
-	if strings.Contains(version, "/") {
-		version = strings.SplitN(version, "/", 2)[1]
+		return "", fmt.Errorf("getServiceRevisionFromIntegration: %w", err)
 	}
-	return strings.TrimSpace(string(version)), err
+	return version, nil
 }
 
 // The parameter that the build system uses for repo specific revisions is <REPO_NAME>_REV
@@ -44,42 +36,21 @@ func repoToBuildParameter(repo string) string {
 	return strings.Replace(repoRevision, "-", "_", -1)
 }
 
-// Legacy: Mender Client 5.0.x and below. Uses release_tool.py from the
-// integration repo. Remove when deprecating the old release process.
+// Legacy: Mender Client 5.0.x and below. Reads the integration branches
+// from the integration repo. Remove when deprecating the old release process.
 func getIntegrationVersionsLegacy(
 	log *logrus.Entry,
 	repo, version string,
 	conf *config,
 ) ([]string, error) {
-	cmdArgs := []string{
-		"release_tool.py",
-		"--integration-versions-including",
+	branches, err := integration.NewReader(conf.integrationDirectory).VersionsIncluding(
+		traceContext(log),
 		repo,
-		"--version",
 		version,
-	}
-	if strings.HasPrefix(version, featureBranchPrefix) {
-		cmdArgs = append(cmdArgs, "--feature-branches")
-	}
-	c := exec.Command("python3", cmdArgs...)
-	c.Dir = conf.integrationDirectory + "/extra/"
-	integrations, err := c.Output()
-
+		strings.HasPrefix(version, featureBranchPrefix),
+	)
 	if err != nil {
-		return nil, fmt.Errorf(
-			"getIntegrationVersionsLegacy: Error: %v (%s)",
-			err,
-			integrations,
-		)
-	}
-
-	branches := strings.Split(strings.TrimSpace(string(integrations)), "\n")
-
-	// remove the remote (ex: "`origin`/1.0.x")
-	for idx, branch := range branches {
-		if strings.Contains(branch, "/") {
-			branches[idx] = strings.Split(branch, "/")[1]
-		}
+		return nil, fmt.Errorf("getIntegrationVersionsLegacy: %w", err)
 	}
 
 	// filter out "staging" branch if version is "master"
@@ -108,15 +79,17 @@ func getIntegrationVersionsLegacy(
 	return branches, nil
 }
 
-func getListOfVersionedRepositories(inVersion string, conf *config) ([]string, error) {
-	c := exec.Command("python3", "release_tool.py", "--list", "--in-integration-version", inVersion)
-	c.Dir = conf.integrationDirectory + "/extra/"
-	output, err := c.Output()
+func getListOfVersionedRepositories(
+	ctx context.Context,
+	inVersion string,
+	conf *config,
+) ([]string, error) {
+	repos, err := integration.NewReader(conf.integrationDirectory).
+		VersionedRepositories(ctx, inVersion)
 	if err != nil {
-		return nil, fmt.Errorf("getListOfVersionedRepositories: Error: %v (%s)", err, output)
+		return nil, fmt.Errorf("getListOfVersionedRepositories: %w", err)
 	}
-
-	return strings.Split(strings.TrimSpace(string(output)), "\n"), nil
+	return repos, nil
 }
 
 // MenderClientRelease represents a Mender Client release as defined in
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"text/template"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/sirupsen/logrus"
+
+	clientgithub "github.com/mendersoftware/integration-test-runner/client/github"
+)
+
+// clientPipelinePlan is what a "start client pipeline" command would trigger
+type clientPipelinePlan struct {
+	Repo    string
+	Number  int
+	Builds  []plannedClientBuild
+	Skipped []skippedClientBuild
+}
+
+type plannedClientBuild struct {
+	Release string
+	// Legacy builds use the integration repo versions (Mender Client 5.0.x and below)
+	Legacy        bool
+	Ref           string
+	RefOverridden bool
+	Variables     []plannedVariable
+}
+
+type plannedVariable struct {
+	Key   string
+	Value string
+	// Overridden is true for the variables set by a --pr option
+	Overridden bool
+}
+
+type skippedClientBuild struct {
+	Release string
+	Reason  string
+}
+
+// nolint:lll
+const clientPipelinePlanTemplate = `Hello :smiley_cat: this is what ` + "`" + commandStartClientPipeline + "`" + ` would trigger for {{.Repo}}#{{.Number}}, nothing was started.
+{{range .Builds}}
+### {{.Release}}{{if .Legacy}} (legacy release process){{end}}
+
+mender-qa ref: {{if .RefOverridden}}**` + "`{{.Ref}}`" + `** (overridden){{else}}` + "`{{.Ref}}`" + `{{end}}
+
+| Key   | Value |
+| ----- | ----- |
+{{range .Variables}}{{if .Overridden}}| **{{.Key}}** | **{{.Value}}** (overridden) |{{else}}| {{.Key}} | {{.Value}} |{{end}}
+{{end}}{{end}}{{if not .Builds}}
+No pipeline would be started.
+{{end}}{{if .Skipped}}
+### Skipped
+
+| Release | Reason |
+| ------- | ------ |
+{{range .Skipped}}| {{.Release}} | {{.Reason}} |
+{{end}}{{end}}`
+
+// planClientBuilds computes the pipeline parameters of the builds without
+// creating anything, recording the builds which would be skipped and why
+func planClientBuilds(
+	log *logrus.Entry,
+	conf *config,
+	repo string,
+	number int,
+	builds []buildOptions,
+	buildOptions *BuildOptions,
+) *clientPipelinePlan {
+	plan := &clientPipelinePlan{
+		Repo:   repo,
+		Number: number,
+	}
+	overridden := make(map[string]bool)
+	for overriddenRepo := range buildOptions.PullRequests {
+		overridden[repoToBuildParameter(overriddenRepo)] = true
+	}
+	_, refOverridden := buildOptions.PullRequests["mender-qa"]
+
+	planned := []string{}
+	for _, build := range builds {
+		if reason := skipClientBuildReason(&build, buildOptions); reason != "" {
+			plan.Skipped = append(plan.Skipped, skippedClientBuild{
+				Release: build.baseBranch,
+				Reason:  reason,
+			})
+			continue
+		}
+		buildParameters, err := getClientBuildParameters(log, conf, &build, buildOptions)
+		if err != nil {
+			plan.Skipped = append(plan.Skipped, skippedClientBuild{
+				Release: build.baseBranch,
+				Reason:  "failed to compute the build parameters: " + err.Error(),
+			})
+			continue
+		}
+		plannedBuild := plannedClientBuild{
+			Release:       build.baseBranch,
+			Legacy:        build.releaseData == nil,
+			Ref:           getMenderQARef(&build, buildOptions),
+			RefOverridden: refOverridden,
+		}
+		for _, variable := range filterOutEmptyVariables(buildParameters) {
+			plannedBuild.Variables = append(plannedBuild.Variables, plannedVariable{
+				Key:        *variable.Key,
+				Value:      *variable.Value,
+				Overridden: overridden[*variable.Key],
+			})
+		}
+		plan.Builds = append(plan.Builds, plannedBuild)
+		planned = append(planned, build.baseBranch)
+	}
+
+	for _, release := range buildOptions.Releases {
+		if !slices.Contains(planned, release) {
+			found := false
+			for _, skipped := range plan.Skipped {
+				found = found || skipped.Release == release
+			}
+			if !found {
+				plan.Skipped = append(plan.Skipped, skippedClientBuild{
+					Release: release,
+					Reason:  "no release containing " + repo + " matches the target branch",
+				})
+			}
+		}
+	}
+	return plan
+}
+
+func formatClientPipelinePlan(plan *clientPipelinePlan) (string, error) {
+	tmpl, err := template.New("Plan").Parse(clientPipelinePlanTemplate)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, plan); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// planClientPipelines replies to the "plan client pipeline" command with the
+// build matrix of each client pipeline "start client pipeline" would create
+func planClientPipelines(
+	log *logrus.Entry,
+	githubClient clientgithub.Client,
+	conf *config,
+	prRequest *github.PullRequestEvent,
+	buildOptions *BuildOptions,
+) error {
+	buildOptions = getPullRequestOptions(prRequest.GetPullRequest()).getBuildOptions(buildOptions)
+	builds := parseClientPullRequest(log, conf, "opened", prRequest)
+	plan := planClientBuilds(
+		log,
+		conf,
+		prRequest.GetRepo().GetName(),
+		prRequest.GetNumber(),
+		builds,
+		buildOptions,
+	)
+	log.Infof(
+		"%s:%d would trigger %d builds, %d skipped",
+		plan.Repo,
+		plan.Number,
+		len(plan.Builds),
+		len(plan.Skipped),
+	)
+
+	commentBody, err := formatClientPipelinePlan(plan)
+	if err != nil {
+		log.Errorf("Failed to execute the build plan template. Error: %s\n", err.Error())
+		return err
+	}
+	comment := github.IssueComment{
+		Body: &commentBody,
+	}
+	err = githubClient.CreateComment(traceContext(log),
+		conf.githubOrganization, plan.Repo, plan.Number, &comment)
+	if err != nil {
+		log.Infof("Failed to comment on the pr: %v, Error: %s", prRequest, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanClientBuilds(t *testing.T) {
+	release := func(version, connect string) *MenderClientRelease {
+		return &MenderClientRelease{
+			Version: version,
+			Subcomponents: []MenderClientSubcomponent{
+				{Name: "mender-auth", Version: version, Source: "github.com/mendersoftware/mender"},
+				{
+					Name:    "mender-connect",
+					Version: connect,
+					Source:  "github.com/mendersoftware/mender-connect",
+				},
+			},
+		}
+	}
+	builds := []buildOptions{
+		{repo: "mender", pr: "12", baseBranch: "6.0.x", releaseData: release("6.0.x", "3.0.x")},
+		{repo: "mender", pr: "12", baseBranch: "7.0.x", releaseData: release("7.0.x", "4.0.x")},
+	}
+	buildOptions, err := parseBuildOptions(
+		"plan client pipeline --pr mender-connect/255 --pr mender-qa/903 " +
+			"--release 7.0.x --release 1.0.x",
+	)
+	require.NoError(t, err)
+
+	plan := planClientBuilds(logrus.NewEntry(logrus.StandardLogger()), &config{},
+		"mender", 12, builds, buildOptions)
+
+	require.Len(t, plan.Builds, 1)
+	build := plan.Builds[0]
+	assert.Equal(t, "7.0.x", build.Release)
+	assert.False(t, build.Legacy)
+	assert.Equal(t, "pr_903", build.Ref)
+	assert.True(t, build.RefOverridden)
+	assert.Contains(t, build.Variables, plannedVariable{
+		Key:        "MENDER_CONNECT_REV",
+		Value:      "pull/255/head",
+		Overridden: true,
+	})
+	assert.Contains(t, build.Variables, plannedVariable{
+		Key:   "MENDER_REV",
+		Value: "pull/12/head",
+	})
+	assert.Equal(t, []skippedClientBuild{
+		{Release: "6.0.x", Reason: "not in --release"},
+		{Release: "1.0.x", Reason: "no release containing mender matches the target branch"},
+	}, plan.Skipped)
+
+	comment, err := formatClientPipelinePlan(plan)
+	require.NoError(t, err)
+	assert.Contains(t, comment, "### 7.0.x\n")
+	assert.Contains(t, comment, "mender-qa ref: **`pr_903`** (overridden)\n")
+	assert.Contains(t, comment, "| **MENDER_CONNECT_REV** | **pull/255/head** (overridden) |\n")
+	assert.Contains(t, comment, "| MENDER_REV | pull/12/head |\n")
+	assert.Contains(t, comment, "| 6.0.x | not in --release |\n")
+	assert.NotContains(t, comment, "No pipeline would be started.")
+}
+
+func TestFormatClientPipelinePlanEmpty(t *testing.T) {
+	comment, err := formatClientPipelinePlan(&clientPipelinePlan{Repo: "mender", Number: 12})
+	require.NoError(t, err)
+	assert.Contains(t, comment, "would trigger for mender#12, nothing was started.")
+	assert.Contains(t, comment, "No pipeline would be started.")
+	assert.NotContains(t, comment, "### Skipped")
+}
@@ -0,0 +1,162 @@
+// Package metrics exposes the Prometheus metrics of the integration-test-runner
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "integration_test_runner"
+
+// Outcomes of the observed operations
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Services called by the runner
+const (
+	ServiceGitHub = "github"
+	ServiceGitLab = "gitlab"
+)
+
+var (
+	webhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Number of GitHub webhook deliveries by event type and action.",
+	}, []string{"event", "action"})
+
+	webhookDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "webhook_processing_duration_seconds",
+		Help:      "Time spent processing the GitHub webhook deliveries.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"event"})
+
+	commandInvocations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "command_invocations_total",
+		Help:      "Number of bot commands invoked in comments by command and outcome.",
+	}, []string{"command", "outcome"})
+
+	pipelinesCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pipelines_created_total",
+		Help:      "Number of GitLab pipelines created by project.",
+	}, []string{"project"})
+
+	pipelinesCancelled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pipelines_cancelled_total",
+		Help:      "Number of GitLab pipelines cancelled by project.",
+	}, []string{"project"})
+
+	gitDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "git_operation_duration_seconds",
+		Help:      "Duration of the git commands by git subcommand.",
+		Buckets:   []float64{0.01, 0.1, 0.5, 1, 5, 10, 30, 60, 120, 300},
+	}, []string{"operation"})
+
+	gitFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "git_operation_failures_total",
+		Help:      "Number of failed git commands by git subcommand.",
+	}, []string{"operation"})
+
+	syncAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sync_fetch_attempts_total",
+		Help:      "Number of GitHub->GitLab sync attempts by fetch depth and outcome.",
+	}, []string{"depth", "outcome"})
+
+	apiDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "api_request_duration_seconds",
+		Help:      "Latency of the GitHub and GitLab API calls by service and operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"service", "operation"})
+
+	apiErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_request_errors_total",
+		Help:      "Number of failed GitHub and GitLab API calls by service and operation.",
+	}, []string{"service", "operation"})
+
+	githubRateLimitRemaining = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "github_rate_limit_remaining",
+		Help:      "Remaining GitHub API requests in the current rate limit window.",
+	})
+)
+
+// Handler returns the HTTP handler serving the metrics
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Outcome returns the outcome label value for an error
+func Outcome(err error) string {
+	if err != nil {
+		return OutcomeFailure
+	}
+	return OutcomeSuccess
+}
+
+// ObserveWebhook records a webhook delivery processed since start
+func ObserveWebhook(event, action string, start time.Time) {
+	webhookDeliveries.WithLabelValues(event, action).Inc()
+	webhookDuration.WithLabelValues(event).Observe(time.Since(start).Seconds())
+}
+
+// ObserveCommand records the invocation of a bot command
+func ObserveCommand(command string, err error) {
+	commandInvocations.WithLabelValues(command, Outcome(err)).Inc()
+}
+
+// PipelineCreated records the creation of a pipeline in a GitLab project
+func PipelineCreated(project string) {
+	pipelinesCreated.WithLabelValues(project).Inc()
+}
+
+// PipelineCancelled records the cancellation of a pipeline in a GitLab project
+func PipelineCancelled(project string) {
+	pipelinesCancelled.WithLabelValues(project).Inc()
+}
+
+// ObserveGitOperation records a git command run since start
+func ObserveGitOperation(operation string, start time.Time, err error) {
+	gitDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		gitFailures.WithLabelValues(operation).Inc()
+	}
+}
+
+// ObserveSyncAttempt records an attempt to sync a ref at the given fetch
+// depth, where a negative depth is the full history
+func ObserveSyncAttempt(depth int, err error) {
+	label := "full"
+	if depth >= 0 {
+		label = strconv.Itoa(depth)
+	}
+	syncAttempts.WithLabelValues(label, Outcome(err)).Inc()
+}
+
+// ObserveAPICall records a GitHub or GitLab API call made since start
+func ObserveAPICall(service, operation string, start time.Time, err error) {
+	apiDuration.WithLabelValues(service, operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		apiErrors.WithLabelValues(service, operation).Inc()
+	}
+}
+
+// SetGitHubRateLimitRemaining records the remaining GitHub API requests
+func SetGitHubRateLimitRemaining(remaining int) {
+	githubRateLimitRemaining.Set(float64(remaining))
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T) string {
+	res := httptest.NewRecorder()
+	Handler().ServeHTTP(res, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestOutcome(t *testing.T) {
+	assert.Equal(t, OutcomeSuccess, Outcome(nil))
+	assert.Equal(t, OutcomeFailure, Outcome(errors.New("failed")))
+}
+
+func TestMetrics(t *testing.T) {
+	ObserveWebhook("pull_request", "opened", time.Now())
+	ObserveCommand("start client pipeline", nil)
+	ObserveCommand("cherry-pick to:", errors.New("conflict"))
+	PipelineCreated("Northern.tech/Mender/mender-qa")
+	PipelineCancelled("Northern.tech/Mender/mender-qa")
+	ObserveGitOperation("fetch", time.Now(), errors.New("exit status 128"))
+	ObserveSyncAttempt(5, errors.New("exit status 128"))
+	ObserveSyncAttempt(-1, nil)
+	ObserveAPICall(ServiceGitLab, "CreatePipeline", time.Now(), errors.New("403"))
+	SetGitHubRateLimitRemaining(4321)
+
+	body := scrape(t)
+	for _, expected := range []string{
+		`integration_test_runner_webhook_deliveries_total{action="opened",event="pull_request"} 1`,
+		`integration_test_runner_webhook_processing_duration_seconds_count{event="pull_request"} 1`,
+		`integration_test_runner_command_invocations_total{command="start client pipeline",outcome="success"} 1`,
+		`integration_test_runner_command_invocations_total{command="cherry-pick to:",outcome="failure"} 1`,
+		`integration_test_runner_pipelines_created_total{project="Northern.tech/Mender/mender-qa"} 1`,
+		`integration_test_runner_pipelines_cancelled_total{project="Northern.tech/Mender/mender-qa"} 1`,
+		`integration_test_runner_git_operation_duration_seconds_count{operation="fetch"} 1`,
+		`integration_test_runner_git_operation_failures_total{operation="fetch"} 1`,
+		`integration_test_runner_sync_fetch_attempts_total{depth="5",outcome="failure"} 1`,
+		`integration_test_runner_sync_fetch_attempts_total{depth="full",outcome="success"} 1`,
+		`integration_test_runner_api_request_duration_seconds_count{operation="CreatePipeline",service="gitlab"} 1`,
+		`integration_test_runner_api_request_errors_total{operation="CreatePipeline",service="gitlab"} 1`,
+		`integration_test_runner_github_rate_limit_remaining 4321`,
+	} {
+		assert.Contains(t, body, expected)
+	}
+}
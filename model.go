@@ -4,6 +4,7 @@ type BuildOptions struct {
 	Fast         bool
 	PullRequests map[string]string
 	Releases     []string
+	NoSupersede  bool
 }
 
 func NewBuildOptions() *BuildOptions {
@@ -3,8 +3,9 @@ package main
 import (
 	"bytes"
 	"context"
-	"reflect"
+	"maps"
 	"sort"
+	"strings"
 	"text/template"
 
 	"github.com/google/go-github/v28/github"
@@ -61,16 +62,93 @@ func filterOutEmptyVariables(
 	return optionsOut
 }
 
+// The identity variables tag the pipelines of the bot with the build they
+// run: a new pipeline supersedes the running ones with the same identity,
+// whatever the other variables, e.g. the --pr overrides
+const (
+	pipelineOriginRepoKey    = "BOT_ORIGIN_REPO"
+	pipelineOriginPRKey      = "BOT_ORIGIN_PR"
+	pipelineOriginReleaseKey = "BOT_ORIGIN_RELEASE"
+)
+
+// pipelineOriginSHAKey tags the client pipelines with the head commit of the
+// pull request they build, for the client pipeline status
+const pipelineOriginSHAKey = "BOT_ORIGIN_SHA"
+
+var pipelineIdentityKeys = []string{
+	pipelineOriginRepoKey,
+	pipelineOriginPRKey,
+	pipelineOriginReleaseKey,
+}
+
+// getPipelineIdentityParameters returns the identity variables of the
+// pipeline of a build
+func getPipelineIdentityParameters(build *buildOptions) []*gitlab.PipelineVariableOptions {
+	values := map[string]string{
+		pipelineOriginRepoKey:    build.repo,
+		pipelineOriginPRKey:      build.pr,
+		pipelineOriginReleaseKey: build.baseBranch,
+	}
+	var buildParameters []*gitlab.PipelineVariableOptions
+	for _, key := range pipelineIdentityKeys {
+		buildParameters = append(buildParameters, &gitlab.PipelineVariableOptions{
+			Key:   gitlab.Ptr(key),
+			Value: gitlab.Ptr(values[key]),
+		})
+	}
+	return buildParameters
+}
+
+// sortPipelineVariables sorts the variables by key, the order GitLab lists
+// them in
+func sortPipelineVariables(vars []*gitlab.PipelineVariableOptions) {
+	sort.SliceStable(vars, func(i, j int) bool {
+		return *vars[i].Key < *vars[j].Key
+	})
+}
+
+// pipelineIdentity returns the identity of a pipeline from its variables, or
+// the empty string if they lack any of the identity variables, e.g. for the
+// pipelines created before the tagging
+func pipelineIdentity(variables map[string]string) string {
+	values := []string{}
+	for _, key := range pipelineIdentityKeys {
+		value, ok := variables[key]
+		if !ok {
+			return ""
+		}
+		values = append(values, key+"="+value)
+	}
+	return strings.Join(values, ",")
+}
+
+// isSupersededPipeline tells whether a pipeline with the new variables
+// supersedes the one with the old variables: same identity or, for untagged
+// pipelines, the very same variables
+func isSupersededPipeline(newVariables, oldVariables map[string]string) bool {
+	if identity := pipelineIdentity(newVariables); identity != "" {
+		return identity == pipelineIdentity(oldVariables)
+	}
+	return maps.Equal(newVariables, oldVariables)
+}
+
+func pipelineVariableOptionsMap(vars []*gitlab.PipelineVariableOptions) map[string]string {
+	variables := make(map[string]string, len(vars))
+	for _, v := range vars {
+		variables[*v.Key] = *v.Value
+	}
+	return variables
+}
+
+// stopStalePipelines cancels the pending and running pipelines of the bot in
+// the project superseded by a pipeline with the given variables
 func stopStalePipelines(
 	pipelinePath string,
 	log *logrus.Entry,
 	client clientgitlab.Client,
 	vars []*gitlab.PipelineVariableOptions,
 ) {
-
-	sort.SliceStable(vars, func(i, j int) bool {
-		return *vars[i].Key < *vars[j].Key
-	})
+	newVariables := pipelineVariableOptionsMap(vars)
 
 	username := githubBotName
 	status := gitlab.Pending
@@ -79,7 +157,7 @@ func stopStalePipelines(
 		Status:   &status,
 	}
 
-	pipelinesPending, err := client.ListProjectPipelines(clientPipelinePath, opt)
+	pipelinesPending, err := client.ListProjectPipelines(pipelinePath, opt)
 	if err != nil {
 		log.Errorf("stopStalePipelines: Could not list pending pipelines: %s", err.Error())
 	}
@@ -90,27 +168,28 @@ func stopStalePipelines(
 		Status:   &status,
 	}
 
-	pipelinesRunning, err := client.ListProjectPipelines(clientPipelinePath, opt)
+	pipelinesRunning, err := client.ListProjectPipelines(pipelinePath, opt)
 	if err != nil {
 		log.Errorf("stopStalePipelines: Could not list running pipelines: %s", err.Error())
 	}
 
 	for _, pipeline := range append(pipelinesPending, pipelinesRunning...) {
 
-		variables, err := client.GetPipelineVariables(clientPipelinePath, pipeline.ID)
+		variables, err := client.GetPipelineVariables(pipelinePath, pipeline.ID)
 		if err != nil {
 			log.Errorf("stopStalePipelines: Could not get variables for pipeline: %s", err.Error())
 			continue
 		}
 
-		sort.SliceStable(variables, func(i, j int) bool {
-			return variables[i].Key < variables[j].Key
-		})
+		oldVariables := make(map[string]string, len(variables))
+		for _, v := range variables {
+			oldVariables[v.Key] = v.Value
+		}
 
-		if reflect.DeepEqual(vars, variables) {
+		if isSupersededPipeline(newVariables, oldVariables) {
 			log.Infof("Cancelling stale pipeline %d, url: %s", pipeline.ID, pipeline.WebURL)
 
-			err := client.CancelPipelineBuild(clientPipelinePath, pipeline.ID)
+			err := client.CancelPipelineBuild(pipelinePath, pipeline.ID)
 			if err != nil {
 				log.Errorf("stopStalePipelines: Could not cancel pipeline: %s", err.Error())
 			}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/sirupsen/logrus"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+
+	clientgitlab "github.com/mendersoftware/integration-test-runner/client/gitlab"
+)
+
+// pipelineQueueInterval is how often the queued pipelines are started when
+// their project has free slots
+const pipelineQueueInterval = time.Minute
+
+// pipelineQueueAttempts is how many times a queued pipeline is tried before
+// giving up and telling the pull request
+const pipelineQueueAttempts = 3
+
+// queuedPipeline is a pipeline waiting for a free slot in its project
+type queuedPipeline struct {
+	// key identifies the pipeline by its variables, or by its identity when
+	// it supersedes the previous ones: triggering it again replaces the
+	// queued one
+	key       string
+	variables map[string]string
+	release   bool
+	log       *logrus.Entry
+	conf      *config
+	pr        *github.PullRequestEvent
+	create    createPipelineFunc
+	// attempts is the number of times the pipeline failed to start
+	attempts int
+}
+
+// createPipelineFunc creates a pipeline, possibly after the delivery which
+// triggered it, hence with its own logger and configuration
+type createPipelineFunc func(log *logrus.Entry, conf *config, client clientgitlab.Client) error
+
+// pipelineQueue caps the number of pipelines the bot runs concurrently in a
+// GitLab project; the excess ones wait in a FIFO queue per project, where
+// the pipelines for release branches go first
+type pipelineQueue struct {
+	limits map[string]int
+
+	// mutex guards the queues and the slots being started, it is never held
+	// while calling GitLab or GitHub
+	mutex   sync.Mutex
+	pending map[string][]*queuedPipeline
+	// starting are the pipelines being created per project, which GitLab
+	// does not count as active yet
+	starting map[string]int
+}
+
+// pipelinesQueue is set up in doMain when any project has a limit
+var pipelinesQueue *pipelineQueue
+
+func newPipelineQueue(limits map[string]int) *pipelineQueue {
+	return &pipelineQueue{
+		limits:   limits,
+		pending:  make(map[string][]*queuedPipeline),
+		starting: make(map[string]int),
+	}
+}
+
+// pipelineKey returns the key of a pipeline from its variables
+func pipelineKey(vars []*gitlab.PipelineVariableOptions) string {
+	pairs := []string{}
+	for _, v := range vars {
+		pairs = append(pairs, *v.Key+"="+*v.Value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// isReleaseBranch tells whether a pipeline for the branch goes first
+func isReleaseBranch(branch string) bool {
+	return maintenanceBranchPattern.MatchString(branch)
+}
+
+// activePipelines returns the number of pending and running pipelines the
+// bot created in the project
+func activePipelines(client clientgitlab.Client, path string) (int, error) {
+	active := 0
+	for _, status := range []gitlab.BuildStateValue{gitlab.Pending, gitlab.Running} {
+		pipelines, err := client.ListProjectPipelines(path, &gitlab.ListProjectPipelinesOptions{
+			ListOptions: gitlab.ListOptions{PerPage: 100},
+			Username:    gitlab.Ptr(githubBotName),
+			Status:      gitlab.Ptr(status),
+		})
+		if err != nil {
+			return 0, err
+		}
+		active += len(pipelines)
+	}
+	return active, nil
+}
+
+// enqueue queues the pipeline, or replaces the queued one with the same key,
+// and returns its 1-based position in the queue
+func (q *pipelineQueue) enqueue(path string, pipeline *queuedPipeline) int {
+	pending := q.pending[path]
+	for i, queued := range pending {
+		if queued.key == pipeline.key {
+			pending[i] = pipeline
+			return i + 1
+		}
+	}
+	position := len(pending)
+	if pipeline.release {
+		position = 0
+		for position < len(pending) && pending[position].release {
+			position++
+		}
+	}
+	q.pending[path] = append(pending[:position],
+		append([]*queuedPipeline{pipeline}, pending[position:]...)...)
+	return position + 1
+}
+
+// reserve reserves a slot of the project for a new pipeline if the queue is
+// empty and the active pipelines leave one free; countErr is the error of
+// counting them, in which case the pipeline is not held back
+func (q *pipelineQueue) reserve(path string, active int, countErr error) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if len(q.pending[path]) > 0 ||
+		(countErr == nil && active+q.starting[path] >= q.limits[path]) {
+		return false
+	}
+	q.starting[path]++
+	return true
+}
+
+// pop removes the head of the queue of the project and reserves a slot for
+// it, if the active pipelines leave one free; nil otherwise
+func (q *pipelineQueue) pop(path string, active int) *queuedPipeline {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if len(q.pending[path]) == 0 || active+q.starting[path] >= q.limits[path] {
+		return nil
+	}
+	next := q.pending[path][0]
+	q.pending[path] = q.pending[path][1:]
+	q.starting[path]++
+	return next
+}
+
+// requeue puts a pipeline which failed to start back at the head of the
+// queue, unless it was triggered again meanwhile
+func (q *pipelineQueue) requeue(path string, pipeline *queuedPipeline) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for _, queued := range q.pending[path] {
+		if queued.key == pipeline.key {
+			return
+		}
+	}
+	q.pending[path] = append([]*queuedPipeline{pipeline}, q.pending[path]...)
+}
+
+// release releases the slot reserved for a pipeline once created, when
+// GitLab counts it as active, or failed
+func (q *pipelineQueue) release(path string) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.starting[path]--
+}
+
+// remove drops the queued pipelines superseded by a pipeline with the given
+// variables, e.g. when their pull request is closed
+func (q *pipelineQueue) remove(path string, variables map[string]string) {
+	if q == nil {
+		return
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	pending := []*queuedPipeline{}
+	for _, queued := range q.pending[path] {
+		if !isSupersededPipeline(variables, queued.variables) {
+			pending = append(pending, queued)
+		}
+	}
+	q.pending[path] = pending
+}
+
+// schedulePipeline creates the pipeline in the project if it has a free slot,
+// otherwise it queues it, replacing the queued pipeline it supersedes if any,
+// and comments its position on the pull request
+func schedulePipeline(
+	log *logrus.Entry,
+	conf *config,
+	client clientgitlab.Client,
+	path string,
+	vars []*gitlab.PipelineVariableOptions,
+	supersede bool,
+	baseBranch string,
+	pr *github.PullRequestEvent,
+	create createPipelineFunc,
+) error {
+	q := pipelinesQueue
+	if q == nil || q.limits[path] <= 0 {
+		return create(log, conf, client)
+	}
+	limit := q.limits[path]
+	q.mutex.Lock()
+	queued := len(q.pending[path]) > 0
+	q.mutex.Unlock()
+	if !queued {
+		active, err := activePipelines(client, path)
+		if err != nil {
+			log.Errorf("Could not count the pipelines of %s: %s", path, err.Error())
+		}
+		if q.reserve(path, active, err) {
+			defer q.release(path)
+			return create(log, conf, client)
+		}
+	}
+
+	// the configuration is shared by the deliveries, keep the one of the PR;
+	// the request context of the logger is recycled once the delivery ends
+	queuedConf := *conf
+	variables := pipelineVariableOptionsMap(vars)
+	key := pipelineKey(vars)
+	if identity := pipelineIdentity(variables); supersede && identity != "" {
+		key = identity
+	}
+	q.mutex.Lock()
+	position := q.enqueue(path, &queuedPipeline{
+		key:       key,
+		variables: variables,
+		release:   isReleaseBranch(baseBranch),
+		log:       log.WithContext(context.Background()),
+		conf:      &queuedConf,
+		pr:        pr,
+		create:    create,
+	})
+	q.mutex.Unlock()
+	log.Infof("Queued the pipeline in project %s at position %d", path, position)
+
+	// nolint:lll
+	tmplString := `Hello :smiley_cat: {{.Path}} is already running {{.Limit}} of my pipelines, so I queued yours at position {{.Position}}. I will start it as soon as a slot frees up.`
+	_ = say(traceContext(log), tmplString, struct {
+		Path     string
+		Limit    int
+		Position int
+	}{
+		Path:     path,
+		Limit:    limit,
+		Position: position,
+	}, log, conf, pr)
+	return nil
+}
+
+// dispatch starts the queued pipelines of the projects having free slots
+func (q *pipelineQueue) dispatch() {
+	q.mutex.Lock()
+	heads := map[string]*queuedPipeline{}
+	for path, pending := range q.pending {
+		if len(pending) > 0 {
+			heads[path] = pending[0]
+		}
+	}
+	q.mutex.Unlock()
+
+	for path, head := range heads {
+		client, err := newGitLabClient(traceContext(head.log), head.conf)
+		if err != nil {
+			logrus.Errorf("Could not create the GitLab client: %s", err.Error())
+			continue
+		}
+		active, err := activePipelines(client, path)
+		if err != nil {
+			logrus.Errorf("Could not count the pipelines of %s: %s", path, err.Error())
+			continue
+		}
+		for next := q.pop(path, active); next != nil; next = q.pop(path, active) {
+			next.log.Infof("Starting the queued pipeline in project %s", path)
+			client, err := newGitLabClient(traceContext(next.log), next.conf)
+			if err == nil {
+				err = next.create(next.log, next.conf, client)
+			}
+			q.release(path)
+			if err != nil {
+				next.log.Errorf("Could not start the queued pipeline: %s", err.Error())
+				if q.retry(path, next) {
+					// tried again at the next dispatch, keeping its place
+					break
+				}
+				continue
+			}
+			active++
+		}
+	}
+}
+
+// retry requeues a pipeline which failed to start, to try it again at the
+// next dispatch, or gives up after pipelineQueueAttempts and comments on the
+// pull request; it tells whether the pipeline was requeued
+func (q *pipelineQueue) retry(path string, pipeline *queuedPipeline) bool {
+	pipeline.attempts++
+	if pipeline.attempts < pipelineQueueAttempts {
+		q.requeue(path, pipeline)
+		return true
+	}
+	pipeline.log.Errorf("Giving up on the queued pipeline in project %s", path)
+
+	// nolint:lll
+	tmplString := `Hello :smiley_cat: I could not start your queued pipeline in {{.Path}} after {{.Attempts}} attempts. Please check the logs, or ask me to start it again.`
+	_ = say(traceContext(pipeline.log), tmplString, struct {
+		Path     string
+		Attempts int
+	}{
+		Path:     path,
+		Attempts: pipeline.attempts,
+	}, pipeline.log, pipeline.conf, pipeline.pr)
+	return false
+}
+
+// run dispatches the queued pipelines at every interval until ctx is done
+func (q *pipelineQueue) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			q.dispatch()
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+
+	clientgitlab "github.com/mendersoftware/integration-test-runner/client/gitlab"
+	"github.com/mendersoftware/integration-test-runner/testing/fakegitlab"
+)
+
+func pipelineVariables(pairs ...string) []*gitlab.PipelineVariableOptions {
+	vars := []*gitlab.PipelineVariableOptions{}
+	for i := 0; i < len(pairs); i += 2 {
+		vars = append(vars, &gitlab.PipelineVariableOptions{
+			Key:   gitlab.Ptr(pairs[i]),
+			Value: gitlab.Ptr(pairs[i+1]),
+		})
+	}
+	return vars
+}
+
+func TestPipelineQueueEnqueue(t *testing.T) {
+	const path = "Northern.tech/Mender/mender-qa"
+	q := newPipelineQueue(map[string]int{path: 1})
+	queued := func(key string, release bool) *queuedPipeline {
+		return &queuedPipeline{key: key, release: release}
+	}
+	keys := func() []string {
+		res := []string{}
+		for _, pipeline := range q.pending[path] {
+			res = append(res, pipeline.key)
+		}
+		return res
+	}
+
+	assert.Equal(t, 1, q.enqueue(path, queued("a", false)))
+	assert.Equal(t, 2, q.enqueue(path, queued("b", false)))
+	// the release branches go first, in order
+	assert.Equal(t, 1, q.enqueue(path, queued("r1", true)))
+	assert.Equal(t, 2, q.enqueue(path, queued("r2", true)))
+	assert.Equal(t, 5, q.enqueue(path, queued("c", false)))
+	// triggered again, keeps its position
+	assert.Equal(t, 3, q.enqueue(path, queued("a", false)))
+	assert.Equal(t, []string{"r1", "r2", "a", "b", "c"}, keys())
+
+	build := &buildOptions{repo: "mender", pr: "1", baseBranch: "master"}
+	vars := append(pipelineVariables("MENDER_REV", "pull/1/head"),
+		getPipelineIdentityParameters(build)...)
+	assert.Equal(t, 6, q.enqueue(path, &queuedPipeline{
+		key:       pipelineKey(vars),
+		variables: pipelineVariableOptionsMap(vars),
+	}))
+	build.pr = "2"
+	q.remove(path, pipelineVariableOptionsMap(getPipelineIdentityParameters(build)))
+	assert.Len(t, keys(), 6)
+	// same pull request and release, whatever the other variables
+	build.pr = "1"
+	q.remove(path, pipelineVariableOptionsMap(append(
+		pipelineVariables("MENDER_CONNECT_REV", "pull/2/head"),
+		getPipelineIdentityParameters(build)...)))
+	assert.Equal(t, []string{"r1", "r2", "a", "b", "c"}, keys())
+}
+
+func TestPipelineKey(t *testing.T) {
+	assert.Equal(t,
+		pipelineKey(pipelineVariables("B", "2", "A", "1")),
+		pipelineKey(pipelineVariables("A", "1", "B", "2")))
+	assert.NotEqual(t,
+		pipelineKey(pipelineVariables("A", "1")),
+		pipelineKey(pipelineVariables("A", "2")))
+}
+
+func TestSchedulePipeline(t *testing.T) {
+	env := newE2EEnvironment(t)
+	const path = "Northern.tech/Mender/mender-qa"
+	running := env.gitlab.AddPipeline(path, &fakegitlab.Pipeline{
+		Pipeline: gitlab.Pipeline{
+			Status: string(gitlab.Running),
+			User:   &gitlab.BasicUser{Username: githubBotName},
+		},
+	})
+	env.gitlab.AddPipeline(path, &fakegitlab.Pipeline{
+		Pipeline: gitlab.Pipeline{Status: string(gitlab.Success)},
+	})
+
+	previousQueue := pipelinesQueue
+	pipelinesQueue = newPipelineQueue(map[string]int{path: 1})
+	t.Cleanup(func() { pipelinesQueue = previousQueue })
+
+	client, err := newGitLabClient(traceContext(nil), env.conf)
+	require.NoError(t, err)
+	pr := env.pullRequestEvent("opened", 7)
+	pr.Repo = &github.Repository{Name: github.String("mender")}
+	started := []string{}
+	create := func(org string) createPipelineFunc {
+		return func(log *logrus.Entry, conf *config, client clientgitlab.Client) error {
+			assert.Equal(t, org, conf.githubOrganization)
+			started = append(started, org)
+			return nil
+		}
+	}
+
+	err = schedulePipeline(logrus.NewEntry(logrus.StandardLogger()), env.conf, client, path,
+		pipelineVariables("MENDER_REV", "pull/7/head"), true, "master", pr, create("cfengine"))
+	require.NoError(t, err)
+	assert.Empty(t, started)
+	comments := env.github.Comments("cfengine", "mender", 7)
+	require.Len(t, comments, 1)
+	assert.Contains(t, comments[0].GetBody(), "I queued yours at position 1")
+
+	// the configuration of the queued pipeline is kept
+	env.conf.githubOrganization = "mendersoftware"
+	pipelinesQueue.dispatch()
+	assert.Empty(t, started)
+
+	env.gitlab.SetPipelineStatus(path, running.ID, string(gitlab.Success))
+	pipelinesQueue.dispatch()
+	assert.Equal(t, []string{"cfengine"}, started)
+	assert.Empty(t, pipelinesQueue.pending[path])
+
+	// free slot and empty queue: started right away
+	err = schedulePipeline(logrus.NewEntry(logrus.StandardLogger()), env.conf, client, path,
+		pipelineVariables("MENDER_REV", "pull/8/head"), true, "master", pr, create("mendersoftware"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cfengine", "mendersoftware"}, started)
+}
+
+func TestDispatchFailingPipeline(t *testing.T) {
+	env := newE2EEnvironment(t)
+	const path = "Northern.tech/Mender/mender-qa"
+	running := env.gitlab.AddPipeline(path, &fakegitlab.Pipeline{
+		Pipeline: gitlab.Pipeline{
+			Status: string(gitlab.Running),
+			User:   &gitlab.BasicUser{Username: githubBotName},
+		},
+	})
+
+	previousQueue := pipelinesQueue
+	pipelinesQueue = newPipelineQueue(map[string]int{path: 2})
+	t.Cleanup(func() { pipelinesQueue = previousQueue })
+	// the first pipeline holds the second slot
+	pipelinesQueue.starting[path] = 1
+
+	client, err := newGitLabClient(traceContext(nil), env.conf)
+	require.NoError(t, err)
+	pr := env.pullRequestEvent("opened", 7)
+	pr.Repo = &github.Repository{Name: github.String("mender")}
+	failing := 0
+	started := []string{}
+	create := func(name string) createPipelineFunc {
+		return func(log *logrus.Entry, conf *config, client clientgitlab.Client) error {
+			if name == "failing" {
+				failing++
+				return errors.New("pipeline creation failed")
+			}
+			started = append(started, name)
+			return nil
+		}
+	}
+	for i, name := range []string{"failing", "other"} {
+		err = schedulePipeline(logrus.NewEntry(logrus.StandardLogger()), env.conf, client, path,
+			pipelineVariables("MENDER_REV", fmt.Sprintf("pull/%d/head", i)), true, "master",
+			pr, create(name))
+		require.NoError(t, err)
+	}
+	pipelinesQueue.starting[path] = 0
+	env.gitlab.SetPipelineStatus(path, running.ID, string(gitlab.Success))
+
+	// a failing pipeline keeps its place and does not hold a slot
+	for attempt := 1; attempt < pipelineQueueAttempts; attempt++ {
+		pipelinesQueue.dispatch()
+		assert.Equal(t, attempt, failing)
+		assert.Empty(t, started)
+		require.Len(t, pipelinesQueue.pending[path], 2)
+		assert.Equal(t, attempt, pipelinesQueue.pending[path][0].attempts)
+		assert.Zero(t, pipelinesQueue.starting[path])
+	}
+
+	pipelinesQueue.dispatch()
+	assert.Equal(t, pipelineQueueAttempts, failing)
+	assert.Equal(t, []string{"other"}, started)
+	assert.Empty(t, pipelinesQueue.pending[path])
+	comments := env.github.Comments("cfengine", "mender", 7)
+	require.Len(t, comments, 3)
+	assert.Contains(t, comments[2].GetBody(), "I could not start your queued pipeline")
+}
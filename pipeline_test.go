@@ -0,0 +1,96 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+
+	"github.com/mendersoftware/integration-test-runner/testing/fakegitlab"
+)
+
+func TestIsSupersededPipeline(t *testing.T) {
+	identity := func(pr, release string, pairs ...string) map[string]string {
+		build := &buildOptions{repo: "mender", pr: pr, baseBranch: release}
+		return pipelineVariableOptionsMap(append(pipelineVariables(pairs...),
+			getPipelineIdentityParameters(build)...))
+	}
+	testCases := map[string]struct {
+		newVariables map[string]string
+		oldVariables map[string]string
+		superseded   bool
+	}{
+		"same build, other --pr": {
+			newVariables: identity("12", "6.0.x", "MENDER_CONNECT_REV", "pull/255/head"),
+			oldVariables: identity("12", "6.0.x", "MENDER_CONNECT_REV", "3.0.x"),
+			superseded:   true,
+		},
+		"other release": {
+			newVariables: identity("12", "6.0.x"),
+			oldVariables: identity("12", "7.0.x"),
+		},
+		"other pull request": {
+			newVariables: identity("12", "6.0.x"),
+			oldVariables: identity("13", "6.0.x"),
+		},
+		"untagged old pipeline": {
+			newVariables: identity("12", "6.0.x", "MENDER_REV", "pull/12/head"),
+			oldVariables: map[string]string{"MENDER_REV": "pull/12/head"},
+		},
+		"untagged, same variables": {
+			newVariables: map[string]string{"MENDER_REV": "pull/12/head"},
+			oldVariables: map[string]string{"MENDER_REV": "pull/12/head"},
+			superseded:   true,
+		},
+		"untagged, other variables": {
+			newVariables: map[string]string{"MENDER_REV": "pull/12/head"},
+			oldVariables: map[string]string{"MENDER_REV": "pull/13/head"},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.superseded, isSupersededPipeline(tc.newVariables, tc.oldVariables))
+		})
+	}
+}
+
+func TestStopStalePipelines(t *testing.T) {
+	env := newE2EEnvironment(t)
+	build := &buildOptions{repo: "integration", pr: "2725", baseBranch: "master"}
+	addPipeline := func(path string, build *buildOptions) *fakegitlab.Pipeline {
+		variables := []*gitlab.PipelineVariable{}
+		for _, v := range getPipelineIdentityParameters(build) {
+			variables = append(variables, &gitlab.PipelineVariable{Key: *v.Key, Value: *v.Value})
+		}
+		return env.gitlab.AddPipeline(path, &fakegitlab.Pipeline{
+			Pipeline: gitlab.Pipeline{
+				Status: string(gitlab.Running),
+				User:   &gitlab.BasicUser{Username: githubBotName},
+			},
+			Variables: append(variables, &gitlab.PipelineVariable{
+				Key:   "INTEGRATION_REV",
+				Value: "pull/2725/head",
+			}),
+		})
+	}
+	stale := addPipeline(integrationPipelinePath, build)
+	otherPR := addPipeline(integrationPipelinePath, &buildOptions{
+		repo:       "integration",
+		pr:         "2726",
+		baseBranch: "master",
+	})
+	otherProject := addPipeline(clientPipelinePath, build)
+
+	client, err := newGitLabClient(traceContext(nil), env.conf)
+	require.NoError(t, err)
+	vars, err := getIntegrationBuildParameters(nil, env.conf, build, nil)
+	require.NoError(t, err)
+	stopStalePipelines(integrationPipelinePath, logrus.NewEntry(logrus.StandardLogger()),
+		client, vars)
+
+	assert.Equal(t, string(gitlab.Canceled), stale.Status)
+	assert.Equal(t, string(gitlab.Running), otherPR.Status)
+	assert.Equal(t, string(gitlab.Running), otherProject.Status)
+}
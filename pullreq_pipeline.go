@@ -10,22 +10,20 @@ import (
 	"github.com/sirupsen/logrus"
 	gitlab "gitlab.com/gitlab-org/api/client-go"
 
-	clientgitlab "github.com/mendersoftware/integration-test-runner/client/gitlab"
 	"github.com/mendersoftware/integration-test-runner/git"
 )
 
+// startPRPipeline starts the pipeline of the pr_ branch, or the merge request
+// pipeline when the branch has a merge request, i.e. mergeRequestIID is set
 func startPRPipeline(
 	log *logrus.Entry,
 	ref string,
+	mergeRequestIID int64,
 	event *github.PullRequestEvent,
 	conf *config,
 	isOrgMember func() bool,
 ) error {
-	client, err := clientgitlab.NewGitLabClient(
-		conf.gitlabToken,
-		conf.gitlabBaseURL,
-		conf.dryRunMode,
-	)
+	client, err := newGitLabClient(traceContext(log), conf)
 	if err != nil {
 		return err
 	}
@@ -55,6 +53,16 @@ func startPRPipeline(
 	}
 	gitlabPath := repoHostURI[1]
 
+	if mergeRequestIID != 0 {
+		// the merge request pipelines get the CI_MERGE_REQUEST_* variables
+		pipeline, err := client.CreateMergeRequestPipeline(gitlabPath, mergeRequestIID)
+		if err != nil {
+			return err
+		}
+		log.Debugf("started merge request pipeline for PR: %s", pipeline.WebURL)
+		return nil
+	}
+
 	ciIIDKey := "CI_EXTERNAL_PULL_REQUEST_IID"
 	ciIID := strconv.Itoa(event.GetNumber())
 	ciSourceRepoKey := "CI_EXTERNAL_PULL_REQUEST_SOURCE_REPOSITORY"
@@ -114,13 +122,14 @@ func syncBranch(
 	repo := pr.GetRepo().GetName()
 	prNum := strconv.Itoa(pr.GetNumber())
 
+	ctx := traceContext(log)
 	tmpdir, err := os.MkdirTemp("", repo)
 	if err != nil {
 		return err
 	}
 	defer os.RemoveAll(tmpdir)
 
-	gitcmd := git.Command("init", ".")
+	gitcmd := git.Command("init", ".").WithContext(ctx)
 	gitcmd.Dir = tmpdir
 	out, err := gitcmd.CombinedOutput()
 	if err != nil {
@@ -128,7 +137,7 @@ func syncBranch(
 	}
 
 	repoURL := getRemoteURLGitHub(conf.githubProtocol, conf.githubOrganization, repo)
-	gitcmd = git.Command("remote", "add", "github", repoURL)
+	gitcmd = git.Command("remote", "add", "github", repoURL).WithContext(ctx)
 	gitcmd.Dir = tmpdir
 	out, err = gitcmd.CombinedOutput()
 	if err != nil {
@@ -140,14 +149,14 @@ func syncBranch(
 		return fmt.Errorf("getRemoteURLGitLab returned error: %s", err.Error())
 	}
 
-	gitcmd = git.Command("remote", "add", "gitlab", remoteURL)
+	gitcmd = git.Command("remote", "add", "gitlab", remoteURL).WithContext(ctx)
 	gitcmd.Dir = tmpdir
 	out, err = gitcmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("%v returned error: %s: %s", gitcmd.Args, out, err.Error())
 	}
 
-	gitcmd = git.Command("fetch", "github", "pull/"+prNum+"/head:"+prBranchName)
+	gitcmd = git.Command("fetch", "github", "pull/"+prNum+"/head:"+prBranchName).WithContext(ctx)
 	gitcmd.Dir = tmpdir
 	out, err = gitcmd.CombinedOutput()
 	if err != nil {
@@ -155,7 +164,9 @@ func syncBranch(
 	}
 
 	// Push but not don't trigger CI (yet)
-	gitcmd = git.Command("push", "-f", "-o", "ci.skip", "--set-upstream", "gitlab", prBranchName)
+	gitcmd = git.Command(
+		"push", "-f", "-o", "ci.skip", "--set-upstream", "gitlab", prBranchName,
+	).WithContext(ctx)
 	gitcmd.Dir = tmpdir
 	out, err = gitcmd.CombinedOutput()
 	if err != nil {
@@ -213,11 +224,7 @@ func deletePRBranch(
 		return nil, err
 	}
 
-	client, err := clientgitlab.NewGitLabClient(
-		conf.gitlabToken,
-		conf.gitlabBaseURL,
-		conf.dryRunMode,
-	)
+	client, err := newGitLabClient(traceContext(log), conf)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mendersoftware/integration-test-runner/logger"
+)
+
+const subcommandReplay = "replay"
+
+const replayUsage = `usage: integration-test-runner replay -event <type> [options] <payload.json>
+
+Processes a recorded GitHub webhook payload in dry-run mode and prints the
+resulting side-effect journal. GITHUB_TOKEN, GITLAB_TOKEN and GITLAB_BASE_URL
+are still needed, as the read-only API calls are performed.
+
+options:
+`
+
+// runReplay implements the replay subcommand: it runs a recorded webhook
+// payload through processGitHubWebhook in dry-run mode, without the need for
+// a webhook secret or an HTTP server, and writes the journal to out
+func runReplay(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet(subcommandReplay, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), replayUsage)
+		flags.PrintDefaults()
+	}
+	eventType := flags.String("event", "",
+		"type of the webhook event, like the X-GitHub-Event header (e.g. pull_request)")
+	deliveryID := flags.String("delivery", "replay", "delivery ID to tag the journal entries with")
+	gitRules := flags.String("git-rules", "",
+		"JSON file with the rules scripting the output of the git commands")
+	format := flags.String("format", logsFormatMessages,
+		"output format: "+logsFormatMessages+" or "+logsFormatEvents)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *eventType == "" || flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("an event type and a payload file are required")
+	}
+	if *format != logsFormatMessages && *format != logsFormatEvents {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	payload, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	webhookEvent, err := github.ParseWebHook(*eventType, payload)
+	if err != nil {
+		return fmt.Errorf("failed to parse the payload: %w", err)
+	}
+
+	conf, requestLogger, flushTracing, err := setupCommand(true, *gitRules)
+	if err != nil {
+		return err
+	}
+	defer flushTracing()
+
+	ctx := newCommandContext(*deliveryID)
+	done := requestLogger.StartDelivery(*deliveryID)
+	err = processGitHubWebhook(ctx, *eventType, webhookEvent, githubClient, conf)
+	done()
+	if err != nil {
+		logrus.Errorf("processing the webhook returned an error: %s", err.Error())
+	}
+
+	return writeJournal(out, requestLogger.Entries(logger.Filter{}), *format)
+}
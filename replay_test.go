@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mendersoftware/integration-test-runner/git"
+	"github.com/mendersoftware/integration-test-runner/logger"
+)
+
+func setupReplayTest(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "github-token")
+	t.Setenv("GITLAB_TOKEN", "gitlab-token")
+	t.Setenv("GITLAB_BASE_URL", "https://gitlab.example.com")
+	previousClient := githubClient
+	t.Cleanup(func() {
+		githubClient = previousClient
+		git.SetDryRunMode(false)
+		git.SetDryRunExecutor(nil)
+		logrus.SetOutput(os.Stderr)
+	})
+}
+
+func TestRunReplay(t *testing.T) {
+	setupReplayTest(t)
+
+	testCases := map[string]struct {
+		args     []string
+		expected []string
+		err      string
+	}{
+		"push": {
+			args: []string{"-event", "push", "tests/tests/payloads/push_cfengine.json"},
+			expected: []string{
+				"info:Fetching branch at depth: 5",
+				"git push -f gitlab master",
+				"info:Pushed ref to GitLab: website:refs/heads/master",
+			},
+		},
+		"missing event type": {
+			args: []string{"tests/tests/payloads/push_cfengine.json"},
+			err:  "an event type and a payload file are required",
+		},
+		"missing payload": {
+			args: []string{"-event", "push", "tests/tests/payloads/missing.json"},
+			err:  "open tests/tests/payloads/missing.json: no such file or directory",
+		},
+		"unknown format": {
+			args: []string{"-event", "push", "-format", "xml",
+				"tests/tests/payloads/push_cfengine.json"},
+			err: `unknown format "xml"`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := runReplay(tc.args, out)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			for _, expected := range tc.expected {
+				assert.Contains(t, out.String(), expected)
+			}
+		})
+	}
+}
+
+func TestRunReplayEvents(t *testing.T) {
+	setupReplayTest(t)
+
+	out := &bytes.Buffer{}
+	err := runReplay([]string{
+		"-event", "push",
+		"-format", "events",
+		"-delivery", "abc",
+		"tests/tests/payloads/push_cfengine.json",
+	}, out)
+	require.NoError(t, err)
+
+	var entries []logger.Entry
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entries))
+	require.NotEmpty(t, entries)
+	pushes := 0
+	for _, entry := range entries {
+		assert.Equal(t, "abc", entry.Delivery)
+		if entry.Kind == logger.KindGit && entry.Args["args"] != nil &&
+			len(entry.Args["args"].([]interface{})) > 0 &&
+			entry.Args["args"].([]interface{})[0] == "push" {
+			pushes++
+		}
+	}
+	assert.Equal(t, 1, pushes)
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"strconv"
@@ -116,11 +115,7 @@ func triggerReviewDeploy(
 	enterprise bool,
 	githubClient clientgithub.Client,
 ) error {
-	client, err := clientgitlab.NewGitLabClient(
-		conf.gitlabToken,
-		conf.gitlabBaseURL,
-		conf.dryRunMode,
-	)
+	client, err := newGitLabClient(traceContext(log), conf)
 	if err != nil {
 		return err
 	}
@@ -198,7 +193,7 @@ func triggerReviewDeployWithClient(
 		Body: &commentBody,
 	}
 
-	if err := githubClient.CreateComment(context.Background(),
+	if err := githubClient.CreateComment(traceContext(log),
 		conf.githubOrganization, repoName, pr.GetNumber(), &comment); err != nil {
 		log.Infof("Failed to comment on the pr: %v, Error: %s", pr, err.Error())
 	}
@@ -213,11 +208,7 @@ func triggerReviewE2E(
 	testEnvironment string,
 	githubClient clientgithub.Client,
 ) error {
-	client, err := clientgitlab.NewGitLabClient(
-		conf.gitlabToken,
-		conf.gitlabBaseURL,
-		conf.dryRunMode,
-	)
+	client, err := newGitLabClient(traceContext(log), conf)
 	if err != nil {
 		return err
 	}
@@ -304,7 +295,7 @@ func triggerReviewE2EWithClient(
 		Body: &commentBody,
 	}
 
-	if err := githubClient.CreateComment(context.Background(),
+	if err := githubClient.CreateComment(traceContext(log),
 		conf.githubOrganization, repoName, pr.GetNumber(), &comment); err != nil {
 		log.Infof("Failed to comment on the pr: %v, Error: %s", pr, err.Error())
 	}
@@ -0,0 +1,120 @@
+// Package fakegit provides local bare repositories standing in for the GitHub
+// and GitLab git remotes, for tests running offline.
+package fakegit
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Remote holds bare repositories under a temporary directory; the GitHub and
+// GitLab remote URLs used by the integration-test-runner are redirected to
+// them through the git "url.<base>.insteadOf" configuration
+type Remote struct {
+	// Root is the directory holding the bare repositories
+	Root string
+
+	t testing.TB
+}
+
+// NewRemote creates a new remote redirecting github.com and gitlab.com for
+// the git commands run during the test; it modifies the environment, hence
+// it can't be used in parallel tests
+func NewRemote(t testing.TB) *Remote {
+	t.Helper()
+	r := &Remote{
+		Root: t.TempDir(),
+		t:    t,
+	}
+	rewrites := map[string]string{
+		"https://github.com/": filepath.Join(r.Root, "github.com") + "/",
+		"git@github.com:/":    filepath.Join(r.Root, "github.com") + "/",
+		"git@gitlab.com:":     filepath.Join(r.Root, "gitlab.com") + "/",
+	}
+	i := 0
+	for from, to := range rewrites {
+		t.Setenv("GIT_CONFIG_KEY_"+strconv.Itoa(i), "url."+to+".insteadOf")
+		t.Setenv("GIT_CONFIG_VALUE_"+strconv.Itoa(i), from)
+		i++
+	}
+	t.Setenv("GIT_CONFIG_COUNT", strconv.Itoa(i))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "Test Author")
+	t.Setenv("GIT_AUTHOR_EMAIL", "author@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test Committer")
+	t.Setenv("GIT_COMMITTER_EMAIL", "committer@example.com")
+	return r
+}
+
+// GitHubRepo returns the directory of the bare repository for a GitHub
+// repository, e.g. GitHubRepo("mendersoftware", "mender")
+func (r *Remote) GitHubRepo(org, repo string) string {
+	return filepath.Join(r.Root, "github.com", org, repo+".git")
+}
+
+// GitLabRepo returns the directory of the bare repository for a GitLab
+// project, e.g. GitLabRepo("Northern.tech/Mender/mender")
+func (r *Remote) GitLabRepo(path string) string {
+	return filepath.Join(r.Root, "gitlab.com", path+".git")
+}
+
+// Init creates an empty bare repository in the given directory
+func (r *Remote) Init(dir string) {
+	r.t.Helper()
+	r.Git("", "init", "--bare", "--quiet", dir)
+	// GitLab accepts push options like "-o ci.skip"
+	r.Git(dir, "config", "receive.advertisePushOptions", "true")
+}
+
+// Commit creates a commit writing content to file on top of ref (which is
+// created if missing) in the bare repository dir, and returns its SHA
+func (r *Remote) Commit(dir, ref, file, content, message string) string {
+	r.t.Helper()
+	if !strings.HasPrefix(ref, "refs/") {
+		ref = "refs/heads/" + ref
+	}
+	work := r.t.TempDir()
+	r.Git(work, "init", "--quiet")
+	if sha := r.RefSHA(dir, ref); sha != "" {
+		r.Git(work, "fetch", "--quiet", dir, ref)
+		r.Git(work, "checkout", "--quiet", "FETCH_HEAD")
+	}
+	path := filepath.Join(work, file)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		r.t.Fatal(err)
+	}
+	r.Git(work, "add", file)
+	r.Git(work, "commit", "--quiet", "-m", message)
+	r.Git(work, "push", "--quiet", dir, "HEAD:"+ref)
+	return r.RefSHA(dir, ref)
+}
+
+// RefSHA returns the SHA a ref points to in the repository dir, or the empty
+// string if the ref doesn't exist
+func (r *Remote) RefSHA(dir, ref string) string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--verify", "--quiet", ref).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Git runs a git command in dir, failing the test on error, and returns its
+// output
+func (r *Remote) Git(dir string, args ...string) string {
+	r.t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %s: %s: %s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
@@ -0,0 +1,554 @@
+// Package fakegithub implements an in-memory fake of the GitHub REST API
+// endpoints used by the integration-test-runner, for tests running offline.
+package fakegithub
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+)
+
+type repository struct {
+	pulls     map[int]*github.PullRequest
+	comments  map[int][]*github.IssueComment
+	labels    map[int][]string
+	assignees map[int][]string
+	reviewers map[int][]string
+	// outsiders are the users who may not be requested to review
+	outsiders map[string]bool
+	reviews   map[int][]*github.PullRequestReview
+	timeline  map[int][]*github.Timeline
+	contents  map[string]string
+	statuses  map[string][]*github.RepoStatus
+}
+
+// Server is a fake GitHub server keeping the state of the repositories in
+// memory
+type Server struct {
+	*httptest.Server
+
+	mutex        sync.Mutex
+	repositories map[string]*repository
+	members      map[string]map[string]bool
+	nextID       int64
+	nextNumber   int
+}
+
+// NewServer starts a new fake GitHub server; call Close when done
+func NewServer() *Server {
+	s := &Server{
+		repositories: make(map[string]*repository),
+		members:      make(map[string]map[string]bool),
+		nextID:       1,
+		nextNumber:   1,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/{owner}/{repo}/issues/{number}/comments", s.listComments)
+	mux.HandleFunc("POST /repos/{owner}/{repo}/issues/{number}/comments", s.createComment)
+	mux.HandleFunc("DELETE /repos/{owner}/{repo}/issues/comments/{id}", s.deleteComment)
+	mux.HandleFunc("POST /repos/{owner}/{repo}/issues/{number}/labels", s.addLabels)
+	mux.HandleFunc("DELETE /repos/{owner}/{repo}/issues/{number}/labels/{name}", s.removeLabel)
+	mux.HandleFunc("POST /repos/{owner}/{repo}/issues/{number}/assignees", s.addAssignees)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/issues/{number}/timeline", s.listTimeline)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/pulls", s.listPulls)
+	mux.HandleFunc("POST /repos/{owner}/{repo}/pulls", s.createPull)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/pulls/{number}", s.getPull)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/pulls/{number}/reviews", s.listReviews)
+	mux.HandleFunc("POST /repos/{owner}/{repo}/pulls/{number}/requested_reviewers",
+		s.requestReviewers)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/contents/{path...}", s.getContents)
+	mux.HandleFunc("POST /repos/{owner}/{repo}/statuses/{ref}", s.createStatus)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/commits/{ref}/statuses", s.listStatuses)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/commits/{ref}/status", s.getCombinedStatus)
+	mux.HandleFunc("GET /orgs/{org}/members/{user}", s.isMember)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// BaseURL returns the base URL of the API, to use as github.Client.BaseURL
+func (s *Server) BaseURL() string {
+	return s.URL + "/"
+}
+
+func (s *Server) repository(owner, repo string) *repository {
+	key := owner + "/" + repo
+	r, ok := s.repositories[key]
+	if !ok {
+		r = &repository{
+			pulls:     make(map[int]*github.PullRequest),
+			comments:  make(map[int][]*github.IssueComment),
+			labels:    make(map[int][]string),
+			assignees: make(map[int][]string),
+			reviewers: make(map[int][]string),
+			outsiders: make(map[string]bool),
+			reviews:   make(map[int][]*github.PullRequestReview),
+			timeline:  make(map[int][]*github.Timeline),
+			contents:  make(map[string]string),
+			statuses:  make(map[string][]*github.RepoStatus),
+		}
+		s.repositories[key] = r
+	}
+	return r
+}
+
+func (s *Server) newID() int64 {
+	id := s.nextID
+	s.nextID++
+	return id
+}
+
+// AddMember makes the user a member of the organization
+func (s *Server) AddMember(org, user string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.members[org] == nil {
+		s.members[org] = make(map[string]bool)
+	}
+	s.members[org][user] = true
+}
+
+// AddOutsideContributor makes the user an outside contributor of the
+// repository, whom the review may not be requested from
+func (s *Server) AddOutsideContributor(owner, repo, user string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.repository(owner, repo).outsiders[user] = true
+}
+
+// AddPullRequest adds a pull request to a repository; the number is assigned
+// if unset
+func (s *Server) AddPullRequest(owner, repo string, pr *github.PullRequest) *github.PullRequest {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if pr.GetNumber() == 0 {
+		pr.Number = github.Int(s.nextNumber)
+	}
+	if pr.GetNumber() >= s.nextNumber {
+		s.nextNumber = pr.GetNumber() + 1
+	}
+	if pr.ID == nil {
+		pr.ID = github.Int64(s.newID())
+	}
+	s.repository(owner, repo).pulls[pr.GetNumber()] = pr
+	return pr
+}
+
+// PullRequests returns the pull requests of a repository
+func (s *Server) PullRequests(owner, repo string) []*github.PullRequest {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	r := s.repository(owner, repo)
+	prs := []*github.PullRequest{}
+	for number := 1; number < s.nextNumber; number++ {
+		if pr, ok := r.pulls[number]; ok {
+			prs = append(prs, pr)
+		}
+	}
+	return prs
+}
+
+// AddComment adds a comment to an issue or pull request
+func (s *Server) AddComment(
+	owner, repo string,
+	number int,
+	comment *github.IssueComment,
+) *github.IssueComment {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if comment.ID == nil {
+		comment.ID = github.Int64(s.newID())
+	}
+	r := s.repository(owner, repo)
+	r.comments[number] = append(r.comments[number], comment)
+	return comment
+}
+
+// Comments returns the comments of an issue or pull request
+func (s *Server) Comments(owner, repo string, number int) []*github.IssueComment {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]*github.IssueComment{}, s.repository(owner, repo).comments[number]...)
+}
+
+// Labels returns the labels of an issue or pull request
+func (s *Server) Labels(owner, repo string, number int) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string{}, s.repository(owner, repo).labels[number]...)
+}
+
+// Assignees returns the assignees of an issue or pull request
+func (s *Server) Assignees(owner, repo string, number int) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string{}, s.repository(owner, repo).assignees[number]...)
+}
+
+// Reviewers returns the users requested to review a pull request
+func (s *Server) Reviewers(owner, repo string, number int) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string{}, s.repository(owner, repo).reviewers[number]...)
+}
+
+// AddReview adds a review to a pull request
+func (s *Server) AddReview(owner, repo string, number int, review *github.PullRequestReview) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if review.ID == nil {
+		review.ID = github.Int64(s.newID())
+	}
+	r := s.repository(owner, repo)
+	r.reviews[number] = append(r.reviews[number], review)
+}
+
+// AddTimelineEvent adds an event to the timeline of an issue or pull request
+func (s *Server) AddTimelineEvent(owner, repo string, number int, event *github.Timeline) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	r := s.repository(owner, repo)
+	r.timeline[number] = append(r.timeline[number], event)
+}
+
+// SetContent sets the content of a file of a repository
+func (s *Server) SetContent(owner, repo, path, content string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.repository(owner, repo).contents[path] = content
+}
+
+// AddStatus sets a commit status on a ref
+func (s *Server) AddStatus(owner, repo, ref string, status *github.RepoStatus) *github.RepoStatus {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if status.ID == nil {
+		status.ID = github.Int64(s.newID())
+	}
+	r := s.repository(owner, repo)
+	r.statuses[ref] = append([]*github.RepoStatus{status}, r.statuses[ref]...)
+	return status
+}
+
+// Statuses returns the commit statuses of a ref, the most recent first
+func (s *Server) Statuses(owner, repo, ref string) []*github.RepoStatus {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]*github.RepoStatus{}, s.repository(owner, repo).statuses[ref]...)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"message": msg})
+}
+
+func number(w http.ResponseWriter, r *http.Request) (int, bool) {
+	n, err := strconv.Atoi(r.PathValue("number"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return 0, false
+	}
+	return n, true
+}
+
+func (s *Server) listComments(w http.ResponseWriter, r *http.Request) {
+	n, ok := number(w, r)
+	if !ok {
+		return
+	}
+	comments := s.Comments(r.PathValue("owner"), r.PathValue("repo"), n)
+	writeJSON(w, http.StatusOK, comments)
+}
+
+func (s *Server) createComment(w http.ResponseWriter, r *http.Request) {
+	n, ok := number(w, r)
+	if !ok {
+		return
+	}
+	comment := &github.IssueComment{}
+	if err := json.NewDecoder(r.Body).Decode(comment); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	now := time.Now()
+	comment.CreatedAt = &now
+	comment = s.AddComment(r.PathValue("owner"), r.PathValue("repo"), n, comment)
+	writeJSON(w, http.StatusCreated, comment)
+}
+
+func (s *Server) deleteComment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	repo := s.repository(r.PathValue("owner"), r.PathValue("repo"))
+	for n, comments := range repo.comments {
+		for i, comment := range comments {
+			if comment.GetID() == id {
+				repo.comments[n] = append(comments[:i:i], comments[i+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+	}
+	writeError(w, http.StatusNotFound, "Not Found")
+}
+
+func (s *Server) addLabels(w http.ResponseWriter, r *http.Request) {
+	n, ok := number(w, r)
+	if !ok {
+		return
+	}
+	var labels []string
+	if err := json.NewDecoder(r.Body).Decode(&labels); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	repo := s.repository(r.PathValue("owner"), r.PathValue("repo"))
+	res := []*github.Label{}
+	for _, label := range labels {
+		found := false
+		for _, existing := range repo.labels[n] {
+			found = found || existing == label
+		}
+		if !found {
+			repo.labels[n] = append(repo.labels[n], label)
+		}
+	}
+	for _, label := range repo.labels[n] {
+		res = append(res, &github.Label{Name: github.String(label)})
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) removeLabel(w http.ResponseWriter, r *http.Request) {
+	n, ok := number(w, r)
+	if !ok {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	repo := s.repository(r.PathValue("owner"), r.PathValue("repo"))
+	for i, label := range repo.labels[n] {
+		if label == r.PathValue("name") {
+			repo.labels[n] = append(repo.labels[n][:i], repo.labels[n][i+1:]...)
+			res := []*github.Label{}
+			for _, label := range repo.labels[n] {
+				res = append(res, &github.Label{Name: github.String(label)})
+			}
+			writeJSON(w, http.StatusOK, res)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Label does not exist")
+}
+
+func (s *Server) addAssignees(w http.ResponseWriter, r *http.Request) {
+	n, ok := number(w, r)
+	if !ok {
+		return
+	}
+	body := struct {
+		Assignees []string `json:"assignees"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	repo := s.repository(r.PathValue("owner"), r.PathValue("repo"))
+	repo.assignees[n] = append(repo.assignees[n], body.Assignees...)
+	issue := &github.Issue{Number: github.Int(n)}
+	for _, login := range repo.assignees[n] {
+		issue.Assignees = append(issue.Assignees, &github.User{Login: github.String(login)})
+	}
+	writeJSON(w, http.StatusCreated, issue)
+}
+
+func (s *Server) listTimeline(w http.ResponseWriter, r *http.Request) {
+	n, ok := number(w, r)
+	if !ok {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	timeline := s.repository(r.PathValue("owner"), r.PathValue("repo")).timeline[n]
+	if timeline == nil {
+		timeline = []*github.Timeline{}
+	}
+	writeJSON(w, http.StatusOK, timeline)
+}
+
+func (s *Server) listPulls(w http.ResponseWriter, r *http.Request) {
+	state := r.URL.Query().Get("state")
+	if state == "" {
+		state = "open"
+	}
+	prs := []*github.PullRequest{}
+	for _, pr := range s.PullRequests(r.PathValue("owner"), r.PathValue("repo")) {
+		if state == "all" || state == pr.GetState() {
+			prs = append(prs, pr)
+		}
+	}
+	writeJSON(w, http.StatusOK, prs)
+}
+
+func (s *Server) createPull(w http.ResponseWriter, r *http.Request) {
+	newPR := &github.NewPullRequest{}
+	if err := json.NewDecoder(r.Body).Decode(newPR); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	owner, repo := r.PathValue("owner"), r.PathValue("repo")
+	now := time.Now()
+	pr := &github.PullRequest{
+		State:     github.String("open"),
+		Title:     newPR.Title,
+		Body:      newPR.Body,
+		Draft:     newPR.Draft,
+		CreatedAt: &now,
+		Head:      &github.PullRequestBranch{Ref: newPR.Head},
+		Base:      &github.PullRequestBranch{Ref: newPR.Base},
+	}
+	pr = s.AddPullRequest(owner, repo, pr)
+	pr.HTMLURL = github.String(fmt.Sprintf("%s/%s/%s/pull/%d", s.URL, owner, repo, pr.GetNumber()))
+	writeJSON(w, http.StatusCreated, pr)
+}
+
+func (s *Server) getPull(w http.ResponseWriter, r *http.Request) {
+	n, ok := number(w, r)
+	if !ok {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	pr, found := s.repository(r.PathValue("owner"), r.PathValue("repo")).pulls[n]
+	if !found {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, http.StatusOK, pr)
+}
+
+func (s *Server) listReviews(w http.ResponseWriter, r *http.Request) {
+	n, ok := number(w, r)
+	if !ok {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	reviews := s.repository(r.PathValue("owner"), r.PathValue("repo")).reviews[n]
+	if reviews == nil {
+		reviews = []*github.PullRequestReview{}
+	}
+	writeJSON(w, http.StatusOK, reviews)
+}
+
+func (s *Server) requestReviewers(w http.ResponseWriter, r *http.Request) {
+	n, ok := number(w, r)
+	if !ok {
+		return
+	}
+	body := github.ReviewersRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	repo := s.repository(r.PathValue("owner"), r.PathValue("repo"))
+	pr, found := repo.pulls[n]
+	if !found {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	for _, login := range body.Reviewers {
+		if repo.outsiders[login] {
+			writeError(w, http.StatusUnprocessableEntity,
+				"Reviews may only be requested from collaborators.")
+			return
+		}
+	}
+	repo.reviewers[n] = append(repo.reviewers[n], body.Reviewers...)
+	pr.RequestedReviewers = nil
+	for _, login := range repo.reviewers[n] {
+		pr.RequestedReviewers = append(pr.RequestedReviewers,
+			&github.User{Login: github.String(login)})
+	}
+	writeJSON(w, http.StatusCreated, pr)
+}
+
+func (s *Server) getContents(w http.ResponseWriter, r *http.Request) {
+	path := r.PathValue("path")
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	content, found := s.repository(r.PathValue("owner"), r.PathValue("repo")).contents[path]
+	if !found {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, http.StatusOK, &github.RepositoryContent{
+		Type:     github.String("file"),
+		Path:     github.String(path),
+		Encoding: github.String("base64"),
+		Content:  github.String(base64.StdEncoding.EncodeToString([]byte(content))),
+	})
+}
+
+func (s *Server) isMember(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.members[r.PathValue("org")][r.PathValue("user")] {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+func (s *Server) createStatus(w http.ResponseWriter, r *http.Request) {
+	status := &github.RepoStatus{}
+	if err := json.NewDecoder(r.Body).Decode(status); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	now := time.Now()
+	status.CreatedAt = &now
+	status = s.AddStatus(r.PathValue("owner"), r.PathValue("repo"), r.PathValue("ref"), status)
+	writeJSON(w, http.StatusCreated, status)
+}
+
+func (s *Server) listStatuses(w http.ResponseWriter, r *http.Request) {
+	statuses := s.Statuses(r.PathValue("owner"), r.PathValue("repo"), r.PathValue("ref"))
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+func (s *Server) getCombinedStatus(w http.ResponseWriter, r *http.Request) {
+	ref := r.PathValue("ref")
+	combined := &github.CombinedStatus{SHA: github.String(ref), Statuses: []github.RepoStatus{}}
+	contexts := map[string]bool{}
+	for _, status := range s.Statuses(r.PathValue("owner"), r.PathValue("repo"), ref) {
+		if !contexts[status.GetContext()] {
+			contexts[status.GetContext()] = true
+			combined.Statuses = append(combined.Statuses, *status)
+		}
+	}
+	combined.TotalCount = github.Int(len(combined.Statuses))
+	writeJSON(w, http.StatusOK, combined)
+}
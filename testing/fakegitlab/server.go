@@ -0,0 +1,557 @@
+// Package fakegitlab implements an in-memory fake of the GitLab REST API
+// endpoints used by the integration-test-runner, for tests running offline.
+package fakegitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// Pipeline is a pipeline of a project together with its variables and jobs
+type Pipeline struct {
+	gitlab.Pipeline
+	Variables []*gitlab.PipelineVariable
+	Jobs      []*gitlab.Job
+}
+
+type project struct {
+	pipelines         []*Pipeline
+	protectedBranches map[string]*gitlab.ProtectedBranch
+	branches          map[string]bool
+	mergeRequests     []*gitlab.MergeRequest
+}
+
+// Server is a fake GitLab server keeping the state of the projects in memory
+type Server struct {
+	*httptest.Server
+
+	// Username is the user creating the pipelines
+	Username string
+
+	mutex    sync.Mutex
+	projects map[string]*project
+	nextID   int64
+}
+
+// NewServer starts a new fake GitLab server; call Close when done
+func NewServer() *Server {
+	s := &Server{
+		Username: "mender-test-bot",
+		projects: make(map[string]*project),
+		nextID:   1,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v4/projects/{id}/pipeline", s.createPipeline)
+	mux.HandleFunc("GET /api/v4/projects/{id}/pipelines", s.listPipelines)
+	mux.HandleFunc("GET /api/v4/projects/{id}/pipelines/{pid}/variables", s.getVariables)
+	mux.HandleFunc("POST /api/v4/projects/{id}/pipelines/{pid}/cancel", s.cancelPipeline)
+	mux.HandleFunc("GET /api/v4/projects/{id}/pipelines/{pid}/jobs", s.listJobs)
+	mux.HandleFunc("POST /api/v4/projects/{id}/jobs/{jid}/play", s.playJob)
+	mux.HandleFunc("POST /api/v4/projects/{id}/protected_branches", s.protectBranch)
+	mux.HandleFunc("DELETE /api/v4/projects/{id}/protected_branches/{name}", s.unprotectBranch)
+	mux.HandleFunc("DELETE /api/v4/projects/{id}/repository/branches/{name}", s.deleteBranch)
+	mux.HandleFunc("GET /api/v4/projects/{id}/merge_requests", s.listMergeRequests)
+	mux.HandleFunc("POST /api/v4/projects/{id}/merge_requests", s.createMergeRequest)
+	mux.HandleFunc("PUT /api/v4/projects/{id}/merge_requests/{iid}", s.updateMergeRequest)
+	mux.HandleFunc("POST /api/v4/projects/{id}/merge_requests/{iid}/pipelines",
+		s.createMergeRequestPipeline)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// BaseURL returns the base URL of the API, to use with gitlab.WithBaseURL
+func (s *Server) BaseURL() string {
+	return s.URL + "/api/v4"
+}
+
+func (s *Server) project(path string) *project {
+	p, ok := s.projects[path]
+	if !ok {
+		p = &project{
+			protectedBranches: make(map[string]*gitlab.ProtectedBranch),
+			branches:          make(map[string]bool),
+		}
+		s.projects[path] = p
+	}
+	return p
+}
+
+func (s *Server) newID() int64 {
+	id := s.nextID
+	s.nextID++
+	return id
+}
+
+// AddPipeline adds a pipeline to a project, assigning its ID if unset
+func (s *Server) AddPipeline(path string, pipeline *Pipeline) *Pipeline {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if pipeline.ID == 0 {
+		pipeline.ID = s.newID()
+	}
+	for _, job := range pipeline.Jobs {
+		if job.ID == 0 {
+			job.ID = s.newID()
+		}
+	}
+	p := s.project(path)
+	p.pipelines = append(p.pipelines, pipeline)
+	return pipeline
+}
+
+// Pipelines returns the pipelines of a project, oldest first
+func (s *Server) Pipelines(path string) []*Pipeline {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]*Pipeline{}, s.project(path).pipelines...)
+}
+
+// SetPipelineStatus sets the status of a pipeline
+func (s *Server) SetPipelineStatus(path string, id int64, status string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if pipeline := s.findPipeline(path, id); pipeline != nil {
+		pipeline.Status = status
+	}
+}
+
+// AddBranch adds a branch to a project
+func (s *Server) AddBranch(path, branch string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.project(path).branches[branch] = true
+}
+
+// Branches returns the sorted branches of a project
+func (s *Server) Branches(path string) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return sortedKeys(s.project(path).branches)
+}
+
+// ProtectedBranches returns the sorted protected branches of a project
+func (s *Server) ProtectedBranches(path string) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	names := make(map[string]bool)
+	for name := range s.project(path).protectedBranches {
+		names[name] = true
+	}
+	return sortedKeys(names)
+}
+
+// MergeRequests returns the merge requests of a project, oldest first
+func (s *Server) MergeRequests(path string) []*gitlab.MergeRequest {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]*gitlab.MergeRequest{}, s.project(path).mergeRequests...)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Server) findMergeRequest(path string, iid int64) *gitlab.MergeRequest {
+	for _, mergeRequest := range s.project(path).mergeRequests {
+		if mergeRequest.IID == iid {
+			return mergeRequest
+		}
+	}
+	return nil
+}
+
+func (s *Server) findPipeline(path string, id int64) *Pipeline {
+	for _, pipeline := range s.project(path).pipelines {
+		if pipeline.ID == id {
+			return pipeline
+		}
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"message": msg})
+}
+
+func pathInt(r *http.Request, name string) (int64, error) {
+	return strconv.ParseInt(r.PathValue(name), 10, 64)
+}
+
+func (s *Server) createPipeline(w http.ResponseWriter, r *http.Request) {
+	var options gitlab.CreatePipelineOptions
+	if err := json.NewDecoder(r.Body).Decode(&options); err != nil || options.Ref == nil {
+		writeError(w, http.StatusBadRequest, "ref is missing")
+		return
+	}
+	path := r.PathValue("id")
+	now := time.Now()
+	pipeline := &Pipeline{}
+	pipeline.Ref = *options.Ref
+	pipeline.Status = string(gitlab.Pending)
+	pipeline.Source = "api"
+	pipeline.CreatedAt = &now
+	pipeline.User = &gitlab.BasicUser{Username: s.Username}
+	if options.Variables != nil {
+		for _, variable := range *options.Variables {
+			v := &gitlab.PipelineVariable{VariableType: "env_var"}
+			if variable.Key != nil {
+				v.Key = *variable.Key
+			}
+			if variable.Value != nil {
+				v.Value = *variable.Value
+			}
+			pipeline.Variables = append(pipeline.Variables, v)
+		}
+	}
+
+	s.mutex.Lock()
+	pipeline.ID = s.newID()
+	pipeline.IID = pipeline.ID
+	pipeline.WebURL = fmt.Sprintf("%s/%s/-/pipelines/%d", s.URL, path, pipeline.ID)
+	p := s.project(path)
+	p.pipelines = append(p.pipelines, pipeline)
+	res := pipeline.Pipeline
+	s.mutex.Unlock()
+
+	writeJSON(w, http.StatusCreated, res)
+}
+
+func (s *Server) listPipelines(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	pipelines := s.project(r.PathValue("id")).pipelines
+	res := []*gitlab.PipelineInfo{}
+	// newest first, like GitLab does
+	for i := len(pipelines) - 1; i >= 0; i-- {
+		pipeline := pipelines[i]
+		if status := query.Get("status"); status != "" && status != pipeline.Status {
+			continue
+		}
+		if ref := query.Get("ref"); ref != "" && ref != pipeline.Ref {
+			continue
+		}
+		if username := query.Get("username"); username != "" &&
+			(pipeline.User == nil || pipeline.User.Username != username) {
+			continue
+		}
+		res = append(res, &gitlab.PipelineInfo{
+			ID:        pipeline.ID,
+			IID:       pipeline.IID,
+			ProjectID: pipeline.ProjectID,
+			Status:    pipeline.Status,
+			Source:    string(pipeline.Source),
+			Ref:       pipeline.Ref,
+			SHA:       pipeline.SHA,
+			WebURL:    pipeline.WebURL,
+			CreatedAt: pipeline.CreatedAt,
+		})
+		if perPage, _ := strconv.Atoi(query.Get("per_page")); perPage > 0 && len(res) == perPage {
+			break
+		}
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) getVariables(w http.ResponseWriter, r *http.Request) {
+	id, err := pathInt(r, "pid")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	pipeline := s.findPipeline(r.PathValue("id"), id)
+	if pipeline == nil {
+		writeError(w, http.StatusNotFound, "404 Not found")
+		return
+	}
+	variables := pipeline.Variables
+	if variables == nil {
+		variables = []*gitlab.PipelineVariable{}
+	}
+	writeJSON(w, http.StatusOK, variables)
+}
+
+func (s *Server) cancelPipeline(w http.ResponseWriter, r *http.Request) {
+	id, err := pathInt(r, "pid")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	pipeline := s.findPipeline(r.PathValue("id"), id)
+	if pipeline == nil {
+		writeError(w, http.StatusNotFound, "404 Not found")
+		return
+	}
+	pipeline.Status = string(gitlab.Canceled)
+	writeJSON(w, http.StatusOK, pipeline.Pipeline)
+}
+
+func (s *Server) listJobs(w http.ResponseWriter, r *http.Request) {
+	id, err := pathInt(r, "pid")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	pipeline := s.findPipeline(r.PathValue("id"), id)
+	if pipeline == nil {
+		writeError(w, http.StatusNotFound, "404 Not found")
+		return
+	}
+	jobs := pipeline.Jobs
+	if jobs == nil {
+		jobs = []*gitlab.Job{}
+	}
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+func (s *Server) playJob(w http.ResponseWriter, r *http.Request) {
+	id, err := pathInt(r, "jid")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, pipeline := range s.project(r.PathValue("id")).pipelines {
+		for _, job := range pipeline.Jobs {
+			if job.ID != id {
+				continue
+			}
+			if job.Status != "manual" {
+				writeError(w, http.StatusBadRequest, "400 Unplayable Job")
+				return
+			}
+			job.Status = "pending"
+			writeJSON(w, http.StatusOK, job)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "404 Job Not Found")
+}
+
+func (s *Server) protectBranch(w http.ResponseWriter, r *http.Request) {
+	var options gitlab.ProtectRepositoryBranchesOptions
+	if err := json.NewDecoder(r.Body).Decode(&options); err != nil || options.Name == nil {
+		writeError(w, http.StatusBadRequest, "name is missing")
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	p := s.project(r.PathValue("id"))
+	if _, exists := p.protectedBranches[*options.Name]; exists {
+		writeError(w, http.StatusConflict, "Protected branch '"+*options.Name+"' already exists")
+		return
+	}
+	branch := &gitlab.ProtectedBranch{
+		ID:   s.newID(),
+		Name: *options.Name,
+	}
+	if options.AllowForcePush != nil {
+		branch.AllowForcePush = *options.AllowForcePush
+	}
+	p.protectedBranches[branch.Name] = branch
+	writeJSON(w, http.StatusCreated, branch)
+}
+
+func (s *Server) unprotectBranch(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	p := s.project(r.PathValue("id"))
+	name := r.PathValue("name")
+	if _, exists := p.protectedBranches[name]; !exists {
+		writeError(w, http.StatusNotFound, "404 Not found")
+		return
+	}
+	delete(p.protectedBranches, name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteBranch(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	p := s.project(r.PathValue("id"))
+	name := r.PathValue("name")
+	if !p.branches[name] {
+		writeError(w, http.StatusNotFound, "404 Branch Not Found")
+		return
+	}
+	delete(p.branches, name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// labels returns the labels of the options, sent comma separated
+func labels(options *gitlab.LabelOptions) gitlab.Labels {
+	res := gitlab.Labels{}
+	for _, label := range *options {
+		for _, name := range strings.Split(label, ",") {
+			if name != "" {
+				res = append(res, name)
+			}
+		}
+	}
+	return res
+}
+
+func (s *Server) listMergeRequests(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	res := []*gitlab.BasicMergeRequest{}
+	for _, mergeRequest := range s.project(r.PathValue("id")).mergeRequests {
+		if state := query.Get("state"); state != "" && state != "all" &&
+			state != mergeRequest.State {
+			continue
+		}
+		if source := query.Get("source_branch"); source != "" &&
+			source != mergeRequest.SourceBranch {
+			continue
+		}
+		if target := query.Get("target_branch"); target != "" &&
+			target != mergeRequest.TargetBranch {
+			continue
+		}
+		res = append(res, &mergeRequest.BasicMergeRequest)
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) createMergeRequest(w http.ResponseWriter, r *http.Request) {
+	var options gitlab.CreateMergeRequestOptions
+	if err := json.NewDecoder(r.Body).Decode(&options); err != nil ||
+		options.SourceBranch == nil || options.TargetBranch == nil || options.Title == nil {
+		writeError(w, http.StatusBadRequest, "source_branch, target_branch or title is missing")
+		return
+	}
+	path := r.PathValue("id")
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	p := s.project(path)
+	for _, mergeRequest := range p.mergeRequests {
+		if mergeRequest.State == "opened" && mergeRequest.SourceBranch == *options.SourceBranch &&
+			mergeRequest.TargetBranch == *options.TargetBranch {
+			writeError(w, http.StatusConflict,
+				"Another open merge request already exists for this source branch")
+			return
+		}
+	}
+	now := time.Now()
+	mergeRequest := &gitlab.MergeRequest{}
+	mergeRequest.ID = s.newID()
+	mergeRequest.IID = int64(len(p.mergeRequests) + 1)
+	mergeRequest.Title = *options.Title
+	mergeRequest.SourceBranch = *options.SourceBranch
+	mergeRequest.TargetBranch = *options.TargetBranch
+	mergeRequest.State = "opened"
+	mergeRequest.CreatedAt = &now
+	mergeRequest.Author = &gitlab.BasicUser{Username: s.Username}
+	mergeRequest.WebURL = fmt.Sprintf("%s/%s/-/merge_requests/%d", s.URL, path, mergeRequest.IID)
+	if options.Description != nil {
+		mergeRequest.Description = *options.Description
+	}
+	if options.Labels != nil {
+		mergeRequest.Labels = labels(options.Labels)
+	}
+	p.mergeRequests = append(p.mergeRequests, mergeRequest)
+	writeJSON(w, http.StatusCreated, mergeRequest)
+}
+
+func (s *Server) updateMergeRequest(w http.ResponseWriter, r *http.Request) {
+	iid, err := pathInt(r, "iid")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var options gitlab.UpdateMergeRequestOptions
+	if err := json.NewDecoder(r.Body).Decode(&options); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	mergeRequest := s.findMergeRequest(r.PathValue("id"), iid)
+	if mergeRequest == nil {
+		writeError(w, http.StatusNotFound, "404 Not found")
+		return
+	}
+	if options.Title != nil {
+		mergeRequest.Title = *options.Title
+	}
+	if options.Description != nil {
+		mergeRequest.Description = *options.Description
+	}
+	if options.Labels != nil {
+		mergeRequest.Labels = labels(options.Labels)
+	}
+	if options.StateEvent != nil {
+		switch *options.StateEvent {
+		case "close":
+			mergeRequest.State = "closed"
+		case "reopen":
+			mergeRequest.State = "opened"
+		}
+	}
+	writeJSON(w, http.StatusOK, mergeRequest)
+}
+
+func (s *Server) createMergeRequestPipeline(w http.ResponseWriter, r *http.Request) {
+	iid, err := pathInt(r, "iid")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	path := r.PathValue("id")
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	mergeRequest := s.findMergeRequest(path, iid)
+	if mergeRequest == nil {
+		writeError(w, http.StatusNotFound, "404 Not found")
+		return
+	}
+	now := time.Now()
+	pipeline := &Pipeline{}
+	pipeline.ID = s.newID()
+	pipeline.IID = pipeline.ID
+	pipeline.Ref = fmt.Sprintf("refs/merge-requests/%d/head", iid)
+	pipeline.Status = string(gitlab.Pending)
+	pipeline.Source = "merge_request_event"
+	pipeline.CreatedAt = &now
+	pipeline.User = &gitlab.BasicUser{Username: s.Username}
+	pipeline.WebURL = fmt.Sprintf("%s/%s/-/pipelines/%d", s.URL, path, pipeline.ID)
+	p := s.project(path)
+	p.pipelines = append(p.pipelines, pipeline)
+	writeJSON(w, http.StatusCreated, &gitlab.PipelineInfo{
+		ID:        pipeline.ID,
+		IID:       pipeline.IID,
+		Status:    pipeline.Status,
+		Source:    string(pipeline.Source),
+		Ref:       pipeline.Ref,
+		WebURL:    pipeline.WebURL,
+		CreatedAt: pipeline.CreatedAt,
+	})
+}
@@ -12,14 +12,41 @@
 #    See the License for the specific language governing permissions and
 #    limitations under the License.
 
+import re
+
 import pytest
 import requests
 
 INTEGRATION_TEST_RUNNER_URI = "http://integration-test-runner:8080"
 
+TMPDIR_PATTERN = re.compile(r"/tmp/[^/\s\"']+")
+IGNORED_EVENT_FIELDS = ("time",)
+
+
+def normalize_events(events):
+    """Prepare the structured log events for the golden file comparison:
+    drop the fields which change on every run (timestamps) and replace the
+    names of the temporary directories with a placeholder."""
+
+    def normalize(value):
+        if isinstance(value, dict):
+            return {k: normalize(v) for k, v in value.items()}
+        if isinstance(value, list):
+            return [normalize(v) for v in value]
+        if isinstance(value, str):
+            return TMPDIR_PATTERN.sub("<tmpdir>", value)
+        return value
+
+    return [
+        normalize({k: v for k, v in event.items() if k not in IGNORED_EVENT_FIELDS})
+        for event in events
+    ]
+
 
 @pytest.fixture(scope="function")
 def integration_test_runner_url():
     res = requests.delete(INTEGRATION_TEST_RUNNER_URI + "/logs")
     assert res.status_code == 204
+    res = requests.delete(INTEGRATION_TEST_RUNNER_URI + "/git-rules")
+    assert res.status_code == 204
     yield INTEGRATION_TEST_RUNNER_URI
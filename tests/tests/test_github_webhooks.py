@@ -18,6 +18,8 @@ import time
 
 import pytest
 
+from conftest import normalize_events
+
 BASE_DIR = os.path.dirname(__file__)
 
 
@@ -26,6 +28,16 @@ def load_payload(filename):
         return f.read()
 
 
+def load_git_rules(base_url, golden):
+    """Script the output of the git commands with the golden file's git rules, if any."""
+    filename = golden.get("git_rules")
+    if filename is None:
+        return
+    with open(os.path.join(BASE_DIR, "git-rules", filename), "rb") as f:
+        res = requests.put(base_url + "/git-rules", data=f.read())
+    assert res.status_code == 204
+
+
 @pytest.mark.golden_test(
     "golden-files/test_pull_request_opened_from_fork_to_mender_qa_by_outsider.yml"
 )
@@ -139,6 +151,26 @@ def test_push_cfengine(golden, integration_test_runner_url):
     assert res.json() == golden.out["output"]
 
 
+@pytest.mark.golden_test("golden-files/test_push_cfengine_events.yml")
+def test_push_cfengine_events(golden, integration_test_runner_url):
+    res = requests.post(
+        integration_test_runner_url + "/",
+        data=load_payload(golden["input"]),
+        headers={
+            "Content-Type": "application/json",
+            "X-Github-Event": "push",
+            "X-Github-Delivery": "delivery",
+        },
+    )
+    assert res.status_code == 202
+    #
+    res = requests.get(
+        integration_test_runner_url + "/logs", params={"format": "events"}
+    )
+    assert res.status_code == 200
+    assert normalize_events(res.json()) == golden.out["output"]
+
+
 @pytest.mark.golden_test("golden-files/test_push_mender_qa_repo.yml")
 def test_push_mender_qa_repo(golden, integration_test_runner_url):
     res = requests.post(
@@ -249,6 +281,7 @@ def test_cherrypick_singleline(golden, integration_test_runner_url):
 
 @pytest.mark.golden_test("golden-files/test_issue_comment_conventional_commit.yml")
 def test_conventional_commit(golden, integration_test_runner_url):
+    load_git_rules(integration_test_runner_url, golden)
     res = requests.post(
         integration_test_runner_url + "/",
         data=load_payload(golden["input"]),
@@ -0,0 +1,98 @@
+// Package tracing sets up the OpenTelemetry tracing of the
+// integration-test-runner
+package tracing
+
+import (
+	"context"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	serviceName = "integration-test-runner"
+	tracerName  = "github.com/mendersoftware/integration-test-runner"
+)
+
+// ContextKey is the key under which a context carrying the span of a
+// webhook delivery is stored in a key-value store such as the gin.Context,
+// which does not let the span be set as its own value
+const ContextKey = "tracing.context"
+
+// Config configures the export of the traces
+type Config struct {
+	// Endpoint is the URL of the OTLP/HTTP collector, e.g.
+	// http://otel-collector:4318
+	Endpoint string
+	// Stdout, if set and no endpoint is configured, receives the traces
+	// as pretty printed JSON, to inspect them locally
+	Stdout io.Writer
+}
+
+// Setup installs the global tracer provider exporting the traces according
+// to the configuration; without endpoint and stdout tracing is disabled.
+// The returned function flushes the pending spans and stops the exporter.
+func Setup(ctx context.Context, conf Config) (func(context.Context) error, error) {
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+	switch {
+	case conf.Endpoint != "":
+		exporter, err = otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(conf.Endpoint))
+	case conf.Stdout != nil:
+		exporter, err = stdouttrace.New(
+			stdouttrace.WithWriter(conf.Stdout),
+			stdouttrace.WithPrettyPrint(),
+		)
+	default:
+		return func(context.Context) error { return nil }, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", serviceName),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
+
+// Start starts a span as a child of the span carried by ctx, or of the
+// span stored under ContextKey; a nil ctx starts a root span
+func Start(
+	ctx context.Context,
+	name string,
+	attrs ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	} else if !trace.SpanContextFromContext(ctx).IsValid() {
+		if parent, ok := ctx.Value(ContextKey).(context.Context); ok {
+			ctx = trace.ContextWithSpan(ctx, trace.SpanFromContext(parent))
+		}
+	}
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records the error, if any, on the span and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
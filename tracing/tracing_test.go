@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// keyValueContext stores values by string keys, like the gin.Context
+type keyValueContext struct {
+	context.Context
+	values map[string]interface{}
+}
+
+func (c *keyValueContext) Value(key interface{}) interface{} {
+	if key, ok := key.(string); ok {
+		return c.values[key]
+	}
+	return c.Context.Value(key)
+}
+
+func setupRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestStart(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	rootCtx, root := Start(context.Background(), "webhook push")
+	ctx := &keyValueContext{
+		Context: context.Background(),
+		values:  map[string]interface{}{ContextKey: rootCtx},
+	}
+	_, child := Start(ctx, "git fetch")
+	End(child, errors.New("exit status 128"))
+	_, orphan := Start(nil, "git init")
+	End(orphan, nil)
+	End(root, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "git fetch", spans[0].Name())
+	assert.Equal(t, root.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "exit status 128", spans[0].Status().Description)
+	assert.Equal(t, "git init", spans[1].Name())
+	assert.False(t, spans[1].Parent().IsValid())
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}
+
+func TestSetupDisabled(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Config{})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestSetupStdout(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	out := &bytes.Buffer{}
+	shutdown, err := Setup(context.Background(), Config{Stdout: out})
+	require.NoError(t, err)
+	_, span := Start(context.Background(), "exec release_tool.py")
+	End(span, nil)
+	require.NoError(t, shutdown(context.Background()))
+
+	assert.Contains(t, out.String(), `"Name": "exec release_tool.py"`)
+	assert.Contains(t, out.String(), `"Value": "integration-test-runner"`)
+}
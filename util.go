@@ -1,9 +1,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/google/go-github/v28/github"
+	"github.com/sirupsen/logrus"
+
+	clientgithub "github.com/mendersoftware/integration-test-runner/client/github"
+	clientgitlab "github.com/mendersoftware/integration-test-runner/client/gitlab"
 )
 
 type gitProtocol int
@@ -13,6 +18,50 @@ const (
 	gitProtocolHTTP
 )
 
+// newGitHubClient returns the instrumented GitHub client for the given conf
+func newGitHubClient(conf *config) (clientgithub.Client, error) {
+	client := clientgithub.NewGitHubClient(conf.githubToken, conf.dryRunMode)
+	if conf.githubBaseURL != "" {
+		var err error
+		client, err = clientgithub.NewGitHubClientWithBaseURL(
+			conf.githubToken,
+			conf.githubBaseURL,
+			conf.dryRunMode,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return clientgithub.NewInstrumentedClient(client), nil
+}
+
+// newGitLabClient returns the instrumented GitLab client for the given conf,
+// retrying the failed calls and tracing them under the span carried by ctx
+func newGitLabClient(ctx context.Context, conf *config) (clientgitlab.Client, error) {
+	client, err := clientgitlab.NewGitLabClient(
+		conf.gitlabToken,
+		conf.gitlabBaseURL,
+		conf.dryRunMode,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return clientgitlab.NewRetryingClient(
+		ctx,
+		clientgitlab.NewInstrumentedClient(ctx, client),
+		conf.gitlabRetryPolicy,
+	), nil
+}
+
+// traceContext returns the context carrying the span of the delivery the
+// logger was created for, see getCustomLoggerFromContext
+func traceContext(log *logrus.Entry) context.Context {
+	if log == nil || log.Context == nil {
+		return context.Background()
+	}
+	return log.Context
+}
+
 func getRemoteURLGitHub(proto gitProtocol, org, repo string) string {
 	if proto == gitProtocolSSH {
 		return "git@github.com:/" + org + "/" + repo + ".git"
@@ -63,3 +112,12 @@ func getGitHubOrganization(webhookType string, webhookEvent interface{}) (string
 	)
 
 }
+
+// getWebhookAction returns the action of a webhook event, or the empty
+// string for the events without action (e.g. push)
+func getWebhookAction(webhookEvent interface{}) string {
+	if event, ok := webhookEvent.(interface{ GetAction() string }); ok {
+		return event.GetAction()
+	}
+	return ""
+}